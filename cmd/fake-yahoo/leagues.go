@@ -0,0 +1,282 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+
+	"github.com/Forestmb/goff"
+	"github.com/Forestmb/power-league/offline"
+)
+
+// startingPositions are the roster positions filled by the players who score
+// points for each team
+var startingPositions = []string{"QB", "WR", "WR", "RB", "RB", "TE", "W/R/T", "K", "DEF"}
+
+// benchPositions are the positions of the players on the bench of each team
+var benchPositions = []string{"QB", "WR", "WR", "RB", "RB", "TE"}
+
+// fakeLeague is a league served by the fake Yahoo API. Its data comes from an
+// offline league, with the keys of the league and its teams changed to match
+// the ones used by Yahoo.
+type fakeLeague struct {
+	gameKey string
+	league  *goff.League
+	client  *offline.Client
+
+	// Players on each team for each week, by team key and then week
+	rosters map[string][][]goff.Player
+
+	// Team managed by the user logged in to the fake Yahoo API
+	ownedTeamKey string
+}
+
+// generateLeague creates an offline league where each team plays every other
+// team in turn. Every team has a strength of its own that its score in each
+// week varies around, and teams are projected to score their strength in
+// weeks that haven't been played.
+func generateLeague(
+	r *rand.Rand,
+	name string,
+	season string,
+	teams int,
+	weeks int,
+	played int,
+	playoffStartWeek int) (*offline.League, error) {
+
+	if teams < 2 || teams%2 != 0 {
+		return nil, fmt.Errorf("leagues need an even number of teams, not %d", teams)
+	}
+
+	league := &offline.League{
+		Name:             name,
+		Season:           season,
+		EndWeek:          weeks,
+		PlayoffStartWeek: playoffStartWeek,
+		Teams:            make([]offline.Team, teams),
+		Weeks:            make([]offline.Week, weeks),
+	}
+	strengths := make([]float64, teams)
+	for i := range league.Teams {
+		league.Teams[i] = offline.Team{
+			Key:     fmt.Sprintf("%d", i+1),
+			Name:    fmt.Sprintf("Team %d", i+1),
+			Manager: fmt.Sprintf("Manager %d", i+1),
+		}
+		strengths[i] = 100.0 + r.NormFloat64()*10.0
+	}
+
+	for week := range league.Weeks {
+		w := offline.Week{
+			Scores:    make(map[string]float64),
+			Projected: make(map[string]float64),
+		}
+		for i, team := range league.Teams {
+			if week < played {
+				w.Scores[team.Key] = roundPoints(strengths[i] + r.NormFloat64()*20.0)
+			} else {
+				w.Projected[team.Key] = roundPoints(strengths[i])
+			}
+		}
+		for _, pair := range roundRobin(teams, week) {
+			w.Matchups = append(w.Matchups, []string{
+				league.Teams[pair[0]].Key,
+				league.Teams[pair[1]].Key,
+			})
+		}
+		league.Weeks[week] = w
+	}
+	return league, nil
+}
+
+// roundRobin returns the pairs of teams, by index, that play each other in a
+// week so that every team plays every other team once before any rematches
+func roundRobin(teams int, week int) [][2]int {
+	round := week % (teams - 1)
+	// Team 0 stays in place while the others rotate around it
+	order := make([]int, teams)
+	for i := range order {
+		if i == 0 {
+			order[i] = 0
+		} else {
+			order[i] = (i-1+round)%(teams-1) + 1
+		}
+	}
+
+	pairs := make([][2]int, teams/2)
+	for i := range pairs {
+		pairs[i] = [2]int{order[i], order[teams-1-i]}
+	}
+	return pairs
+}
+
+// newFakeLeague creates the league with the given number in a Yahoo game from
+// an offline league, generating the roster of each team for each week
+func newFakeLeague(
+	r *rand.Rand,
+	gameKey string,
+	number int,
+	l *offline.League) (*fakeLeague, error) {
+
+	leagueKey := fmt.Sprintf("%s.l.%d", gameKey, number)
+	teamKeys := make(map[string]string)
+	for i := range l.Teams {
+		key := l.Teams[i].Key
+		if key == "" {
+			key = l.Teams[i].Name
+		}
+		teamKeys[key] = fmt.Sprintf("%s.t.%d", leagueKey, i+1)
+		l.Teams[i].Key = teamKeys[key]
+	}
+	for i, week := range l.Weeks {
+		l.Weeks[i].Scores = renameTeams(week.Scores, teamKeys)
+		l.Weeks[i].Projected = renameTeams(week.Projected, teamKeys)
+		for _, matchup := range week.Matchups {
+			for j := range matchup {
+				if key, ok := teamKeys[matchup[j]]; ok {
+					matchup[j] = key
+				}
+			}
+		}
+	}
+
+	client, err := offline.NewClient(l)
+	if err != nil {
+		return nil, err
+	}
+	league := client.League()
+	league.LeagueKey = leagueKey
+	league.LeagueID = uint64(number)
+	league.Settings.ScoringType = "headpoint"
+
+	f := &fakeLeague{
+		gameKey: gameKey,
+		league:  league,
+		client:  client,
+		rosters: make(map[string][][]goff.Player),
+
+		ownedTeamKey: l.Teams[0].Key,
+	}
+	for teamIndex, team := range l.Teams {
+		f.rosters[team.Key] = make([][]goff.Player, league.EndWeek)
+		for week := 1; week <= league.EndWeek; week++ {
+			score := 0.0
+			if week <= len(l.Weeks) {
+				score = l.Weeks[week-1].Scores[team.Key]
+			}
+			f.rosters[team.Key][week-1] = generateRoster(
+				r,
+				gameKey,
+				number,
+				teamIndex+1,
+				team.Name,
+				week,
+				score)
+		}
+	}
+	return f, nil
+}
+
+// generateRoster creates the players on a team for a week, splitting the
+// points the team scored between its starting players. The same players are
+// on the team every week.
+func generateRoster(
+	r *rand.Rand,
+	gameKey string,
+	leagueNumber int,
+	teamNumber int,
+	teamName string,
+	week int,
+	score float64) []goff.Player {
+
+	weights := make([]float64, len(startingPositions))
+	totalWeight := 0.0
+	for i := range weights {
+		weights[i] = 0.5 + r.Float64()
+		totalWeight += weights[i]
+	}
+
+	players := make([]goff.Player, 0, len(startingPositions)+len(benchPositions))
+	remaining := score
+	for i, position := range startingPositions {
+		points := roundPoints(score * weights[i] / totalWeight)
+		if i == len(startingPositions)-1 {
+			points = roundPoints(remaining)
+		}
+		remaining -= points
+
+		playerPosition := position
+		if position == "W/R/T" {
+			playerPosition = "WR"
+		}
+		players = append(players, newPlayer(
+			gameKey, leagueNumber, teamNumber, len(players), teamName,
+			playerPosition, position, week, points))
+	}
+	for _, position := range benchPositions {
+		points := 0.0
+		if score != 0.0 {
+			points = roundPoints(r.Float64() * 2.0 * score / float64(len(startingPositions)))
+		}
+		players = append(players, newPlayer(
+			gameKey, leagueNumber, teamNumber, len(players), teamName,
+			position, "BN", week, points))
+	}
+	return players
+}
+
+// newPlayer creates a player whose key is unique across every league
+func newPlayer(
+	gameKey string,
+	leagueNumber int,
+	teamNumber int,
+	index int,
+	teamName string,
+	position string,
+	selectedPosition string,
+	week int,
+	points float64) goff.Player {
+
+	id := uint64((leagueNumber*100+teamNumber)*100 + index)
+	return goff.Player{
+		PlayerKey:          fmt.Sprintf("%s.p.%d", gameKey, id),
+		PlayerID:           id,
+		Name:               goff.Name{Full: fmt.Sprintf("%s %s %d", teamName, position, index+1)},
+		DisplayPosition:    position,
+		ElligiblePositions: []string{position},
+		SelectedPosition: goff.SelectedPosition{
+			CoverageType: "week",
+			Week:         week,
+			Position:     selectedPosition,
+		},
+		PlayerPoints: newPoints(week, points),
+	}
+}
+
+// newPoints creates points for a week, including the text Yahoo sends them as
+func newPoints(week int, total float64) goff.Points {
+	return goff.Points{
+		CoverageType: "week",
+		Week:         week,
+		Total:        total,
+		TotalStr:     fmt.Sprintf("%.2f", total),
+	}
+}
+
+func renameTeams(points map[string]float64, teamKeys map[string]string) map[string]float64 {
+	if points == nil {
+		return nil
+	}
+	renamed := make(map[string]float64)
+	for key, value := range points {
+		if newKey, ok := teamKeys[key]; ok {
+			key = newKey
+		}
+		renamed[key] = value
+	}
+	return renamed
+}
+
+func roundPoints(points float64) float64 {
+	return math.Round(points*100.0) / 100.0
+}
@@ -0,0 +1,143 @@
+// Command fake-yahoo starts a web server that stands in for Yahoo when
+// running power-league locally.
+//
+// The server implements the OAuth 2 endpoints used to log in, granting access
+// to anyone right away, and the parts of the Yahoo Fantasy Sports API used by
+// power-league. It serves synthetic leagues whose teams score around a
+// strength of their own each week, along with any leagues read from JSON or
+// CSV files in the format accepted by the power-league upload page.
+//
+// To use it, start the server and pass its URL to power-league:
+//
+//	$ go run ./cmd/fake-yahoo -address :8081
+//	$ go run . -noTLS -address :8080 -yahooURL http://localhost:8081
+//
+// See usage for the options available to configure the synthetic leagues.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Forestmb/goff"
+	"github.com/Forestmb/power-league/offline"
+	"github.com/golang/glog"
+)
+
+func main() {
+	addr := flag.String(
+		"address",
+		":8081",
+		"Address to listen for incoming connections.")
+	numLeagues := flag.Int(
+		"leagues",
+		2,
+		"Number of synthetic leagues to serve.")
+	teams := flag.Int(
+		"teams",
+		10,
+		"Number of teams in each synthetic league. Must be even.")
+	weeks := flag.Int(
+		"weeks",
+		17,
+		"Number of weeks in the season of each synthetic league.")
+	played := flag.Int(
+		"played",
+		8,
+		"Number of weeks that have been played in each synthetic league. "+
+			"The season is finished if every week has been played.")
+	playoffStartWeek := flag.Int(
+		"playoffStartWeek",
+		15,
+		"First week of the playoffs in each synthetic league, or 0 for no "+
+			"playoffs.")
+	season := flag.String(
+		"season",
+		"2021",
+		"Year of the season the leagues are in.")
+	seed := flag.Int64(
+		"seed",
+		1,
+		"Seed used to generate the scores and rosters of the synthetic leagues.")
+	leagueFiles := flag.String(
+		"leagueFiles",
+		"",
+		"Comma-separated list of JSON or CSV league files to serve in addition "+
+			"to the synthetic leagues.")
+	flag.Parse()
+	defer glog.Flush()
+
+	gameKey, ok := goff.YearKeys[*season]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "fake-yahoo: season %s is not supported\n", *season)
+		os.Exit(1)
+	}
+
+	r := rand.New(rand.NewSource(*seed))
+	var leagues []*fakeLeague
+	for i := 1; i <= *numLeagues; i++ {
+		l, err := generateLeague(
+			r,
+			fmt.Sprintf("Synthetic League %d", i),
+			*season,
+			*teams,
+			*weeks,
+			*played,
+			*playoffStartWeek)
+		if err == nil {
+			var league *fakeLeague
+			league, err = newFakeLeague(r, gameKey, len(leagues)+1, l)
+			leagues = append(leagues, league)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "fake-yahoo: unable to generate league: %s\n", err)
+			os.Exit(1)
+		}
+	}
+	if *leagueFiles != "" {
+		for _, filename := range strings.Split(*leagueFiles, ",") {
+			league, err := readLeagueFile(r, gameKey, len(leagues)+1, filename)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "fake-yahoo: unable to read %s: %s\n", filename, err)
+				os.Exit(1)
+			}
+			leagues = append(leagues, league)
+		}
+	}
+
+	for _, league := range leagues {
+		glog.Infof("serving league -- key=%s, name=%s",
+			league.league.LeagueKey,
+			league.league.Name)
+	}
+	glog.Infof("starting fake yahoo server -- address=%s", *addr)
+	err := http.ListenAndServe(*addr, newServer(leagues).Handler())
+	if err != nil {
+		glog.Exit("ListenAndServe: ", err)
+	}
+}
+
+// readLeagueFile reads an offline league to serve from a file
+func readLeagueFile(
+	r *rand.Rand,
+	gameKey string,
+	number int,
+	filename string) (*fakeLeague, error) {
+
+	file, err := os.Open(filepath.Clean(filename))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	l, err := offline.Read(file, filename)
+	if err != nil {
+		return nil, err
+	}
+	return newFakeLeague(r, gameKey, number, l)
+}
@@ -0,0 +1,359 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/Forestmb/goff"
+	"github.com/golang/glog"
+)
+
+// Paths of the fake endpoints, the same as the ones used by Yahoo
+const (
+	authorizePath = "/oauth2/request_auth"
+	tokenPath     = "/oauth2/get_token"
+	fantasyPath   = "/fantasy/v2/"
+)
+
+// server implements the parts of Yahoo's OAuth 2 and fantasy sports APIs used
+// by power-league. Every user is logged in as the same user, who is in every
+// league.
+type server struct {
+	leagues      []*fakeLeague
+	leaguesByKey map[string]*fakeLeague
+
+	mutex  sync.Mutex
+	tokens int
+}
+
+// resource is one part of a fantasy sports API path along with its
+// parameters, e.g. "stats;type=week;week=1"
+type resource struct {
+	name   string
+	params map[string]string
+}
+
+func newServer(leagues []*fakeLeague) *server {
+	s := &server{
+		leagues:      leagues,
+		leaguesByKey: make(map[string]*fakeLeague),
+	}
+	for _, league := range leagues {
+		s.leaguesByKey[league.league.LeagueKey] = league
+	}
+	return s
+}
+
+// Handler returns the handler for every fake endpoint
+func (s *server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(authorizePath, s.handleAuthorize)
+	mux.HandleFunc(tokenPath, s.handleToken)
+	mux.HandleFunc(fantasyPath, s.handleFantasy)
+	return mux
+}
+
+//
+// OAuth 2
+//
+
+// handleAuthorize grants access right away, sending the user back to the
+// redirect URL with an authorization code
+func (s *server) handleAuthorize(w http.ResponseWriter, r *http.Request) {
+	redirectURL, err := url.Parse(r.FormValue("redirect_uri"))
+	if err != nil || redirectURL.String() == "" {
+		http.Error(w, "redirect_uri is required", http.StatusBadRequest)
+		return
+	}
+
+	values := redirectURL.Query()
+	values.Set("code", "fake-yahoo-code")
+	values.Set("state", r.FormValue("state"))
+	redirectURL.RawQuery = values.Encode()
+	glog.V(2).Infof("authorized client -- client=%s, redirect=%s",
+		r.FormValue("client_id"),
+		redirectURL)
+	http.Redirect(w, r, redirectURL.String(), http.StatusFound)
+}
+
+// handleToken exchanges any authorization code or refresh token for a new
+// access token
+func (s *server) handleToken(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	grantType := r.FormValue("grant_type")
+	if grantType != "authorization_code" && grantType != "refresh_token" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "unsupported_grant_type",
+		})
+		return
+	}
+
+	s.mutex.Lock()
+	s.tokens++
+	token := s.tokens
+	s.mutex.Unlock()
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"access_token":      fmt.Sprintf("fake-yahoo-access-token-%d", token),
+		"refresh_token":     fmt.Sprintf("fake-yahoo-refresh-token-%d", token),
+		"token_type":        "bearer",
+		"expires_in":        3600,
+		"xoauth_yahoo_guid": "FAKEYAHOOGUID",
+	})
+}
+
+//
+// Fantasy sports API
+//
+
+func (s *server) handleFantasy(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "Bearer ") {
+		writeError(w, http.StatusUnauthorized, "Please provide valid credentials.")
+		return
+	}
+
+	resources := parseResources(strings.TrimPrefix(r.URL.Path, fantasyPath))
+	glog.V(2).Infof("fantasy request -- path=%s", r.URL.Path)
+
+	var content *goff.FantasyContent
+	var err error
+	switch {
+	case len(resources) == 3 && resources[0].name == "users":
+		content = s.userLeagues(resources[1].params["game_keys"])
+	case len(resources) >= 2 && resources[0].name == "league":
+		content, err = s.league(resources[1], resources[2:])
+	case len(resources) == 3 && resources[0].name == "team":
+		content, err = s.team(resources[1].name, resources[2])
+	default:
+		err = fmt.Errorf("unsupported request '%s'", r.URL.Path)
+	}
+
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeContent(w, content)
+}
+
+// userLeagues returns the leagues in the given game
+func (s *server) userLeagues(gameKey string) *goff.FantasyContent {
+	game := goff.Game{}
+	for _, league := range s.leagues {
+		if league.gameKey == gameKey {
+			game.Leagues = append(game.Leagues, metadata(league.league))
+		}
+	}
+	return &goff.FantasyContent{
+		Users: []goff.User{goff.User{Games: []goff.Game{game}}},
+	}
+}
+
+// league returns the league resource, its metadata, standings, stats, or
+// scoreboard
+func (s *server) league(leagueResource resource, subresources []resource) (*goff.FantasyContent, error) {
+	league, ok := s.leaguesByKey[leagueResource.name]
+	if !ok {
+		return nil, fmt.Errorf("league '%s' does not exist", leagueResource.name)
+	}
+	leagueKey := league.league.LeagueKey
+
+	content := &goff.FantasyContent{League: metadata(league.league)}
+	switch {
+	case len(subresources) == 0 &&
+		strings.Contains(leagueResource.params["out"], "standings"):
+		content.League.Settings = league.league.Settings
+		for _, team := range league.league.Standings {
+			content.League.Standings = append(content.League.Standings, league.yahooTeam(team))
+		}
+	case len(subresources) == 0,
+		len(subresources) == 1 && subresources[0].name == "metadata":
+		// Only the metadata
+	case len(subresources) == 2 &&
+		subresources[0].name == "teams" &&
+		subresources[1].name == "stats":
+		week, err := strconv.Atoi(subresources[1].params["week"])
+		if err != nil {
+			return nil, fmt.Errorf("invalid week: %s", err)
+		}
+		teams, err := league.client.GetAllTeamStats(leagueKey, week, false)
+		if err != nil {
+			return nil, err
+		}
+		for _, team := range teams {
+			content.League.Teams = append(content.League.Teams, league.yahooTeam(team))
+		}
+	case len(subresources) == 1 && subresources[0].name == "scoreboard":
+		weeks := subresources[0].params["week"]
+		content.League.Scoreboard.Weeks = weeks
+		for _, weekStr := range strings.Split(weeks, ",") {
+			week, err := strconv.Atoi(weekStr)
+			if err != nil {
+				return nil, fmt.Errorf("invalid week: %s", err)
+			}
+			allMatchups, err := league.client.GetMatchupsForWeekRange(leagueKey, week, week)
+			if err != nil {
+				return nil, err
+			}
+			for _, matchup := range allMatchups[week] {
+				// Byes and other matchups without two teams can't be
+				// represented in a Yahoo scoreboard
+				if len(matchup.Teams) != 2 {
+					continue
+				}
+				for i := range matchup.Teams {
+					matchup.Teams[i] = league.yahooTeam(matchup.Teams[i])
+				}
+				content.League.Scoreboard.Matchups = append(
+					content.League.Scoreboard.Matchups,
+					matchup)
+			}
+		}
+	case len(subresources) == 2 &&
+		subresources[0].name == "players" &&
+		subresources[1].name == "stats":
+		week, err := strconv.Atoi(subresources[1].params["week"])
+		if err != nil {
+			return nil, fmt.Errorf("invalid week: %s", err)
+		}
+		content.League.Players = league.players(
+			strings.Split(subresources[0].params["player_keys"], ","),
+			week)
+	default:
+		return nil, fmt.Errorf("unsupported league resource")
+	}
+	return content, nil
+}
+
+// team returns the roster of a team for a week
+func (s *server) team(teamKey string, subresource resource) (*goff.FantasyContent, error) {
+	if subresource.name != "roster" {
+		return nil, fmt.Errorf("unsupported team resource '%s'", subresource.name)
+	}
+	week, err := strconv.Atoi(subresource.params["week"])
+	if err != nil {
+		return nil, fmt.Errorf("invalid week: %s", err)
+	}
+
+	for _, league := range s.leagues {
+		if rosters, ok := league.rosters[teamKey]; ok {
+			if week < 1 || week > len(rosters) {
+				return nil, fmt.Errorf("week %d is not in the season", week)
+			}
+			return &goff.FantasyContent{
+				Team: goff.Team{
+					TeamKey: teamKey,
+					Roster: goff.Roster{
+						CoverageType: "week",
+						Week:         week,
+						Players:      rosters[week-1],
+					},
+				},
+			}, nil
+		}
+	}
+	return nil, fmt.Errorf("team '%s' does not exist", teamKey)
+}
+
+// players returns the players with the given keys along with the points they
+// scored in a week
+func (f *fakeLeague) players(playerKeys []string, week int) []goff.Player {
+	wanted := make(map[string]bool)
+	for _, playerKey := range playerKeys {
+		wanted[playerKey] = true
+	}
+
+	var players []goff.Player
+	for _, rosters := range f.rosters {
+		if week < 1 || week > len(rosters) {
+			continue
+		}
+		for _, player := range rosters[week-1] {
+			if wanted[player.PlayerKey] {
+				players = append(players, player)
+			}
+		}
+	}
+	return players
+}
+
+//
+// Responses
+//
+
+// metadata returns the league without its standings and settings, the same
+// as Yahoo's league metadata
+func metadata(league *goff.League) goff.League {
+	return goff.League{
+		LeagueKey:   league.LeagueKey,
+		LeagueID:    league.LeagueID,
+		Name:        league.Name,
+		URL:         league.URL,
+		DraftStatus: league.DraftStatus,
+		CurrentWeek: league.CurrentWeek,
+		StartWeek:   league.StartWeek,
+		EndWeek:     league.EndWeek,
+		IsFinished:  league.IsFinished,
+	}
+}
+
+// yahooTeam returns the team the way it is sent by Yahoo, with its points and
+// rank set in text fields and owned by the current user if it's the first team
+// in the league
+func (f *fakeLeague) yahooTeam(team goff.Team) goff.Team {
+	team.IsOwnedByCurrentLogin = team.TeamKey == f.ownedTeamKey
+	team.TeamPoints = newPoints(team.TeamPoints.Week, team.TeamPoints.Total)
+	team.TeamProjectedPoints = newPoints(
+		team.TeamProjectedPoints.Week,
+		team.TeamProjectedPoints.Total)
+	if team.TeamStandings.Rank > 0 {
+		team.TeamStandings.RankStr = strconv.Itoa(team.TeamStandings.Rank)
+	}
+	return team
+}
+
+func writeContent(w http.ResponseWriter, content *goff.FantasyContent) {
+	bits, err := xml.Marshal(content)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/xml")
+	w.Write([]byte(xml.Header))
+	w.Write(bits)
+}
+
+func writeError(w http.ResponseWriter, status int, description string) {
+	glog.Warningf("fantasy request failed -- status=%d, error=%s", status, description)
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	w.Write([]byte(xml.Header))
+	xml.NewEncoder(w).Encode(struct {
+		XMLName     xml.Name `xml:"error"`
+		Description string   `xml:"description"`
+	}{Description: description})
+}
+
+// parseResources splits a fantasy sports API path into its resources, e.g.
+// "league/406.l.1/teams/stats;type=week;week=1"
+func parseResources(path string) []resource {
+	var resources []resource
+	for _, part := range strings.Split(strings.Trim(path, "/"), "/") {
+		fields := strings.Split(part, ";")
+		r := resource{name: fields[0], params: make(map[string]string)}
+		for _, param := range fields[1:] {
+			keyValue := strings.SplitN(param, "=", 2)
+			if len(keyValue) == 2 {
+				r.params[keyValue[0]] = keyValue[1]
+			}
+		}
+		resources = append(resources, r)
+	}
+	return resources
+}
@@ -0,0 +1,291 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/Forestmb/goff"
+	"github.com/Forestmb/power-league/rankings"
+	"github.com/Forestmb/power-league/site"
+)
+
+func TestRoundRobin(t *testing.T) {
+	teams := 6
+	played := make(map[[2]int]bool)
+	for week := 0; week < teams-1; week++ {
+		seen := make(map[int]bool)
+		for _, pair := range roundRobin(teams, week) {
+			if seen[pair[0]] || seen[pair[1]] || pair[0] == pair[1] {
+				t.Fatalf("Team plays more than once in week %d: %+v",
+					week+1,
+					roundRobin(teams, week))
+			}
+			seen[pair[0]] = true
+			seen[pair[1]] = true
+			if pair[0] > pair[1] {
+				pair[0], pair[1] = pair[1], pair[0]
+			}
+			if played[pair] {
+				t.Fatalf("Rematch before every team has played: %+v", pair)
+			}
+			played[pair] = true
+		}
+	}
+	if len(played) != teams*(teams-1)/2 {
+		t.Fatalf("Not every pair of teams played: %d", len(played))
+	}
+}
+
+func TestGenerateLeagueErrors(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, teams := range []int{0, 1, 7} {
+		_, err := generateLeague(r, "League", "2021", teams, 14, 2, 0)
+		if err == nil {
+			t.Fatalf("No error generating league with %d teams", teams)
+		}
+	}
+}
+
+func TestGenerateRoster(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	roster := generateRoster(r, "406", 1, 2, "Team", 3, 123.45)
+	if len(roster) != len(startingPositions)+len(benchPositions) {
+		t.Fatalf("Unexpected number of players: %d", len(roster))
+	}
+	total := 0.0
+	for _, player := range roster {
+		if player.SelectedPosition.Position != "BN" {
+			total += player.PlayerPoints.Total
+		}
+	}
+	if roundPoints(total) != 123.45 {
+		t.Fatalf("Starting players scored %f points, not the team's score", total)
+	}
+}
+
+func TestFantasyAPI(t *testing.T) {
+	client, leagues, s := mockFakeYahoo(t)
+	defer s.Close()
+	leagueKey := leagues[0].league.LeagueKey
+
+	userLeagues, err := client.GetUserLeagues("2021")
+	if err != nil || len(userLeagues) != 2 || userLeagues[0].LeagueKey != leagueKey {
+		t.Fatalf("Unexpected user leagues: %+v, %v", userLeagues, err)
+	}
+	userLeagues, err = client.GetUserLeagues("2020")
+	if err != nil || len(userLeagues) != 0 {
+		t.Fatalf("Unexpected user leagues for another season: %+v, %v",
+			userLeagues,
+			err)
+	}
+
+	league, err := client.GetLeagueMetadata(leagueKey)
+	if err != nil ||
+		league.Name != "League 1" ||
+		league.CurrentWeek != 3 ||
+		league.EndWeek != 4 ||
+		league.DraftStatus != "postdraft" {
+		t.Fatalf("Unexpected league metadata: %+v, %v", league, err)
+	}
+
+	standings, err := client.GetLeagueStandings(leagueKey)
+	if err != nil || len(standings.Standings) != 4 || !standings.Settings.UsesPlayoff {
+		t.Fatalf("Unexpected league standings: %+v, %v", standings, err)
+	}
+	for index, team := range standings.Standings {
+		if team.TeamStandings.Rank != index+1 ||
+			team.TeamStandings.Record.Wins+team.TeamStandings.Record.Losses != 2 {
+			t.Fatalf("Unexpected team at rank %d: %+v", index+1, team)
+		}
+	}
+
+	teams, err := client.GetAllTeamStats(leagueKey, 1)
+	if err != nil || len(teams) != 4 {
+		t.Fatalf("Unexpected team stats: %+v, %v", teams, err)
+	}
+	scores := leagues[0].client
+	expected, _ := scores.GetAllTeamStats(leagueKey, 1, false)
+	for index, team := range teams {
+		if team.TeamKey != expected[index].TeamKey ||
+			team.TeamPoints.Total != expected[index].TeamPoints.Total ||
+			team.TeamPoints.Total == 0.0 {
+			t.Fatalf("Unexpected stats for team %d:\n\tExpected: %+v\n\t"+
+				"Actual: %+v",
+				index+1,
+				expected[index],
+				team)
+		}
+	}
+
+	allMatchups, err := client.GetMatchupsForWeekRange(leagueKey, 1, 4)
+	if err != nil || len(allMatchups) != 4 {
+		t.Fatalf("Unexpected matchups: %+v, %v", allMatchups, err)
+	}
+	for week, matchups := range allMatchups {
+		if len(matchups) != 2 || matchups[0].Week != week {
+			t.Fatalf("Unexpected matchups in week %d: %+v", week, matchups)
+		}
+	}
+
+	roster, err := client.GetTeamRoster(teams[0].TeamKey, 1)
+	if err != nil || len(roster) != len(startingPositions)+len(benchPositions) {
+		t.Fatalf("Unexpected roster: %+v, %v", roster, err)
+	}
+	players, err := client.GetPlayersStats(leagueKey, 1, roster)
+	if err != nil || len(players) != len(roster) {
+		t.Fatalf("Unexpected player stats: %+v, %v", players, err)
+	}
+	total := 0.0
+	for _, player := range players {
+		if player.SelectedPosition.Position != "BN" {
+			total += player.PlayerPoints.Total
+		}
+	}
+	if roundPoints(total) != teams[0].TeamPoints.Total {
+		t.Fatalf("Starting players scored %f points, not the team's score %f",
+			total,
+			teams[0].TeamPoints.Total)
+	}
+
+	_, err = client.GetLeagueMetadata("406.l.99")
+	if err == nil {
+		t.Fatal("No error getting league that does not exist")
+	}
+}
+
+func TestFantasyAPIGetPowerData(t *testing.T) {
+	client, leagues, s := mockFakeYahoo(t)
+	defer s.Close()
+	leagueKey := leagues[0].league.LeagueKey
+
+	league, err := client.GetLeagueMetadata(leagueKey)
+	if err != nil {
+		t.Fatalf("Unexpected error getting league metadata: %s", err)
+	}
+	data, err := rankings.GetPowerData(
+		&site.YahooClient{Client: client},
+		league,
+		league.CurrentWeek-1)
+	if err != nil {
+		t.Fatalf("GetPowerData returned unexpected error: %s", err)
+	}
+	for _, powerData := range data {
+		if len(powerData.OverallRankings) != 4 {
+			t.Fatalf("Unexpected power data for scheme %s: %+v",
+				powerData.RankingScheme.ID(),
+				powerData)
+		}
+	}
+}
+
+func TestFantasyAPIUnauthorized(t *testing.T) {
+	s := httptest.NewServer(newServer(nil).Handler())
+	defer s.Close()
+
+	client := goff.NewClient(http.DefaultClient)
+	_, err := client.GetFantasyContent(s.URL + fantasyPath + "league/406.l.1/metadata")
+	if err == nil {
+		t.Fatal("No error making request without an access token")
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET",
+		authorizePath+"?client_id=id&response_type=code&state=abc&"+
+			"redirect_uri="+url.QueryEscape("http://localhost:8080/auth"),
+		nil)
+
+	newServer(nil).Handler().ServeHTTP(recorder, request)
+
+	location, _ := url.Parse(recorder.Header().Get("Location"))
+	if recorder.Code != http.StatusFound ||
+		location == nil ||
+		location.Host != "localhost:8080" ||
+		location.Path != "/auth" ||
+		location.Query().Get("state") != "abc" ||
+		location.Query().Get("code") == "" {
+		t.Fatalf("Unexpected authorization redirect: %d %s",
+			recorder.Code,
+			recorder.Header().Get("Location"))
+	}
+
+	recorder = httptest.NewRecorder()
+	request, _ = http.NewRequest("GET", authorizePath, nil)
+	newServer(nil).Handler().ServeHTTP(recorder, request)
+	if recorder.Code != http.StatusBadRequest {
+		t.Fatalf("Unexpected response without a redirect URL: %d", recorder.Code)
+	}
+}
+
+func TestToken(t *testing.T) {
+	for _, test := range []struct {
+		GrantType string
+		Status    int
+	}{
+		{"authorization_code", http.StatusOK},
+		{"refresh_token", http.StatusOK},
+		{"password", http.StatusBadRequest},
+	} {
+		recorder := httptest.NewRecorder()
+		request, _ := http.NewRequest("POST",
+			tokenPath,
+			strings.NewReader("code=abc&grant_type="+test.GrantType))
+		request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+		newServer(nil).Handler().ServeHTTP(recorder, request)
+
+		if recorder.Code != test.Status ||
+			(test.Status == http.StatusOK &&
+				!strings.Contains(recorder.Body.String(), "access_token")) {
+			t.Fatalf("Unexpected token response for grant type %s: %d %s",
+				test.GrantType,
+				recorder.Code,
+				recorder.Body.String())
+		}
+	}
+}
+
+// mockFakeYahoo starts a fake Yahoo server with two synthetic leagues, and
+// returns a client that sends its requests to that server
+func mockFakeYahoo(t *testing.T) (*goff.Client, []*fakeLeague, *httptest.Server) {
+	r := rand.New(rand.NewSource(1))
+	var leagues []*fakeLeague
+	for i := 1; i <= 2; i++ {
+		l, err := generateLeague(r, fmt.Sprintf("League %d", i), "2021", 4, 4, 2, 4)
+		if err != nil {
+			t.Fatalf("Unexpected error generating league: %s", err)
+		}
+		league, err := newFakeLeague(r, "406", i, l)
+		if err != nil {
+			t.Fatalf("Unexpected error creating league: %s", err)
+		}
+		leagues = append(leagues, league)
+	}
+
+	s := httptest.NewServer(newServer(leagues).Handler())
+	serverURL, _ := url.Parse(s.URL)
+	return goff.NewClient(&http.Client{
+		Transport: &mockTransport{serverURL: serverURL},
+	}), leagues, s
+}
+
+// mockTransport sends every request to the fake Yahoo server with an access
+// token
+type mockTransport struct {
+	serverURL *url.URL
+}
+
+func (m *mockTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme = m.serverURL.Scheme
+	req.URL.Host = m.serverURL.Host
+	req.Host = ""
+	req.Header.Set("Authorization", "Bearer token")
+	return http.DefaultTransport.RoundTrip(req)
+}
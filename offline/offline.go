@@ -0,0 +1,547 @@
+// Package offline reads fantasy leagues from local JSON or CSV files, so power
+// rankings can be calculated for leagues that aren't hosted by Yahoo.
+package offline
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Forestmb/goff"
+	"github.com/Forestmb/power-league/rankings"
+)
+
+// draftStatus of every offline league, since only leagues that have been
+// drafted have scores to import
+const draftStatus = "postdraft"
+
+// MaxWeeks is the most weeks an offline league can have. No fantasy season is
+// longer, so a file with more weeks than this is rejected instead of
+// allocating and requesting every one of them.
+const MaxWeeks = 30
+
+// ErrUnsupportedFormat is returned when reading a file that is neither JSON
+// nor CSV
+var ErrUnsupportedFormat = errors.New("offline leagues must be JSON or CSV files")
+
+//
+// Data structures
+//
+
+// League is a fantasy league read from a file. Weeks are numbered from 1 in
+// the order they appear, and the first week without any scores is the first
+// week that hasn't been played.
+type League struct {
+	Name   string `json:"name"`
+	Season string `json:"season"`
+
+	// Last week of the season, if the file doesn't include every week
+	EndWeek int `json:"endWeek"`
+
+	// First week of the playoffs, if the league has playoffs. Standings are
+	// calculated using only the weeks before the playoffs.
+	PlayoffStartWeek int `json:"playoffStartWeek"`
+
+	Teams []Team `json:"teams"`
+	Weeks []Week `json:"weeks"`
+}
+
+// Team is a single team in an offline league
+type Team struct {
+	// Key used to identify the team in each week, the name of the team if
+	// not set
+	Key      string `json:"key"`
+	Name     string `json:"name"`
+	Manager  string `json:"manager"`
+	Division string `json:"division"`
+	Logo     string `json:"logo"`
+
+	// Final rank of the team in the league. The standings are calculated
+	// from the matchups of each week unless every team has a rank.
+	Rank int `json:"rank"`
+}
+
+// Week contains the points each team scored or is projected to score in a
+// single week, by team key, along with the pairs of teams that played each
+// other
+type Week struct {
+	Scores    map[string]float64 `json:"scores"`
+	Projected map[string]float64 `json:"projected"`
+	Matchups  [][]string         `json:"matchups"`
+}
+
+// Client implements rankings.PowerRankingsClient and rankings.DivisionClient
+// for a league read from a file
+type Client struct {
+	league    *goff.League
+	teams     []goff.Team
+	weeks     []Week
+	divisions []rankings.Division
+}
+
+//
+// Functions
+//
+
+// Read reads a league from a JSON or CSV file, using the extension of the
+// file's name to choose the format
+func Read(r io.Reader, filename string) (*League, error) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".json":
+		return ReadJSON(r)
+	case ".csv":
+		league, err := ReadCSV(r)
+		if err != nil {
+			return nil, err
+		}
+		league.Name = strings.TrimSuffix(filepath.Base(filename), filepath.Ext(filename))
+		return league, nil
+	}
+	return nil, ErrUnsupportedFormat
+}
+
+// ReadJSON reads a league from JSON, e.g.
+//
+//	{
+//	  "name": "My League",
+//	  "teams": [{"key": "a", "name": "Team A"}, {"key": "b", "name": "Team B"}],
+//	  "weeks": [
+//	    {"scores": {"a": 101.5, "b": 88.2}, "matchups": [["a", "b"]]}
+//	  ]
+//	}
+func ReadJSON(r io.Reader) (*League, error) {
+	league := &League{}
+	err := json.NewDecoder(r).Decode(league)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read JSON league: %s", err)
+	}
+	if len(league.Weeks) > MaxWeeks {
+		return nil, fmt.Errorf("JSON league has %d weeks, more than the most "+
+			"allowed of %d",
+			len(league.Weeks),
+			MaxWeeks)
+	}
+	if league.EndWeek > MaxWeeks {
+		return nil, fmt.Errorf("invalid endWeek %d, more than the most allowed "+
+			"of %d",
+			league.EndWeek,
+			MaxWeeks)
+	}
+	return league, nil
+}
+
+// ReadCSV reads a league from CSV with a row for each team in each week. The
+// first row names the columns, which must include 'week', 'team' and 'score'.
+// The optional 'opponent', 'projected', 'manager', 'division' and 'rank'
+// columns add matchups, projected points and details about each team. Weeks
+// that haven't been played yet leave 'score' empty.
+func ReadCSV(r io.Reader) (*League, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("unable to read CSV league: %s", err)
+	}
+	if len(records) == 0 {
+		return nil, errors.New("CSV league has no header row")
+	}
+
+	columns := make(map[string]int)
+	for index, name := range records[0] {
+		columns[strings.ToLower(strings.TrimSpace(name))] = index
+	}
+	for _, required := range []string{"week", "team", "score"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("CSV league is missing the '%s' column", required)
+		}
+	}
+	value := func(record []string, column string) string {
+		index, ok := columns[column]
+		if !ok || index >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[index])
+	}
+
+	league := &League{}
+	teamIndex := make(map[string]int)
+	for line, record := range records[1:] {
+		line += 2
+		week, err := strconv.Atoi(value(record, "week"))
+		if err != nil || week < 1 || week > MaxWeeks {
+			return nil, fmt.Errorf("invalid week on line %d: '%s'",
+				line,
+				value(record, "week"))
+		}
+		name := value(record, "team")
+		if name == "" {
+			return nil, fmt.Errorf("missing team on line %d", line)
+		}
+
+		index, ok := teamIndex[name]
+		if !ok {
+			index = len(league.Teams)
+			teamIndex[name] = index
+			league.Teams = append(league.Teams, Team{Key: name, Name: name})
+		}
+		team := &league.Teams[index]
+		if manager := value(record, "manager"); manager != "" {
+			team.Manager = manager
+		}
+		if division := value(record, "division"); division != "" {
+			team.Division = division
+		}
+		if rank := value(record, "rank"); rank != "" {
+			team.Rank, err = strconv.Atoi(rank)
+			if err != nil {
+				return nil, fmt.Errorf("invalid rank on line %d: '%s'", line, rank)
+			}
+		}
+
+		for len(league.Weeks) < week {
+			league.Weeks = append(league.Weeks, Week{})
+		}
+		w := &league.Weeks[week-1]
+		if score := value(record, "score"); score != "" {
+			if w.Scores == nil {
+				w.Scores = make(map[string]float64)
+			}
+			w.Scores[name], err = strconv.ParseFloat(score, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid score on line %d: '%s'", line, score)
+			}
+		}
+		if projected := value(record, "projected"); projected != "" {
+			if w.Projected == nil {
+				w.Projected = make(map[string]float64)
+			}
+			w.Projected[name], err = strconv.ParseFloat(projected, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid projected score on line %d: '%s'",
+					line,
+					projected)
+			}
+		}
+
+		// Both teams in a matchup usually have a row naming the other
+		if opponent := value(record, "opponent"); opponent != "" {
+			duplicate := false
+			for _, matchup := range w.Matchups {
+				if len(matchup) == 2 && matchup[0] == opponent && matchup[1] == name {
+					duplicate = true
+				}
+			}
+			if !duplicate {
+				w.Matchups = append(w.Matchups, []string{name, opponent})
+			}
+		}
+	}
+	return league, nil
+}
+
+// NewClient creates a client for a league read from a file, returning an
+// error if the league refers to teams it doesn't include
+func NewClient(l *League) (*Client, error) {
+	if len(l.Teams) == 0 {
+		return nil, errors.New("offline league has no teams")
+	}
+	if len(l.Weeks) > MaxWeeks || l.EndWeek > MaxWeeks {
+		return nil, fmt.Errorf("offline league has more than %d weeks", MaxWeeks)
+	}
+
+	teams := make([]goff.Team, len(l.Teams))
+	teamKeys := make(map[string]bool)
+	var divisions []rankings.Division
+	divisionIndex := make(map[string]int)
+	for index, team := range l.Teams {
+		key := team.Key
+		if key == "" {
+			key = team.Name
+		}
+		if key == "" {
+			return nil, fmt.Errorf("team %d has no key or name", index+1)
+		}
+		if teamKeys[key] {
+			return nil, fmt.Errorf("team '%s' is included more than once", key)
+		}
+		teamKeys[key] = true
+
+		name := team.Name
+		if name == "" {
+			name = key
+		}
+		manager := team.Manager
+		if manager == "" {
+			manager = name
+		}
+		teams[index] = goff.Team{
+			TeamKey:  key,
+			TeamID:   uint64(index + 1),
+			Name:     name,
+			Managers: []goff.Manager{goff.Manager{Nickname: manager}},
+		}
+		if team.Logo != "" {
+			teams[index].TeamLogos = []goff.TeamLogo{goff.TeamLogo{URL: team.Logo}}
+		}
+
+		if team.Division != "" {
+			i, ok := divisionIndex[team.Division]
+			if !ok {
+				i = len(divisions)
+				divisionIndex[team.Division] = i
+				divisions = append(divisions, rankings.Division{
+					ID:   strconv.Itoa(i + 1),
+					Name: team.Division,
+				})
+			}
+			divisions[i].TeamKeys = append(divisions[i].TeamKeys, key)
+		}
+	}
+
+	completedWeeks := 0
+	for index, week := range l.Weeks {
+		for teamKey := range week.Scores {
+			if !teamKeys[teamKey] {
+				return nil, fmt.Errorf("unknown team '%s' in week %d", teamKey, index+1)
+			}
+		}
+		for teamKey := range week.Projected {
+			if !teamKeys[teamKey] {
+				return nil, fmt.Errorf("unknown team '%s' in week %d", teamKey, index+1)
+			}
+		}
+		for _, matchup := range week.Matchups {
+			if len(matchup) != 2 || matchup[0] == matchup[1] {
+				return nil, fmt.Errorf("matchups must have two different teams, "+
+					"found %q in week %d",
+					matchup,
+					index+1)
+			}
+			for _, teamKey := range matchup {
+				if !teamKeys[teamKey] {
+					return nil, fmt.Errorf("unknown team '%s' in week %d",
+						teamKey,
+						index+1)
+				}
+			}
+		}
+		if len(week.Scores) > 0 && completedWeeks == index {
+			completedWeeks++
+		}
+	}
+
+	endWeek := l.EndWeek
+	if endWeek < len(l.Weeks) {
+		endWeek = len(l.Weeks)
+	}
+	isFinished := completedWeeks >= endWeek
+	currentWeek := completedWeeks + 1
+	if isFinished {
+		currentWeek = completedWeeks
+	}
+	league := &goff.League{
+		LeagueKey:   "offline." + strings.ToLower(strings.Join(strings.Fields(l.Name), "-")),
+		Name:        l.Name,
+		DraftStatus: draftStatus,
+		CurrentWeek: currentWeek,
+		StartWeek:   1,
+		EndWeek:     endWeek,
+		IsFinished:  isFinished,
+		Settings: goff.Settings{
+			UsesPlayoff:      l.PlayoffStartWeek > 0,
+			PlayoffStartWeek: l.PlayoffStartWeek,
+		},
+	}
+
+	client := &Client{
+		league:    league,
+		teams:     teams,
+		weeks:     l.Weeks,
+		divisions: divisions,
+	}
+	league.Standings = client.calculateStandings(l.Teams, completedWeeks)
+	return client, nil
+}
+
+// League returns the league's metadata and standings
+func (c *Client) League() *goff.League {
+	return c.league
+}
+
+// GetLeagueStandings returns the league with its standings
+func (c *Client) GetLeagueStandings(leagueKey string) (*goff.League, error) {
+	return c.league, nil
+}
+
+// GetAllTeamStats returns every team with the points it scored and was
+// projected to score in the given week
+func (c *Client) GetAllTeamStats(
+	leagueKey string,
+	week int,
+	projected bool) ([]goff.Team, error) {
+
+	teams := make([]goff.Team, len(c.teams))
+	copy(teams, c.teams)
+	for index := range teams {
+		c.setPoints(&teams[index], week)
+	}
+	return teams, nil
+}
+
+// GetMatchupsForWeekRange returns the matchups of each week in the range that
+// has any
+func (c *Client) GetMatchupsForWeekRange(
+	leagueKey string,
+	startWeek int,
+	endWeek int) (map[int][]goff.Matchup, error) {
+
+	teamsByKey := make(map[string]goff.Team)
+	for _, team := range c.teams {
+		teamsByKey[team.TeamKey] = team
+	}
+
+	allMatchups := make(map[int][]goff.Matchup)
+	for week := startWeek; week <= endWeek && week <= len(c.weeks); week++ {
+		if week < 1 {
+			continue
+		}
+		for _, teamKeys := range c.weeks[week-1].Matchups {
+			matchup := goff.Matchup{Week: week}
+			for _, teamKey := range teamKeys {
+				team := teamsByKey[teamKey]
+				c.setPoints(&team, week)
+				matchup.Teams = append(matchup.Teams, team)
+			}
+			allMatchups[week] = append(allMatchups[week], matchup)
+		}
+	}
+	return allMatchups, nil
+}
+
+// GetDivisions returns the divisions of the league, if its teams have any
+func (c *Client) GetDivisions(leagueKey string) ([]rankings.Division, error) {
+	return c.divisions, nil
+}
+
+// setPoints sets the points a team scored and was projected to score in a
+// week. Teams without a projection are projected to score their mean score
+// from the weeks before it.
+func (c *Client) setPoints(team *goff.Team, week int) {
+	team.TeamPoints = goff.Points{CoverageType: "week", Week: week}
+	team.TeamProjectedPoints = goff.Points{CoverageType: "week", Week: week}
+	if week < 1 || week > len(c.weeks) {
+		return
+	}
+	team.TeamPoints.Total = c.weeks[week-1].Scores[team.TeamKey]
+	projection, ok := c.weeks[week-1].Projected[team.TeamKey]
+	if !ok {
+		projection = c.meanScore(team.TeamKey, week)
+	}
+	team.TeamProjectedPoints.Total = projection
+}
+
+// meanScore returns the mean points scored by a team before the given week
+func (c *Client) meanScore(teamKey string, beforeWeek int) float64 {
+	total := 0.0
+	weeks := 0
+	for week := 1; week < beforeWeek && week <= len(c.weeks); week++ {
+		if score, ok := c.weeks[week-1].Scores[teamKey]; ok {
+			total += score
+			weeks++
+		}
+	}
+	if weeks == 0 {
+		return 0.0
+	}
+	return total / float64(weeks)
+}
+
+// calculateStandings returns the teams of the league in the order of their
+// standings, with the record and points of each team from the matchups of the
+// completed weeks before the playoffs. The ranks in the file are used instead
+// when every team has one.
+func (c *Client) calculateStandings(teams []Team, completedWeeks int) []goff.Team {
+	standings := make([]goff.Team, len(c.teams))
+	copy(standings, c.teams)
+	indexByTeamKey := make(map[string]int)
+	for index, team := range standings {
+		indexByTeamKey[team.TeamKey] = index
+	}
+
+	lastWeek := completedWeeks
+	if c.league.Settings.UsesPlayoff && c.league.Settings.PlayoffStartWeek-1 < lastWeek {
+		lastWeek = c.league.Settings.PlayoffStartWeek - 1
+	}
+	for week := 1; week <= lastWeek; week++ {
+		scores := c.weeks[week-1].Scores
+		for _, matchup := range c.weeks[week-1].Matchups {
+			if len(matchup) != 2 {
+				continue
+			}
+			first := &standings[indexByTeamKey[matchup[0]]].TeamStandings
+			second := &standings[indexByTeamKey[matchup[1]]].TeamStandings
+			firstScore := scores[matchup[0]]
+			secondScore := scores[matchup[1]]
+			first.PointsFor += firstScore
+			first.PointsAgainst += secondScore
+			second.PointsFor += secondScore
+			second.PointsAgainst += firstScore
+			if firstScore > secondScore {
+				first.Record.Wins++
+				second.Record.Losses++
+			} else if firstScore < secondScore {
+				first.Record.Losses++
+				second.Record.Wins++
+			} else {
+				first.Record.Ties++
+				second.Record.Ties++
+			}
+		}
+	}
+
+	ranked := true
+	for index := range standings {
+		standings[index].TeamStandings.Rank = teams[index].Rank
+		if teams[index].Rank < 1 {
+			ranked = false
+		}
+	}
+	if ranked {
+		sort.SliceStable(standings, func(i, j int) bool {
+			return standings[i].TeamStandings.Rank < standings[j].TeamStandings.Rank
+		})
+		return standings
+	}
+
+	sort.SliceStable(standings, func(i, j int) bool {
+		first := standings[i].TeamStandings
+		second := standings[j].TeamStandings
+		firstPercentage := winPercentage(&first.Record)
+		secondPercentage := winPercentage(&second.Record)
+		if firstPercentage == secondPercentage {
+			return first.PointsFor > second.PointsFor
+		}
+		return firstPercentage > secondPercentage
+	})
+	for index := range standings {
+		standings[index].TeamStandings.Rank = index + 1
+	}
+	return standings
+}
+
+// winPercentage returns the fraction of games won by a team, counting ties as
+// half a win
+func winPercentage(r *goff.Record) float64 {
+	games := r.Wins + r.Losses + r.Ties
+	if games == 0 {
+		return 0.0
+	}
+	return (float64(r.Wins) + 0.5*float64(r.Ties)) / float64(games)
+}
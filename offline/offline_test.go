@@ -0,0 +1,269 @@
+package offline
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Forestmb/power-league/rankings"
+)
+
+const testJSON = `{
+  "name": "Offline League",
+  "season": "2004",
+  "endWeek": 3,
+  "teams": [
+    {"key": "a", "name": "Team A", "manager": "Manager A", "division": "East"},
+    {"key": "b", "name": "Team B", "division": "East"},
+    {"key": "c", "name": "Team C", "division": "West"},
+    {"key": "d", "name": "Team D", "division": "West"}
+  ],
+  "weeks": [
+    {
+      "scores": {"a": 100.0, "b": 90.0, "c": 80.0, "d": 70.0},
+      "matchups": [["a", "b"], ["c", "d"]]
+    },
+    {
+      "scores": {"a": 60.0, "b": 90.0, "c": 80.0, "d": 70.0},
+      "matchups": [["a", "c"], ["b", "d"]]
+    },
+    {
+      "projected": {"a": 110.0, "d": 75.0},
+      "matchups": [["a", "d"], ["b", "c"]]
+    }
+  ]
+}`
+
+const testCSV = `week,team,score,opponent,projected,division
+1,Team A,100.0,Team B,,East
+1,Team B,90.0,Team A,,East
+1,Team C,80.0,Team D,,West
+1,Team D,70.0,Team C,,West
+2,Team A,60.0,Team C
+2,Team C,80.0,Team A
+2,Team B,90.0,Team D
+2,Team D,70.0,Team B
+3,Team A,,Team D,110.0
+3,Team D,,Team A,75.0
+3,Team B,,Team C
+3,Team C,,Team B
+`
+
+func TestReadJSON(t *testing.T) {
+	league, err := Read(strings.NewReader(testJSON), "league.json")
+	if err != nil {
+		t.Fatalf("Unexpected error reading JSON league: %s", err)
+	}
+	if league.Name != "Offline League" ||
+		league.EndWeek != 3 ||
+		len(league.Teams) != 4 ||
+		len(league.Weeks) != 3 ||
+		league.Weeks[0].Scores["a"] != 100.0 ||
+		league.Weeks[2].Projected["a"] != 110.0 {
+		t.Fatalf("Unexpected JSON league: %+v", league)
+	}
+}
+
+func TestReadCSV(t *testing.T) {
+	league, err := Read(strings.NewReader(testCSV), "/tmp/Offline League.csv")
+	if err != nil {
+		t.Fatalf("Unexpected error reading CSV league: %s", err)
+	}
+	if league.Name != "Offline League" ||
+		len(league.Teams) != 4 ||
+		len(league.Weeks) != 3 {
+		t.Fatalf("Unexpected CSV league: %+v", league)
+	}
+	if league.Teams[0].Key != "Team A" || league.Teams[0].Division != "East" {
+		t.Fatalf("Unexpected CSV team: %+v", league.Teams[0])
+	}
+	for index, week := range league.Weeks {
+		if len(week.Matchups) != 2 {
+			t.Fatalf("Unexpected matchups in week %d: %+v", index+1, week.Matchups)
+		}
+	}
+	if len(league.Weeks[2].Scores) != 0 || league.Weeks[2].Projected["Team D"] != 75.0 {
+		t.Fatalf("Unexpected unplayed week: %+v", league.Weeks[2])
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	for _, test := range []struct {
+		Content  string
+		Filename string
+	}{
+		{testCSV, "league.xlsx"},
+		{"{", "league.json"},
+		{"week,team\n1,Team A\n", "league.csv"},
+		{"week,team,score\nfirst,Team A,1.0\n", "league.csv"},
+		{"week,team,score\n1,Team A,many\n", "league.csv"},
+		{"week,team,score\n1,,1.0\n", "league.csv"},
+		{"week,team,score\n31,Team A,1.0\n", "league.csv"},
+		{"week,team,score\n1000000000,Team A,1.0\n", "league.csv"},
+		{`{"teams": [{"key": "a"}], "endWeek": 1000000000}`, "league.json"},
+		{`{"teams": [{"key": "a"}], "weeks": [` +
+			strings.Repeat(`{},`, MaxWeeks) + `{}]}`, "league.json"},
+	} {
+		_, err := Read(strings.NewReader(test.Content), test.Filename)
+		if err == nil {
+			t.Fatalf("No error reading invalid league from %s:\n%s",
+				test.Filename,
+				test.Content)
+		}
+	}
+}
+
+func TestNewClient(t *testing.T) {
+	league, _ := ReadJSON(strings.NewReader(testJSON))
+	client, err := NewClient(league)
+	if err != nil {
+		t.Fatalf("Unexpected error creating client: %s", err)
+	}
+
+	l := client.League()
+	if l.CurrentWeek != 3 ||
+		l.EndWeek != 3 ||
+		l.IsFinished ||
+		l.DraftStatus != "postdraft" ||
+		l.LeagueKey != "offline.offline-league" {
+		t.Fatalf("Unexpected league metadata: %+v", l)
+	}
+
+	// Records: a 1-1, b 1-1, c 2-0, d 0-2
+	expected := []struct {
+		TeamKey   string
+		Wins      int
+		PointsFor float64
+	}{
+		{"c", 2, 160.0},
+		{"b", 1, 180.0},
+		{"a", 1, 160.0},
+		{"d", 0, 140.0},
+	}
+	if len(l.Standings) != len(expected) {
+		t.Fatalf("Unexpected number of teams in standings: %d", len(l.Standings))
+	}
+	for index, team := range l.Standings {
+		if team.TeamKey != expected[index].TeamKey ||
+			team.TeamStandings.Rank != index+1 ||
+			team.TeamStandings.Record.Wins != expected[index].Wins ||
+			team.TeamStandings.PointsFor != expected[index].PointsFor {
+			t.Fatalf("Unexpected team at rank %d:\n\tExpected: %+v\n\t"+
+				"Actual: %+v",
+				index+1,
+				expected[index],
+				team)
+		}
+	}
+
+	divisions, _ := client.GetDivisions(l.LeagueKey)
+	if len(divisions) != 2 ||
+		divisions[0].Name != "East" ||
+		strings.Join(divisions[0].TeamKeys, ",") != "a,b" ||
+		strings.Join(divisions[1].TeamKeys, ",") != "c,d" {
+		t.Fatalf("Unexpected divisions: %+v", divisions)
+	}
+}
+
+func TestNewClientFinalRanks(t *testing.T) {
+	league, _ := ReadJSON(strings.NewReader(testJSON))
+	for index := range league.Teams {
+		league.Teams[index].Rank = index + 1
+	}
+	client, err := NewClient(league)
+	if err != nil {
+		t.Fatalf("Unexpected error creating client: %s", err)
+	}
+	for index, team := range client.League().Standings {
+		if team.TeamKey != league.Teams[index].Key {
+			t.Fatalf("Standings not in order of final ranks: %+v",
+				client.League().Standings)
+		}
+	}
+}
+
+func TestNewClientErrors(t *testing.T) {
+	for _, league := range []*League{
+		&League{},
+		&League{Teams: []Team{Team{}}},
+		&League{Teams: []Team{Team{Key: "a"}, Team{Key: "a"}}},
+		&League{
+			Teams: []Team{Team{Key: "a"}},
+			Weeks: []Week{Week{Scores: map[string]float64{"b": 1.0}}},
+		},
+		&League{
+			Teams: []Team{Team{Key: "a"}},
+			Weeks: []Week{Week{Matchups: [][]string{[]string{"a", "b"}}}},
+		},
+		&League{Teams: []Team{Team{Key: "a"}}, EndWeek: MaxWeeks + 1},
+		&League{Teams: []Team{Team{Key: "a"}}, Weeks: make([]Week, MaxWeeks+1)},
+	} {
+		_, err := NewClient(league)
+		if err == nil {
+			t.Fatalf("No error creating client for invalid league: %+v", league)
+		}
+	}
+}
+
+func TestClientPoints(t *testing.T) {
+	league, _ := ReadJSON(strings.NewReader(testJSON))
+	client, _ := NewClient(league)
+
+	teams, err := client.GetAllTeamStats("key", 1, false)
+	if err != nil || len(teams) != 4 || teams[0].TeamPoints.Total != 100.0 {
+		t.Fatalf("Unexpected teams for week 1: %+v, %v", teams, err)
+	}
+
+	// Teams without projections are projected to score their mean score
+	expected := map[string]float64{"a": 110.0, "b": 90.0, "c": 80.0, "d": 75.0}
+	teams, _ = client.GetAllTeamStats("key", 3, true)
+	for _, team := range teams {
+		if team.TeamPoints.Total != 0.0 ||
+			team.TeamProjectedPoints.Total != expected[team.TeamKey] {
+			t.Fatalf("Unexpected points for team %s in week 3: %+v",
+				team.TeamKey,
+				team)
+		}
+	}
+
+	allMatchups, err := client.GetMatchupsForWeekRange("key", 2, 5)
+	if err != nil || len(allMatchups) != 2 || len(allMatchups[2]) != 2 {
+		t.Fatalf("Unexpected matchups: %+v, %v", allMatchups, err)
+	}
+	matchup := allMatchups[3][0]
+	if matchup.Week != 3 ||
+		matchup.Teams[0].TeamKey != "a" ||
+		matchup.Teams[0].TeamProjectedPoints.Total != 110.0 ||
+		matchup.Teams[1].TeamKey != "d" ||
+		matchup.Teams[1].TeamProjectedPoints.Total != 75.0 {
+		t.Fatalf("Unexpected matchup in week 3: %+v", matchup)
+	}
+}
+
+func TestClientGetPowerData(t *testing.T) {
+	league, err := ReadCSV(strings.NewReader(testCSV))
+	if err != nil {
+		t.Fatalf("Unexpected error reading CSV league: %s", err)
+	}
+	client, err := NewClient(league)
+	if err != nil {
+		t.Fatalf("Unexpected error creating client: %s", err)
+	}
+
+	l := client.League()
+	data, err := rankings.GetPowerData(client, l, 2)
+	if err != nil {
+		t.Fatalf("GetPowerData returned unexpected error: %s", err)
+	}
+	if len(data) == 0 {
+		t.Fatal("GetPowerData returned no data")
+	}
+	for _, powerData := range data {
+		if len(powerData.OverallRankings) != 4 ||
+			len(powerData.ByWeek) != 3 ||
+			len(powerData.Divisions) != 2 {
+			t.Fatalf("Unexpected power data for scheme %s: %+v",
+				powerData.RankingScheme.ID(),
+				powerData)
+		}
+	}
+}
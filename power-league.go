@@ -14,12 +14,16 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"net/http"
+	"net/url"
 	"os"
+	"strings"
 
 	"github.com/Forestmb/goff"
+	"github.com/Forestmb/power-league/providers"
 	"github.com/Forestmb/power-league/rankings"
 	"github.com/Forestmb/power-league/session"
 	"github.com/Forestmb/power-league/site"
@@ -27,6 +31,7 @@ import (
 	"github.com/gorilla/handlers"
 	"github.com/gorilla/securecookie"
 	"github.com/gorilla/sessions"
+	"golang.org/x/oauth2"
 )
 
 func main() {
@@ -60,6 +65,110 @@ func main() {
 		"Minimize calls to the Yahoo Fantasy Sports API. If enabled, it will "+
 			"lower the risk of being throttled but will result in a higher "+
 			"average page load time.")
+	maxConcurrentRequests := flag.Int(
+		"maxConcurrentRequests",
+		rankings.MaxConcurrentRequests,
+		"Maximum number of requests for weekly data made to the Yahoo Fantasy "+
+			"Sports API at the same time when calculating power rankings.")
+	eloKFactor := flag.Float64(
+		"eloKFactor",
+		rankings.EloKFactor,
+		"Maximum number of rating points a team can gain or lose from a single "+
+			"matchup in the Elo Rating scheme.")
+	eloMarginOfVictory := flag.Bool(
+		"eloMarginOfVictory",
+		rankings.EloMarginOfVictory,
+		"Scale the rating points exchanged in the Elo Rating scheme by the "+
+			"margin of victory.")
+	playoffSimulations := flag.Int(
+		"playoffSimulations",
+		rankings.PlayoffSimulations,
+		"Number of times the rest of the regular season is simulated to find "+
+			"each team's playoff odds.")
+	pythagoreanExponent := flag.Float64(
+		"pythagoreanExponent",
+		rankings.PythagoreanExponent,
+		"Exponent applied to the points scored for and against each team in "+
+			"the Pythagorean Expectation scheme.")
+	recencyDecay := flag.Float64(
+		"recencyDecay",
+		rankings.RecencyDecay,
+		"How much each week is worth compared to the week after it in the "+
+			"Recency Weighted scheme.")
+	recencyWindow := flag.Int(
+		"recencyWindow",
+		rankings.RecencyWindow,
+		"Number of most recent weeks counted by the Recency Weighted scheme. "+
+			"If greater than zero, used instead of recencyDecay.")
+	projectionTrailingWeeks := flag.Int(
+		"projectionTrailingWeeks",
+		rankings.ProjectionTrailingWeeks,
+		"Number of most recent weeks averaged by the Trailing Mean projector.")
+	projectionRegressionWeeks := flag.Float64(
+		"projectionRegressionWeeks",
+		rankings.ProjectionRegressionWeeks,
+		"Number of weeks of the league's mean score blended into each team's "+
+			"mean score by the Regressed Mean projector.")
+	boomPercentile := flag.Float64(
+		"boomPercentile",
+		rankings.BoomPercentile,
+		"Percentile of every score in a league, from 0 to 1, a team must "+
+			"be above for its week to count as a boom week.")
+	bustPercentile := flag.Float64(
+		"bustPercentile",
+		rankings.BustPercentile,
+		"Percentile of every score in a league, from 0 to 1, a team must "+
+			"be below for its week to count as a bust week.")
+	optimalLineups := flag.Bool(
+		"optimalLineups",
+		rankings.OptimalLineups,
+		"Enable the Optimal Lineup scheme and manager efficiency. Requires "+
+			"the roster of every team for every week, which results in many "+
+			"more calls to the Yahoo Fantasy Sports API.")
+	compositeWeights := flag.String(
+		"compositeWeights",
+		rankings.DefaultCompositeWeights.String(),
+		"Default weights of the schemes blended into the Composite scheme, "+
+			"as a comma-separated list of scheme:weight pairs. The actual "+
+			"head-to-head record of a team can be weighted using 'record'.")
+	tieBreakers := flag.String(
+		"tieBreakers",
+		rankings.DefaultTieBreakers.String(),
+		"Default tie-breakers used to order teams tied in the overall rankings, "+
+			"as a comma-separated list of points-for, head-to-head, all-play and "+
+			"coin-flip (or coin-flip:seed). Chains for a single scheme are "+
+			"given as scheme=tie-breakers, separated by semicolons.")
+	recordFixtures := flag.String(
+		"recordFixtures",
+		"",
+		"Directory to save every response from the Yahoo Fantasy Sports API "+
+			"used to calculate power rankings to, so they can be replayed "+
+			"later using replayFixtures.")
+	replayFixtures := flag.String(
+		"replayFixtures",
+		"",
+		"Directory of responses saved by recordFixtures to calculate power "+
+			"rankings from instead of the Yahoo Fantasy Sports API. The "+
+			"clientKey and clientSecret are not required when replaying.")
+	yahooURL := flag.String(
+		"yahooURL",
+		"",
+		"Base URL of a server to use in place of Yahoo for logging in and "+
+			"the Yahoo Fantasy Sports API, such as the one started by "+
+			"cmd/fake-yahoo. The clientKey and clientSecret are not required "+
+			"when set.")
+	espnS2 := flag.String(
+		"espnS2",
+		os.Getenv("ESPN_S2"),
+		"Value of the espn_s2 cookie of an ESPN user, used with espnSWID to "+
+			"import private ESPN leagues that user is in. Defaults to the "+
+			"value of the ESPN_S2 environment variable.")
+	espnSWID := flag.String(
+		"espnSWID",
+		os.Getenv("ESPN_SWID"),
+		"Value of the SWID cookie of an ESPN user, used with espnS2 to import "+
+			"private ESPN leagues that user is in. Defaults to the value of "+
+			"the ESPN_SWID environment variable.")
 	trackingID := flag.String(
 		"trackingID",
 		os.Getenv("GA_TRACKING_ID"),
@@ -99,7 +208,7 @@ func main() {
 		envValue := os.Getenv("OAUTH_CLIENT_KEY")
 		clientKey = &envValue
 	}
-	if *clientKey == "" {
+	if *clientKey == "" && *replayFixtures == "" && *yahooURL == "" {
 		fmt.Fprintln(os.Stderr, "power-league: clientKey must be provided")
 		invalidInputParameters = true
 	}
@@ -108,7 +217,7 @@ func main() {
 		envValue := os.Getenv("OAUTH_CLIENT_SECRET")
 		clientSecret = &envValue
 	}
-	if *clientSecret == "" {
+	if *clientSecret == "" && *replayFixtures == "" && *yahooURL == "" {
 		fmt.Fprintln(os.Stderr, "power-league: clientSecret must be provided")
 		invalidInputParameters = true
 	}
@@ -124,6 +233,28 @@ func main() {
 		}
 	}
 
+	defaultCompositeWeights, weightsErr := rankings.ParseCompositeWeights(*compositeWeights)
+	if weightsErr != nil {
+		fmt.Fprintf(os.Stderr, "power-league: invalid compositeWeights: %s\n", weightsErr)
+		invalidInputParameters = true
+	}
+
+	var fakeYahooURL *url.URL
+	if *yahooURL != "" {
+		var urlErr error
+		fakeYahooURL, urlErr = url.Parse(*yahooURL)
+		if urlErr != nil || fakeYahooURL.Scheme == "" || fakeYahooURL.Host == "" {
+			fmt.Fprintf(os.Stderr, "power-league: invalid yahooURL: %s\n", *yahooURL)
+			invalidInputParameters = true
+		}
+	}
+
+	defaultTieBreakers, tieBreakersErr := rankings.ParseTieBreakers(*tieBreakers)
+	if tieBreakersErr != nil {
+		fmt.Fprintf(os.Stderr, "power-league: invalid tieBreakers: %s\n", tieBreakersErr)
+		invalidInputParameters = true
+	}
+
 	if invalidInputParameters {
 		os.Exit(1)
 	}
@@ -138,6 +269,22 @@ func main() {
 	glog.Infof("starting power rankings site -- context=%s", baseContext)
 
 	rankings.MinimizeAPICalls = *minimizeAPICalls
+	rankings.MaxConcurrentRequests = *maxConcurrentRequests
+	rankings.EloKFactor = *eloKFactor
+	rankings.EloMarginOfVictory = *eloMarginOfVictory
+	rankings.PlayoffSimulations = *playoffSimulations
+	rankings.PythagoreanExponent = *pythagoreanExponent
+	rankings.RecencyDecay = *recencyDecay
+	rankings.RecencyWindow = *recencyWindow
+	rankings.ProjectionTrailingWeeks = *projectionTrailingWeeks
+	rankings.ProjectionRegressionWeeks = *projectionRegressionWeeks
+	rankings.BoomPercentile = *boomPercentile
+	rankings.BustPercentile = *bustPercentile
+	rankings.DefaultCompositeWeights = defaultCompositeWeights
+	rankings.DefaultTieBreakers = defaultTieBreakers
+	rankings.OptimalLineups = *optimalLineups
+	providers.ESPNS2 = *espnS2
+	providers.ESPNSWID = *espnSWID
 
 	// Create cookie store
 	var cookieStoreAuthKey []byte
@@ -174,6 +321,7 @@ func main() {
 			clientSecret: *clientSecret,
 			redirectURL:  *clientRedirectURL,
 			authContext:  authContext,
+			yahooURL:     fakeYahooURL,
 		},
 		sessions.NewCookieStore(cookieStoreAuthKey, cookieStoreEncryptionKey),
 		*userCacheDurationSeconds,
@@ -181,6 +329,14 @@ func main() {
 
 	site := site.NewSite(
 		!*noTLS, baseContext, *staticFilesLocation, "templates/html/", *trackingID, sessionManager)
+	if *recordFixtures != "" {
+		glog.Infof("recording fixtures -- dir=%s", *recordFixtures)
+		site.RecordFixtures(*recordFixtures)
+	}
+	if *replayFixtures != "" {
+		glog.Infof("replaying fixtures -- dir=%s", *replayFixtures)
+		site.ReplayFixtures(*replayFixtures)
+	}
 	var err error
 	if *noTLS {
 		err = http.ListenAndServe(*addr, handlers.LoggingHandler(logWriter{}, site.ServeMux))
@@ -210,6 +366,9 @@ type oauth2ConsumerProvider struct {
 	clientSecret string
 	redirectURL  string
 	authContext  string
+
+	// Server used in place of Yahoo, if any
+	yahooURL *url.URL
 }
 
 func (o oauth2ConsumerProvider) Get(r *http.Request) session.Consumer {
@@ -221,5 +380,60 @@ func (o oauth2ConsumerProvider) Get(r *http.Request) session.Consumer {
 		}
 		redirectURL = fmt.Sprintf("%s://%s%s", protocol, r.Host, o.authContext)
 	}
-	return goff.GetOAuth2Config(o.clientKey, o.clientSecret, redirectURL)
+	config := goff.GetOAuth2Config(o.clientKey, o.clientSecret, redirectURL)
+	if o.yahooURL == nil {
+		return config
+	}
+	for _, endpoint := range []*string{&config.Endpoint.AuthURL, &config.Endpoint.TokenURL} {
+		u, err := url.Parse(*endpoint)
+		if err == nil {
+			replaceHost(u, o.yahooURL)
+			*endpoint = u.String()
+		}
+	}
+	return yahooURLConsumer{Config: config, yahooURL: o.yahooURL}
+}
+
+// yahooURLConsumer implements session.Consumer to log in with, and make API
+// requests to, a server used in place of Yahoo
+type yahooURLConsumer struct {
+	*oauth2.Config
+	yahooURL *url.URL
+}
+
+// Client returns an HTTP client that sends requests for the Yahoo Fantasy
+// Sports API to the server used in place of Yahoo
+func (y yahooURLConsumer) Client(ctx context.Context, token *oauth2.Token) *http.Client {
+	client := y.Config.Client(ctx, token)
+	client.Transport = &yahooURLTransport{
+		base:     client.Transport,
+		yahooURL: y.yahooURL,
+	}
+	return client
+}
+
+// yahooURLTransport implements http.RoundTripper to send requests for the
+// Yahoo Fantasy Sports API to another server
+type yahooURLTransport struct {
+	base     http.RoundTripper
+	yahooURL *url.URL
+}
+
+func (y *yahooURLTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	apiURL, err := url.Parse(goff.YahooBaseURL)
+	if err == nil && req.URL.Host == apiURL.Host {
+		req = req.Clone(req.Context())
+		replaceHost(req.URL, y.yahooURL)
+		req.Host = ""
+	}
+	return y.base.RoundTrip(req)
+}
+
+// replaceHost changes a Yahoo URL to the same path on the server used in
+// place of Yahoo
+func replaceHost(u *url.URL, yahooURL *url.URL) {
+	u.Scheme = yahooURL.Scheme
+	u.Host = yahooURL.Host
+	u.Path = strings.TrimSuffix(yahooURL.Path, "/") + u.Path
+	u.RawPath = ""
 }
@@ -0,0 +1,224 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+//
+// Configuration variables
+//
+
+// ESPNBaseURL is the base URL of ESPN's fantasy football API
+var ESPNBaseURL = "https://lm-api-reads.fantasy.espn.com/apis/v3/games/ffl"
+
+// ESPNS2 and ESPNSWID are the values of the 'espn_s2' and 'SWID' cookies of
+// a user logged in to ESPN, used to access private leagues
+var (
+	ESPNS2   = ""
+	ESPNSWID = ""
+)
+
+// espnUndecided is the winner of an ESPN matchup that hasn't finished
+const espnUndecided = "UNDECIDED"
+
+// espn implements Provider for ESPN fantasy football leagues
+type espn struct {
+	baseURL string
+	s2      string
+	swid    string
+}
+
+//
+// API responses
+//
+
+type espnLeague struct {
+	Members  []espnMember  `json:"members"`
+	Teams    []espnTeam    `json:"teams"`
+	Schedule []espnMatchup `json:"schedule"`
+	Settings espnSettings  `json:"settings"`
+	Status   espnStatus    `json:"status"`
+}
+
+type espnSettings struct {
+	Name             string `json:"name"`
+	ScheduleSettings struct {
+		MatchupPeriodCount int `json:"matchupPeriodCount"`
+		PlayoffTeamCount   int `json:"playoffTeamCount"`
+		Divisions          []struct {
+			ID   int    `json:"id"`
+			Name string `json:"name"`
+		} `json:"divisions"`
+	} `json:"scheduleSettings"`
+}
+
+type espnStatus struct {
+	IsActive bool `json:"isActive"`
+}
+
+type espnMember struct {
+	ID          string `json:"id"`
+	DisplayName string `json:"displayName"`
+	FirstName   string `json:"firstName"`
+	LastName    string `json:"lastName"`
+}
+
+type espnTeam struct {
+	ID                  int      `json:"id"`
+	Name                string   `json:"name"`
+	Location            string   `json:"location"`
+	Nickname            string   `json:"nickname"`
+	Logo                string   `json:"logo"`
+	DivisionID          int      `json:"divisionId"`
+	PrimaryOwner        string   `json:"primaryOwner"`
+	Owners              []string `json:"owners"`
+	RankCalculatedFinal int      `json:"rankCalculatedFinal"`
+}
+
+type espnMatchup struct {
+	MatchupPeriodID int              `json:"matchupPeriodId"`
+	Winner          string           `json:"winner"`
+	Home            *espnMatchupTeam `json:"home"`
+	Away            *espnMatchupTeam `json:"away"`
+}
+
+type espnMatchupTeam struct {
+	TeamID      int     `json:"teamId"`
+	TotalPoints float64 `json:"totalPoints"`
+}
+
+//
+// Provider
+//
+
+func (e espn) ID() string {
+	return "espn"
+}
+
+func (e espn) DisplayName() string {
+	return "ESPN"
+}
+
+// GetLeague returns an ESPN league in a season. Each matchup period is
+// counted as a week, so playoff matchups that last two weeks count as one.
+func (e espn) GetLeague(ctx context.Context, leagueID string, season string) (*League, error) {
+	id, err := parseID(leagueID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := strconv.Atoi(season); err != nil {
+		return nil, fmt.Errorf("invalid season '%s'", season)
+	}
+
+	var cookies []*http.Cookie
+	if e.s2 != "" && e.swid != "" {
+		cookies = []*http.Cookie{
+			&http.Cookie{Name: "espn_s2", Value: e.s2},
+			&http.Cookie{Name: "SWID", Value: e.swid},
+		}
+	}
+	var response espnLeague
+	err = getJSON(
+		ctx,
+		fmt.Sprintf("%s/seasons/%s/segments/0/leagues/%d"+
+			"?view=mTeam&view=mMatchupScore&view=mSettings&view=mStatus",
+			e.baseURL,
+			season,
+			id),
+		&response,
+		cookies...)
+	if err != nil {
+		return nil, err
+	}
+	return response.league(leagueID, season), nil
+}
+
+// league converts the response from ESPN into a League
+func (r *espnLeague) league(leagueID string, season string) *League {
+	league := &League{
+		Provider: "espn",
+		ID:       leagueID,
+		Name:     r.Settings.Name,
+		Season:   season,
+		EndWeek:  r.Settings.ScheduleSettings.MatchupPeriodCount,
+	}
+
+	managers := make(map[string]string)
+	for _, member := range r.Members {
+		name := strings.TrimSpace(member.FirstName + " " + member.LastName)
+		if name == "" {
+			name = member.DisplayName
+		}
+		managers[member.ID] = name
+	}
+	divisions := make(map[int]string)
+	for _, division := range r.Settings.ScheduleSettings.Divisions {
+		divisions[division.ID] = division.Name
+	}
+	for _, team := range r.Teams {
+		name := team.Name
+		if name == "" {
+			name = strings.TrimSpace(team.Location + " " + team.Nickname)
+		}
+		owner := team.PrimaryOwner
+		if owner == "" && len(team.Owners) > 0 {
+			owner = team.Owners[0]
+		}
+		t := Team{
+			Key:      strconv.Itoa(team.ID),
+			Name:     name,
+			Manager:  managers[owner],
+			Division: divisions[team.DivisionID],
+			Logo:     team.Logo,
+		}
+		if !r.Status.IsActive {
+			t.Rank = team.RankCalculatedFinal
+		}
+		league.Teams = append(league.Teams, t)
+	}
+	if len(divisions) < 2 {
+		for index := range league.Teams {
+			league.Teams[index].Division = ""
+		}
+	}
+
+	// A week has been played once every matchup in it has a winner
+	undecided := make(map[int]bool)
+	lastWeek := 0
+	for _, matchup := range r.Schedule {
+		week := matchup.MatchupPeriodID
+		if matchup.Winner == espnUndecided || matchup.Winner == "" {
+			undecided[week] = true
+		}
+		if week > lastWeek {
+			lastWeek = week
+		}
+
+		m := Matchup{Week: week}
+		for _, team := range []*espnMatchupTeam{matchup.Home, matchup.Away} {
+			if team != nil {
+				m.Teams = append(m.Teams, MatchupTeam{
+					TeamKey: strconv.Itoa(team.TeamID),
+					Points:  team.TotalPoints,
+				})
+			}
+		}
+		league.Matchups = append(league.Matchups, m)
+	}
+	for league.CompletedWeeks < lastWeek && !undecided[league.CompletedWeeks+1] {
+		league.CompletedWeeks++
+	}
+
+	// Playoff matchups aren't in the schedule until the teams in them are
+	// known
+	regularSeasonWeeks := r.Settings.ScheduleSettings.MatchupPeriodCount
+	if playoffTeams := r.Settings.ScheduleSettings.PlayoffTeamCount; playoffTeams > 1 {
+		league.PlayoffStartWeek = regularSeasonWeeks + 1
+		league.EndWeek = regularSeasonWeeks + playoffRounds(playoffTeams)
+	}
+	return league
+}
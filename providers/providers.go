@@ -0,0 +1,243 @@
+// Package providers gets fantasy leagues from Yahoo, ESPN and Sleeper using
+// the same provider-neutral models, so the power rankings of a league from any
+// of them can be calculated the same way.
+//
+// Each provider is found in a registry by its ID and converts the leagues it
+// gets into a League with its teams and the matchups of every week. NewClient
+// turns a League into a client for the rankings package.
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/Forestmb/power-league/offline"
+	"github.com/golang/glog"
+)
+
+// ErrPrivateLeague is returned when a league can't be accessed without
+// logging in to its provider
+var ErrPrivateLeague = errors.New("the league is private")
+
+// ErrLoginRequired is returned when getting a league from a provider that
+// only has the leagues of a logged in user
+var ErrLoginRequired = errors.New("log in to get leagues from this site")
+
+// ErrLeagueNotFound is returned when a provider has no league with the
+// requested ID
+var ErrLeagueNotFound = errors.New("the league does not exist")
+
+// HTTPClient is used to make requests to every provider
+var HTTPClient = http.DefaultClient
+
+//
+// Interface
+//
+
+// Provider gets fantasy leagues from a fantasy sports site
+type Provider interface {
+	// Unique identifier of the provider, used in URLs
+	ID() string
+
+	// Name of the provider shown to users
+	DisplayName() string
+
+	// GetLeague returns the league with the given ID in a season, along with
+	// the matchups of every week
+	GetLeague(ctx context.Context, leagueID string, season string) (*League, error)
+}
+
+//
+// Data structures
+//
+
+// League is a fantasy league from any provider
+type League struct {
+	Provider string
+	ID       string
+	Name     string
+	Season   string
+
+	// Last week of the season and first week of the playoffs, if the league
+	// has playoffs
+	EndWeek          int
+	PlayoffStartWeek int
+
+	// Number of weeks that have been played
+	CompletedWeeks int
+
+	Teams    []Team
+	Matchups []Matchup
+}
+
+// Team is a single team in a league
+type Team struct {
+	Key      string
+	Name     string
+	Manager  string
+	Division string
+	Logo     string
+
+	// Final rank of the team, once the season is finished
+	Rank int
+}
+
+// Matchup is a group of teams, usually two, playing each other in a week. A
+// team with a bye is in a matchup by itself.
+type Matchup struct {
+	Week  int
+	Teams []MatchupTeam
+}
+
+// MatchupTeam is the points a team scored or is projected to score in a
+// matchup
+type MatchupTeam struct {
+	TeamKey         string
+	Points          float64
+	ProjectedPoints float64
+}
+
+//
+// Functions
+//
+
+// GetProviders returns the supported providers. Leagues can't be requested
+// from the Yahoo provider until it's given the client of a logged in user by
+// NewYahoo.
+func GetProviders() []Provider {
+	return []Provider{
+		espn{baseURL: ESPNBaseURL, s2: ESPNS2, swid: ESPNSWID},
+		sleeper{baseURL: SleeperBaseURL},
+		yahoo{},
+	}
+}
+
+// GetProvider returns the supported provider with the given ID
+func GetProvider(id string) (Provider, bool) {
+	for _, provider := range GetProviders() {
+		if provider.ID() == id {
+			return provider, true
+		}
+	}
+	return nil, false
+}
+
+// NewClient creates a client to calculate the power rankings of a league from
+// any provider. Points are only counted for weeks that have been played.
+func NewClient(l *League) (*offline.Client, error) {
+	endWeek := l.EndWeek
+	for _, matchup := range l.Matchups {
+		if matchup.Week > endWeek {
+			endWeek = matchup.Week
+		}
+	}
+
+	league := &offline.League{
+		Name:             l.Name,
+		Season:           l.Season,
+		EndWeek:          endWeek,
+		PlayoffStartWeek: l.PlayoffStartWeek,
+		Teams:            make([]offline.Team, len(l.Teams)),
+		Weeks:            make([]offline.Week, endWeek),
+	}
+	for index, team := range l.Teams {
+		league.Teams[index] = offline.Team{
+			Key:      team.Key,
+			Name:     team.Name,
+			Manager:  team.Manager,
+			Division: team.Division,
+			Logo:     team.Logo,
+			Rank:     team.Rank,
+		}
+	}
+	for _, matchup := range l.Matchups {
+		if matchup.Week < 1 {
+			continue
+		}
+		week := &league.Weeks[matchup.Week-1]
+		var teamKeys []string
+		for _, team := range matchup.Teams {
+			if matchup.Week <= l.CompletedWeeks {
+				if week.Scores == nil {
+					week.Scores = make(map[string]float64)
+				}
+				week.Scores[team.TeamKey] = team.Points
+			} else if team.ProjectedPoints != 0.0 {
+				if week.Projected == nil {
+					week.Projected = make(map[string]float64)
+				}
+				week.Projected[team.TeamKey] = team.ProjectedPoints
+			}
+			teamKeys = append(teamKeys, team.TeamKey)
+		}
+		// Byes don't count as matchups
+		if len(teamKeys) == 2 {
+			week.Matchups = append(week.Matchups, teamKeys)
+		}
+	}
+
+	client, err := offline.NewClient(league)
+	if err != nil {
+		return nil, err
+	}
+	client.League().LeagueKey = fmt.Sprintf("%s.%s.%s", l.Provider, l.Season, l.ID)
+	return client, nil
+}
+
+// getJSON decodes the JSON response of a GET request, using the given cookies
+// to access private leagues
+func getJSON(
+	ctx context.Context,
+	url string,
+	response interface{},
+	cookies ...*http.Cookie) error {
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	for _, cookie := range cookies {
+		req.AddCookie(cookie)
+	}
+
+	glog.V(3).Infof("requesting league data -- url=%s", url)
+	resp, err := HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return json.NewDecoder(resp.Body).Decode(response)
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return ErrPrivateLeague
+	case http.StatusNotFound:
+		return ErrLeagueNotFound
+	}
+	return fmt.Errorf("unexpected response from %s: %s", url, resp.Status)
+}
+
+// playoffRounds returns the number of weeks it takes for one of the given
+// number of playoff teams to win every matchup
+func playoffRounds(playoffTeams int) int {
+	rounds := 0
+	for teams := 1; teams < playoffTeams; teams *= 2 {
+		rounds++
+	}
+	return rounds
+}
+
+// parseID returns a league ID as a number, the form used by every provider
+func parseID(leagueID string) (int64, error) {
+	id, err := strconv.ParseInt(leagueID, 10, 64)
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("invalid league ID '%s'", leagueID)
+	}
+	return id, nil
+}
@@ -0,0 +1,207 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+)
+
+//
+// Configuration variables
+//
+
+// SleeperBaseURL is the base URL of Sleeper's API
+var SleeperBaseURL = "https://api.sleeper.app/v1"
+
+// sleeperWeeks is the most weeks in a Sleeper season, used when a league
+// doesn't have playoffs
+const sleeperWeeks = 18
+
+// sleeper implements Provider for Sleeper fantasy football leagues
+type sleeper struct {
+	baseURL string
+}
+
+//
+// API responses
+//
+
+type sleeperLeague struct {
+	LeagueID string            `json:"league_id"`
+	Name     string            `json:"name"`
+	Season   string            `json:"season"`
+	Status   string            `json:"status"`
+	Metadata map[string]string `json:"metadata"`
+	Settings struct {
+		PlayoffWeekStart int `json:"playoff_week_start"`
+		PlayoffTeams     int `json:"playoff_teams"`
+		LastScoredLeg    int `json:"last_scored_leg"`
+		Divisions        int `json:"divisions"`
+	} `json:"settings"`
+}
+
+type sleeperUser struct {
+	UserID      string `json:"user_id"`
+	DisplayName string `json:"display_name"`
+	Avatar      string `json:"avatar"`
+	Metadata    struct {
+		TeamName string `json:"team_name"`
+	} `json:"metadata"`
+}
+
+type sleeperRoster struct {
+	RosterID int    `json:"roster_id"`
+	OwnerID  string `json:"owner_id"`
+	Settings struct {
+		Division int `json:"division"`
+	} `json:"settings"`
+}
+
+type sleeperMatchup struct {
+	RosterID     int      `json:"roster_id"`
+	MatchupID    *int     `json:"matchup_id"`
+	Points       float64  `json:"points"`
+	CustomPoints *float64 `json:"custom_points"`
+}
+
+//
+// Provider
+//
+
+func (s sleeper) ID() string {
+	return "sleeper"
+}
+
+func (s sleeper) DisplayName() string {
+	return "Sleeper"
+}
+
+// GetLeague returns a Sleeper league. Sleeper creates a new league ID for
+// every season, so the season is only used to check the ID is for the season
+// that was asked for.
+func (s sleeper) GetLeague(ctx context.Context, leagueID string, season string) (*League, error) {
+	id, err := parseID(leagueID)
+	if err != nil {
+		return nil, err
+	}
+	leagueURL := fmt.Sprintf("%s/league/%d", s.baseURL, id)
+
+	var response sleeperLeague
+	if err = getJSON(ctx, leagueURL, &response); err != nil {
+		return nil, err
+	}
+	// Sleeper returns null for leagues that don't exist
+	if response.LeagueID == "" {
+		return nil, ErrLeagueNotFound
+	}
+	if season != "" && response.Season != season {
+		return nil, fmt.Errorf("league %s is from the %s season, not %s",
+			leagueID,
+			response.Season,
+			season)
+	}
+
+	var users []sleeperUser
+	if err = getJSON(ctx, leagueURL+"/users", &users); err != nil {
+		return nil, err
+	}
+	var rosters []sleeperRoster
+	if err = getJSON(ctx, leagueURL+"/rosters", &rosters); err != nil {
+		return nil, err
+	}
+
+	league := response.league(users, rosters)
+	for week := 1; week <= league.EndWeek; week++ {
+		var matchups []sleeperMatchup
+		err = getJSON(ctx, fmt.Sprintf("%s/matchups/%d", leagueURL, week), &matchups)
+		if err != nil {
+			return nil, err
+		}
+		league.Matchups = append(league.Matchups, sleeperMatchups(week, matchups)...)
+	}
+	return league, nil
+}
+
+// league converts the responses from Sleeper into a League, without its
+// matchups
+func (r *sleeperLeague) league(users []sleeperUser, rosters []sleeperRoster) *League {
+	league := &League{
+		Provider: "sleeper",
+		ID:       r.LeagueID,
+		Name:     r.Name,
+		Season:   r.Season,
+		EndWeek:  sleeperWeeks,
+	}
+	if r.Settings.PlayoffWeekStart > 0 {
+		league.PlayoffStartWeek = r.Settings.PlayoffWeekStart
+		league.EndWeek = r.Settings.PlayoffWeekStart + playoffRounds(r.Settings.PlayoffTeams) - 1
+	}
+	if r.Status == "complete" {
+		league.CompletedWeeks = league.EndWeek
+	} else if r.Status == "in_season" || r.Status == "post_season" {
+		league.CompletedWeeks = r.Settings.LastScoredLeg
+	}
+
+	usersByID := make(map[string]sleeperUser)
+	for _, user := range users {
+		usersByID[user.UserID] = user
+	}
+	for _, roster := range rosters {
+		user := usersByID[roster.OwnerID]
+		team := Team{
+			Key:     strconv.Itoa(roster.RosterID),
+			Name:    user.Metadata.TeamName,
+			Manager: user.DisplayName,
+		}
+		if team.Name == "" {
+			team.Name = user.DisplayName
+		}
+		if team.Name == "" {
+			team.Name = fmt.Sprintf("Team %d", roster.RosterID)
+		}
+		if user.Avatar != "" {
+			team.Logo = "https://sleepercdn.com/avatars/thumbs/" + user.Avatar
+		}
+		if r.Settings.Divisions > 1 && roster.Settings.Division > 0 {
+			team.Division = r.Metadata[fmt.Sprintf("division_%d", roster.Settings.Division)]
+			if team.Division == "" {
+				team.Division = fmt.Sprintf("Division %d", roster.Settings.Division)
+			}
+		}
+		league.Teams = append(league.Teams, team)
+	}
+	return league
+}
+
+// sleeperMatchups groups the teams playing in a week by their matchup. Teams
+// without a matchup have a bye.
+func sleeperMatchups(week int, teams []sleeperMatchup) []Matchup {
+	var matchups []Matchup
+	indexByMatchupID := make(map[int]int)
+	for _, team := range teams {
+		points := team.Points
+		if team.CustomPoints != nil {
+			points = *team.CustomPoints
+		}
+		matchupTeam := MatchupTeam{
+			TeamKey: strconv.Itoa(team.RosterID),
+			Points:  points,
+		}
+
+		if team.MatchupID == nil {
+			matchups = append(matchups, Matchup{
+				Week:  week,
+				Teams: []MatchupTeam{matchupTeam},
+			})
+			continue
+		}
+		index, ok := indexByMatchupID[*team.MatchupID]
+		if !ok {
+			index = len(matchups)
+			indexByMatchupID[*team.MatchupID] = index
+			matchups = append(matchups, Matchup{Week: week})
+		}
+		matchups[index].Teams = append(matchups[index].Teams, matchupTeam)
+	}
+	return matchups
+}
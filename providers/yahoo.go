@@ -0,0 +1,171 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+
+	"github.com/Forestmb/goff"
+	"github.com/Forestmb/power-league/rankings"
+)
+
+//
+// Configuration variables
+//
+
+// YahooID identifies the Yahoo provider
+const YahooID = "yahoo"
+
+// errYahooCategoryLeague is returned for Yahoo leagues that compare teams
+// using stat categories, since only the points of each team are kept
+var errYahooCategoryLeague = errors.New(
+	"only leagues that score fantasy points can be imported")
+
+// YahooClient is the part of goff.Client used to get Yahoo leagues
+type YahooClient interface {
+	GetUserLeagues(year string) ([]goff.League, error)
+	GetLeagueStandings(leagueKey string) (*goff.League, error)
+	GetMatchupsForWeekRange(leagueKey string, startWeek, endWeek int) (map[int][]goff.Matchup, error)
+}
+
+// yahoo implements Provider for Yahoo fantasy football leagues, using the
+// client of a logged in user
+type yahoo struct {
+	client YahooClient
+}
+
+//
+// Provider
+//
+
+// NewYahoo returns the Yahoo provider, getting the leagues of the user the
+// client makes requests for
+func NewYahoo(client YahooClient) Provider {
+	return yahoo{client: client}
+}
+
+func (y yahoo) ID() string {
+	return YahooID
+}
+
+func (y yahoo) DisplayName() string {
+	return "Yahoo"
+}
+
+// GetLeague returns one of the user's Yahoo leagues. Yahoo league IDs are only
+// unique within a season, so the season is used to find the league.
+func (y yahoo) GetLeague(ctx context.Context, leagueID string, season string) (*League, error) {
+	if y.client == nil {
+		return nil, ErrLoginRequired
+	}
+	id, err := parseID(leagueID)
+	if err != nil {
+		return nil, err
+	}
+	if season == "" {
+		return nil, errors.New("the season of the league is required")
+	}
+
+	leagues, err := y.client.GetUserLeagues(season)
+	if err != nil {
+		return nil, err
+	}
+	leagueKey := ""
+	for _, league := range leagues {
+		if league.LeagueID == uint64(id) {
+			leagueKey = league.LeagueKey
+		}
+	}
+	if leagueKey == "" {
+		return nil, ErrLeagueNotFound
+	}
+
+	if err = ctx.Err(); err != nil {
+		return nil, err
+	}
+	standings, err := y.client.GetLeagueStandings(leagueKey)
+	if err != nil {
+		return nil, err
+	}
+	if rankings.IsCategoryLeague(standings) {
+		return nil, errYahooCategoryLeague
+	}
+
+	if err = ctx.Err(); err != nil {
+		return nil, err
+	}
+	startWeek := standings.StartWeek
+	if startWeek < 1 {
+		startWeek = 1
+	}
+	allMatchups, err := y.client.GetMatchupsForWeekRange(
+		leagueKey,
+		startWeek,
+		standings.EndWeek)
+	if err != nil {
+		return nil, err
+	}
+	return yahooLeague(standings, allMatchups, season), nil
+}
+
+// yahooLeague converts a Yahoo league and its matchups into a League. Weeks
+// are numbered from the first week of the league.
+func yahooLeague(l *goff.League, allMatchups map[int][]goff.Matchup, season string) *League {
+	offset := 0
+	if l.StartWeek > 1 {
+		offset = l.StartWeek - 1
+	}
+	league := &League{
+		Provider: YahooID,
+		ID:       strconv.FormatUint(l.LeagueID, 10),
+		Name:     l.Name,
+		Season:   season,
+		EndWeek:  l.EndWeek - offset,
+	}
+	if l.Settings.UsesPlayoff && l.Settings.PlayoffStartWeek > offset {
+		league.PlayoffStartWeek = l.Settings.PlayoffStartWeek - offset
+	}
+	if l.IsFinished {
+		league.CompletedWeeks = league.EndWeek
+	} else if l.CurrentWeek-offset > 1 {
+		league.CompletedWeeks = l.CurrentWeek - offset - 1
+	}
+
+	for _, standing := range l.Standings {
+		team := Team{
+			Key:  standing.TeamKey,
+			Name: standing.Name,
+		}
+		if len(standing.Managers) > 0 {
+			team.Manager = standing.Managers[0].Nickname
+		}
+		if len(standing.TeamLogos) > 0 {
+			team.Logo = standing.TeamLogos[0].URL
+		}
+		if l.IsFinished {
+			team.Rank = standing.TeamStandings.Rank
+		}
+		league.Teams = append(league.Teams, team)
+	}
+
+	var weeks []int
+	for week := range allMatchups {
+		weeks = append(weeks, week)
+	}
+	sort.Ints(weeks)
+	for _, week := range weeks {
+		for _, matchup := range allMatchups[week] {
+			m := Matchup{Week: week - offset}
+			for _, team := range matchup.Teams {
+				m.Teams = append(m.Teams, MatchupTeam{
+					TeamKey:         team.TeamKey,
+					Points:          team.TeamPoints.Total,
+					ProjectedPoints: team.TeamProjectedPoints.Total,
+				})
+			}
+			league.Matchups = append(league.Matchups, m)
+		}
+	}
+	return league
+}
@@ -0,0 +1,329 @@
+package rankings
+
+import (
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Forestmb/goff"
+	"github.com/golang/glog"
+)
+
+// Sports that fantasy leagues can be played for
+var Sports = struct {
+	FOOTBALL   string
+	BASEBALL   string
+	BASKETBALL string
+	HOCKEY     string
+}{
+	"football",
+	"baseball",
+	"basketball",
+	"hockey",
+}
+
+// gameDays are the share of a week's games played on each day of the week by
+// sport. Football games are played on Thursday, Sunday and Monday, while the
+// other sports play every day.
+var gameDays = map[string][7]float64{
+	Sports.FOOTBALL: {
+		time.Sunday:   13.0,
+		time.Monday:   1.0,
+		time.Thursday: 1.0,
+	},
+}
+
+// everyDay is used for sports that play games every day of the week
+var everyDay = [7]float64{1.0, 1.0, 1.0, 1.0, 1.0, 1.0, 1.0}
+
+//
+// Data structures
+//
+
+// SeasonCalendar describes the scoring periods of a league's season.
+//
+// Power rankings number a league's scoring periods from 1, while the fantasy
+// sports provider numbers them by the weeks of the sport's season. Football
+// leagues start in the first week, but baseball, basketball and hockey leagues
+// often start weeks into the season. Long periods, such as those spanning an
+// all-star break, are a single matchup and so count as a single period. When
+// the provider numbers the break as its own week without any matchups, that
+// week is one of the BreakWeeks and isn't a period at all.
+type SeasonCalendar struct {
+	Sport     string
+	StartWeek int
+	EndWeek   int
+
+	// Weeks of the provider between the start and end weeks without any
+	// matchups, in order
+	BreakWeeks []int
+}
+
+//
+// Functions
+//
+
+// GetSeasonCalendar returns the calendar of a league's season
+func GetSeasonCalendar(l *goff.League) *SeasonCalendar {
+	startWeek := l.StartWeek
+	if startWeek < 1 {
+		startWeek = 1
+	}
+	return &SeasonCalendar{
+		Sport:     GetSport(l),
+		StartWeek: startWeek,
+		EndWeek:   l.EndWeek,
+	}
+}
+
+// FindSeasonCalendar returns the calendar of a league's season like
+// GetSeasonCalendar, along with the weeks of the season without any matchups.
+// Only sports with all-star breaks are checked, which takes a request for the
+// matchups of the whole season.
+func FindSeasonCalendar(client PowerRankingsClient, l *goff.League) (*SeasonCalendar, error) {
+	calendar := GetSeasonCalendar(l)
+	if calendar.Sport == Sports.FOOTBALL || calendar.EndWeek <= calendar.StartWeek {
+		return calendar, nil
+	}
+
+	allMatchups, err := client.GetMatchupsForWeekRange(
+		l.LeagueKey,
+		calendar.StartWeek,
+		calendar.EndWeek)
+	if err != nil {
+		return nil, err
+	}
+	calendar.BreakWeeks = findBreakWeeks(allMatchups, calendar.StartWeek, calendar.EndWeek)
+	if len(calendar.BreakWeeks) > 0 {
+		glog.V(2).Infof("found weeks without matchups -- league=%s, weeks=%v",
+			l.LeagueKey,
+			calendar.BreakWeeks)
+	}
+	return calendar, nil
+}
+
+// findBreakWeeks returns the weeks without any matchups between the first and
+// last weeks with matchups. Weeks at the start or end of the season without
+// matchups haven't been scheduled, rather than being a break.
+func findBreakWeeks(allMatchups map[int][]goff.Matchup, startWeek int, endWeek int) []int {
+	first, last := 0, 0
+	for week := startWeek; week <= endWeek; week++ {
+		if len(allMatchups[week]) > 0 {
+			if first == 0 {
+				first = week
+			}
+			last = week
+		}
+	}
+
+	var breakWeeks []int
+	for week := first + 1; first > 0 && week < last; week++ {
+		if len(allMatchups[week]) == 0 {
+			breakWeeks = append(breakWeeks, week)
+		}
+	}
+	return breakWeeks
+}
+
+// GetSport returns the sport of a league using the host of its URL, e.g.
+// https://baseball.fantasysports.yahoo.com/b1/1234. Leagues without a
+// recognized URL are assumed to be football leagues.
+func GetSport(l *goff.League) string {
+	u, err := url.Parse(l.URL)
+	if err != nil {
+		return Sports.FOOTBALL
+	}
+	host := strings.ToLower(u.Hostname())
+	for _, sport := range []string{
+		Sports.BASEBALL,
+		Sports.BASKETBALL,
+		Sports.HOCKEY,
+	} {
+		if strings.HasPrefix(host, sport+".") {
+			return sport
+		}
+	}
+	return Sports.FOOTBALL
+}
+
+// Periods returns the number of scoring periods in the season
+func (c *SeasonCalendar) Periods() int {
+	if c.EndWeek < c.StartWeek {
+		return 0
+	}
+	return c.EndWeek - c.StartWeek + 1 - c.breaksBefore(c.EndWeek+1)
+}
+
+// Period returns the scoring period of a week used by the fantasy sports
+// provider. A break week is part of the period after it.
+func (c *SeasonCalendar) Period(week int) int {
+	return week - c.StartWeek + 1 - c.breaksBefore(week)
+}
+
+// ProviderWeek returns the week used by the fantasy sports provider for a
+// scoring period
+func (c *SeasonCalendar) ProviderWeek(period int) int {
+	week := period + c.StartWeek - 1
+	for _, breakWeek := range c.BreakWeeks {
+		if breakWeek <= week {
+			week++
+		}
+	}
+	return week
+}
+
+// breaksBefore returns the number of break weeks before the given week
+func (c *SeasonCalendar) breaksBefore(week int) int {
+	breaks := 0
+	for _, breakWeek := range c.BreakWeeks {
+		if breakWeek < week {
+			breaks++
+		}
+	}
+	return breaks
+}
+
+// WeekRemaining returns the fraction of the games in the week being played at
+// the given time that are still to come, counting the games of each day as
+// played evenly through the day. Football weeks end on Monday and the weeks of
+// other sports end on Sunday.
+func (c *SeasonCalendar) WeekRemaining(now time.Time) float64 {
+	days, ok := gameDays[c.Sport]
+	if !ok {
+		days = everyDay
+	}
+	lastDay := time.Sunday
+	if c.Sport == Sports.FOOTBALL {
+		lastDay = time.Monday
+	}
+
+	total, remaining := 0.0, 0.0
+	today := now.Weekday()
+	started := false
+	for offset := 1; offset <= 7; offset++ {
+		day := (lastDay + time.Weekday(offset)) % 7
+		total += days[day]
+		if day == today {
+			started = true
+			elapsed := time.Duration(now.Hour())*time.Hour +
+				time.Duration(now.Minute())*time.Minute
+			remaining += days[day] * (1 - elapsed.Hours()/24)
+		} else if started {
+			remaining += days[day]
+		}
+	}
+	return remaining / total
+}
+
+// CompletedPeriods returns the number of scoring periods in a league that
+// have been completed
+func (c *SeasonCalendar) CompletedPeriods(l *goff.League) int {
+	completed := c.Period(l.CurrentWeek) - 1
+	if l.IsFinished {
+		completed = c.Period(l.CurrentWeek)
+	}
+	if completed < 0 {
+		return 0
+	}
+	if c.EndWeek > 0 && completed > c.Periods() {
+		return c.Periods()
+	}
+	return completed
+}
+
+// wrapClient returns a client that uses the scoring periods of the calendar
+// in place of the weeks used by the given client. Clients for seasons that
+// start in the first week without any breaks are returned unchanged.
+func (c *SeasonCalendar) wrapClient(client PowerRankingsClient) PowerRankingsClient {
+	if c.StartWeek <= 1 && len(c.BreakWeeks) == 0 {
+		return client
+	}
+	return &calendarClient{client: client, calendar: c}
+}
+
+// calendarClient translates between the scoring periods of a season calendar
+// and the weeks used by a PowerRankingsClient. It implements every optional
+// client interface, which must only be used when the wrapped client does.
+type calendarClient struct {
+	client   PowerRankingsClient
+	calendar *SeasonCalendar
+}
+
+func (c *calendarClient) GetAllTeamStats(
+	leagueKey string,
+	week int,
+	projected bool) ([]goff.Team, error) {
+
+	return c.client.GetAllTeamStats(
+		leagueKey,
+		c.calendar.ProviderWeek(week),
+		projected)
+}
+
+func (c *calendarClient) GetMatchupsForWeekRange(
+	leagueKey string,
+	startWeek int,
+	endWeek int) (map[int][]goff.Matchup, error) {
+
+	providerMatchups, err := c.client.GetMatchupsForWeekRange(
+		leagueKey,
+		c.calendar.ProviderWeek(startWeek),
+		c.calendar.ProviderWeek(endWeek))
+	if err != nil {
+		return nil, err
+	}
+
+	allMatchups := make(map[int][]goff.Matchup)
+	for week, matchups := range providerMatchups {
+		if week < c.calendar.StartWeek || week > c.calendar.EndWeek {
+			continue
+		}
+		period := c.calendar.Period(week)
+		for _, matchup := range matchups {
+			matchup.Week = period
+			allMatchups[period] = append(allMatchups[period], matchup)
+		}
+	}
+	return allMatchups, nil
+}
+
+func (c *calendarClient) GetLeagueStandings(leagueKey string) (*goff.League, error) {
+	league, err := c.client.GetLeagueStandings(leagueKey)
+	if err != nil || league == nil {
+		return league, err
+	}
+
+	periodLeague := *league
+	periodLeague.CurrentWeek = c.calendar.Period(league.CurrentWeek)
+	periodLeague.StartWeek = 1
+	periodLeague.EndWeek = c.calendar.Periods()
+	if league.Settings.PlayoffStartWeek > 0 {
+		periodLeague.Settings.PlayoffStartWeek =
+			c.calendar.Period(league.Settings.PlayoffStartWeek)
+	}
+	return &periodLeague, nil
+}
+
+func (c *calendarClient) GetTeamRosterStats(
+	leagueKey string,
+	teamKey string,
+	week int) ([]goff.Player, error) {
+
+	return c.client.(RosterClient).GetTeamRosterStats(
+		leagueKey,
+		teamKey,
+		c.calendar.ProviderWeek(week))
+}
+
+func (c *calendarClient) GetStatCategories(leagueKey string) ([]StatCategory, error) {
+	return c.client.(CategoryClient).GetStatCategories(leagueKey)
+}
+
+func (c *calendarClient) GetTeamStatLines(
+	leagueKey string,
+	week int) ([]TeamStatLine, error) {
+
+	return c.client.(CategoryClient).GetTeamStatLines(
+		leagueKey,
+		c.calendar.ProviderWeek(week))
+}
@@ -0,0 +1,337 @@
+package rankings
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/Forestmb/goff"
+	"github.com/golang/glog"
+)
+
+// ErrCategoryLeague is returned when calculating power rankings for a
+// category league using a client that can't get the stats of each team
+var ErrCategoryLeague = errors.New(
+	"rankings: category leagues require team stats by category")
+
+// categoryScoringTypes are the league scoring types that use stat categories
+// instead of fantasy points
+var categoryScoringTypes = map[string]bool{
+	"head":    true,
+	"headone": true,
+	"roto":    true,
+}
+
+//
+// Interface
+//
+
+// CategoryClient is implemented by a PowerRankingsClient that can get the
+// stat categories of a league and the stats of each team
+type CategoryClient interface {
+	GetStatCategories(leagueKey string) ([]StatCategory, error)
+	GetTeamStatLines(leagueKey string, week int) ([]TeamStatLine, error)
+}
+
+// A CategoryScheme is a Scheme that ranks teams in a category league using
+// their stats in each category
+type CategoryScheme interface {
+	Scheme
+	CalculateCategoryRankings(
+		week int,
+		categories []StatCategory,
+		statLines []TeamStatLine,
+		projected bool) *WeeklyRanking
+}
+
+//
+// Data structures
+//
+
+// StatCategory is a stat used to compare teams in a category league
+type StatCategory struct {
+	ID   string
+	Name string
+
+	// Whether teams with lower values in this category are better, e.g. ERA
+	LowerIsBetter bool
+}
+
+// TeamStatLine contains the value of each stat category for a single team.
+// Teams without any stats for a week are not compared to other teams.
+type TeamStatLine struct {
+	Team  goff.Team
+	Stats map[string]float64
+}
+
+//
+// Functions
+//
+
+// IsCategoryLeague returns whether a league compares teams using stat
+// categories instead of fantasy points
+func IsCategoryLeague(l *goff.League) bool {
+	return categoryScoringTypes[l.Settings.ScoringType]
+}
+
+// GetCategorySchemes returns the supported rankings formats for category
+// leagues
+func GetCategorySchemes() []Scheme {
+	return []Scheme{
+		categoryAllPlay{},
+		rotoPoints{},
+	}
+}
+
+// GetCategoryWeeklyRanking returns a category league's rankings for a specific
+// week. There are no projected stats, so projected weeks include every team
+// without any stats.
+func GetCategoryWeeklyRanking(
+	client CategoryClient,
+	leagueKey string,
+	week int,
+	teams []goff.Team,
+	categories []StatCategory,
+	results chan *WeeklyRanking,
+	errors chan error,
+	projected bool,
+	schemes []CategoryScheme) {
+
+	var statLines []TeamStatLine
+	if projected {
+		for _, team := range teams {
+			statLines = append(statLines, TeamStatLine{Team: team})
+		}
+	} else {
+		var err error
+		statLines, err = client.GetTeamStatLines(leagueKey, week)
+		if err != nil {
+			glog.Warningf("couldn't retrieve team stats for week %d: %s", week, err.Error())
+			errors <- err
+			return
+		}
+	}
+
+	for _, scheme := range schemes {
+		statLinesForScheme := make([]TeamStatLine, len(statLines))
+		copy(statLinesForScheme, statLines)
+		results <- scheme.CalculateCategoryRankings(
+			week,
+			categories,
+			statLinesForScheme,
+			projected)
+	}
+}
+
+// compareCategory returns 1 if the first value is better in a category, -1 if
+// the second value is better, and 0 if they are tied
+func compareCategory(category StatCategory, first float64, second float64) int {
+	if first == second {
+		return 0
+	}
+	if (first > second) != category.LowerIsBetter {
+		return 1
+	}
+	return -1
+}
+
+// rankCategoryScores converts team stat lines into TeamScoreData ordered and
+// ranked by the given power scores, or records for record schemes
+func rankCategoryScores(
+	scheme Scheme,
+	statLines []TeamStatLine,
+	powerScores []float64,
+	records []*goff.Record,
+	projected bool) []*TeamScoreData {
+
+	rankings := make([]*TeamScoreData, len(statLines))
+	for index := range statLines {
+		rankings[index] = &TeamScoreData{
+			Team:       &statLines[index].Team,
+			PowerScore: powerScores[index],
+			Record:     records[index],
+			Projected:  projected,
+		}
+	}
+
+	value := func(teamScore *TeamScoreData) float64 {
+		if scheme.Type() == Types.RECORD {
+			return winPercentage(teamScore.Record)
+		}
+		return teamScore.PowerScore
+	}
+	sort.SliceStable(rankings, func(i, j int) bool {
+		return value(rankings[i]) > value(rankings[j])
+	})
+	for i := range rankings {
+		if i > 0 && value(rankings[i]) == value(rankings[i-1]) {
+			rankings[i].Rank = rankings[i-1].Rank
+		} else {
+			rankings[i].Rank = i + 1
+		}
+	}
+	return rankings
+}
+
+// 'Category All-Play' scheme
+type categoryAllPlay struct {
+}
+
+func (c categoryAllPlay) ID() string {
+	return "category-all-play"
+}
+
+func (c categoryAllPlay) DisplayName() string {
+	return "Category All-Play"
+}
+
+func (c categoryAllPlay) Type() string {
+	return Types.RECORD
+}
+
+// CalculateWeeklyRankings for a 'Category All-Play' scheme doesn't have any
+// stats, so no team wins or loses.
+//
+// See CalculateCategoryRankings
+func (c categoryAllPlay) CalculateWeeklyRankings(
+	week int,
+	teams []goff.Team,
+	projected bool,
+	results chan *WeeklyRanking) {
+
+	statLines := make([]TeamStatLine, len(teams))
+	for index, team := range teams {
+		statLines[index] = TeamStatLine{Team: team}
+	}
+	results <- c.CalculateCategoryRankings(week, nil, statLines, projected)
+}
+
+// CalculateCategoryRankings for a 'Category All-Play' scheme compares each
+// team to every other team in every category. A team gets a win for each
+// category it is better in, a loss for each category it is worse in, and a
+// tie for each category with the same value.
+func (c categoryAllPlay) CalculateCategoryRankings(
+	week int,
+	categories []StatCategory,
+	statLines []TeamStatLine,
+	projected bool) *WeeklyRanking {
+
+	records := make([]*goff.Record, len(statLines))
+	powerScores := make([]float64, len(statLines))
+	for i, statLine := range statLines {
+		records[i] = &goff.Record{}
+		if len(statLine.Stats) == 0 {
+			continue
+		}
+		for j, other := range statLines {
+			if i == j || len(other.Stats) == 0 {
+				continue
+			}
+			for _, category := range categories {
+				switch compareCategory(
+					category,
+					statLine.Stats[category.ID],
+					other.Stats[category.ID]) {
+				case 1:
+					records[i].Wins++
+				case -1:
+					records[i].Losses++
+				default:
+					records[i].Ties++
+				}
+			}
+		}
+	}
+
+	return &WeeklyRanking{
+		Scheme:    c,
+		Week:      week,
+		Rankings:  rankCategoryScores(c, statLines, powerScores, records, projected),
+		Projected: projected,
+	}
+}
+
+// 'Roto Points' scheme
+type rotoPoints struct {
+}
+
+func (r rotoPoints) ID() string {
+	return "roto-points"
+}
+
+func (r rotoPoints) DisplayName() string {
+	return "Roto Points"
+}
+
+func (r rotoPoints) Type() string {
+	return Types.SCORE
+}
+
+// CalculateWeeklyRankings for a 'Roto Points' scheme doesn't have any stats,
+// so no team gets any points.
+//
+// See CalculateCategoryRankings
+func (r rotoPoints) CalculateWeeklyRankings(
+	week int,
+	teams []goff.Team,
+	projected bool,
+	results chan *WeeklyRanking) {
+
+	statLines := make([]TeamStatLine, len(teams))
+	for index, team := range teams {
+		statLines[index] = TeamStatLine{Team: team}
+	}
+	results <- r.CalculateCategoryRankings(week, nil, statLines, projected)
+}
+
+// CalculateCategoryRankings for a 'Roto Points' scheme ranks teams in each
+// category. In a league of N teams, the best team in a category gets N points
+// and the worst gets 1, with tied teams splitting the points for the places
+// they share. A team's power score is the sum of its points in every category.
+func (r rotoPoints) CalculateCategoryRankings(
+	week int,
+	categories []StatCategory,
+	statLines []TeamStatLine,
+	projected bool) *WeeklyRanking {
+
+	var competing []int
+	for i, statLine := range statLines {
+		if len(statLine.Stats) > 0 {
+			competing = append(competing, i)
+		}
+	}
+
+	records := make([]*goff.Record, len(statLines))
+	for i := range records {
+		records[i] = &goff.Record{}
+	}
+	powerScores := make([]float64, len(statLines))
+	for _, category := range categories {
+		for _, i := range competing {
+			// Count the teams this team beat or tied in the category
+			better := 0
+			tied := 0
+			for _, j := range competing {
+				if i == j {
+					continue
+				}
+				switch compareCategory(
+					category,
+					statLines[i].Stats[category.ID],
+					statLines[j].Stats[category.ID]) {
+				case 1:
+					better++
+				case 0:
+					tied++
+				}
+			}
+			powerScores[i] += 1.0 + float64(better) + 0.5*float64(tied)
+		}
+	}
+
+	return &WeeklyRanking{
+		Scheme:    r,
+		Week:      week,
+		Rankings:  rankCategoryScores(r, statLines, powerScores, records, projected),
+		Projected: projected,
+	}
+}
@@ -0,0 +1,104 @@
+package rankings
+
+import (
+	"sort"
+
+	"github.com/Forestmb/goff"
+)
+
+//
+// Configuration variables
+//
+
+// BoomPercentile is the percentile of every score in a league a team's score
+// must be above for its week to count as a boom week
+var BoomPercentile = 0.8
+
+// BustPercentile is the percentile of every score in a league a team's score
+// must be below for its week to count as a bust week
+var BustPercentile = 0.2
+
+//
+// Functions
+//
+
+// addConsistency updates each team with statistics describing how consistent
+// its fantasy scores have been in its matchups through the given week. The
+// statistics only depend on the points each team scored, so they're the same
+// for every scheme. Boom and bust weeks are counted against the percentiles of
+// every score in the league over the same weeks.
+func addConsistency(
+	leaguePowerData []*LeaguePowerData,
+	allMatchups map[int][]goff.Matchup,
+	throughWeek int) {
+
+	history := getMatchupHistory(allMatchups, throughWeek)
+	var allScores []float64
+	for _, scores := range history {
+		allScores = append(allScores, scores...)
+	}
+	sort.Float64s(allScores)
+	boom := percentile(allScores, BoomPercentile)
+	bust := percentile(allScores, BustPercentile)
+
+	for _, powerData := range leaguePowerData {
+		for teamKey, teamData := range powerData.ByTeam {
+			calculateConsistency(teamData, history[teamKey], boom, bust)
+		}
+	}
+}
+
+// calculateConsistency sets the consistency statistics of a team from the
+// points it scored in each matchup it has played
+func calculateConsistency(
+	teamData *TeamPowerData,
+	scores []float64,
+	boom float64,
+	bust float64) {
+
+	if len(scores) == 0 {
+		return
+	}
+	teamData.MeanScore = average(scores)
+	teamData.ScoreStandardDeviation = standardDeviation(scores)
+	if teamData.MeanScore != 0 {
+		teamData.CoefficientOfVariation =
+			teamData.ScoreStandardDeviation / teamData.MeanScore
+	}
+	teamData.Floor = scores[0]
+	teamData.Ceiling = scores[0]
+	for _, score := range scores {
+		if score < teamData.Floor {
+			teamData.Floor = score
+		}
+		if score > teamData.Ceiling {
+			teamData.Ceiling = score
+		}
+		if score > boom {
+			teamData.BoomWeeks++
+		} else if score < bust {
+			teamData.BustWeeks++
+		}
+	}
+}
+
+// percentile returns the value at the given percentile, from 0 to 1, of the
+// sorted values, interpolating between the closest two values
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0.0
+	}
+	if p <= 0 {
+		return sorted[0]
+	}
+	if p >= 1 {
+		return sorted[len(sorted)-1]
+	}
+	position := p * float64(len(sorted)-1)
+	lower := int(position)
+	if lower+1 >= len(sorted) {
+		return sorted[lower]
+	}
+	fraction := position - float64(lower)
+	return sorted[lower] + fraction*(sorted[lower+1]-sorted[lower])
+}
@@ -0,0 +1,154 @@
+package rankings
+
+import (
+	"sort"
+
+	"github.com/Forestmb/goff"
+)
+
+//
+// Interface
+//
+
+// DivisionClient is implemented by a PowerRankingsClient that can get the
+// divisions of a league
+type DivisionClient interface {
+	GetDivisions(leagueKey string) ([]Division, error)
+}
+
+//
+// Data structures
+//
+
+// Division is a group of teams in a league, usually competing for a playoff
+// seed among themselves
+type Division struct {
+	ID       string
+	Name     string
+	TeamKeys []string
+}
+
+// DivisionPowerData contains the power rankings of the teams in a single
+// division
+type DivisionPowerData struct {
+	Division Division
+
+	// Teams in the division ordered by their overall and projected ranks
+	OverallRankings   PowerRankings
+	ProjectedRankings PowerRankings
+
+	// Team in the division with the best projected rank
+	ProjectedWinner *TeamPowerData
+}
+
+//
+// Functions
+//
+
+// addDivisions groups the teams of each league power data into their
+// divisions, ranking each team within its division and counting its 'All-Play'
+// record against only the other teams in its division. Nothing is added when
+// the league has fewer than two divisions.
+func addDivisions(
+	leaguePowerData []*LeaguePowerData,
+	divisions []Division,
+	headToHead *HeadToHead) {
+
+	if len(divisions) < 2 {
+		return
+	}
+
+	divisionByTeamKey := make(map[string]string)
+	for _, division := range divisions {
+		for _, teamKey := range division.TeamKeys {
+			divisionByTeamKey[teamKey] = division.ID
+		}
+	}
+	allPlayRecords := calculateDivisionAllPlayRecords(divisionByTeamKey, headToHead)
+
+	for _, powerData := range leaguePowerData {
+		powerData.Divisions = make([]*DivisionPowerData, len(divisions))
+		for i, division := range divisions {
+			divisionData := &DivisionPowerData{Division: division}
+			for _, teamData := range powerData.OverallRankings {
+				if divisionByTeamKey[teamData.Team.TeamKey] == division.ID {
+					divisionData.OverallRankings = append(
+						divisionData.OverallRankings,
+						teamData)
+				}
+			}
+			divisionData.ProjectedRankings = make(
+				PowerRankings,
+				len(divisionData.OverallRankings))
+			copy(divisionData.ProjectedRankings, divisionData.OverallRankings)
+			sort.SliceStable(divisionData.ProjectedRankings, func(a, b int) bool {
+				return divisionData.ProjectedRankings[a].ProjectedRank <
+					divisionData.ProjectedRankings[b].ProjectedRank
+			})
+
+			for _, teamData := range divisionData.OverallRankings {
+				teamData.DivisionID = division.ID
+				teamData.DivisionRank = divisionRank(
+					divisionData.OverallRankings,
+					teamData,
+					func(t *TeamPowerData) int { return t.Rank })
+				teamData.ProjectedDivisionRank = divisionRank(
+					divisionData.OverallRankings,
+					teamData,
+					func(t *TeamPowerData) int { return t.ProjectedRank })
+				teamData.DivisionAllPlayRecord = allPlayRecords[teamData.Team.TeamKey]
+				if teamData.DivisionAllPlayRecord == nil {
+					teamData.DivisionAllPlayRecord = &goff.Record{}
+				}
+			}
+			if len(divisionData.ProjectedRankings) > 0 {
+				divisionData.ProjectedWinner = divisionData.ProjectedRankings[0]
+			}
+			powerData.Divisions[i] = divisionData
+		}
+	}
+}
+
+// divisionRank returns the rank of a team within its division. Like the
+// overall rankings, teams that share a rank in the league share a rank in the
+// division, which is one more than the number of teams in the division ranked
+// ahead of the team.
+func divisionRank(
+	divisionTeams PowerRankings,
+	teamData *TeamPowerData,
+	rank func(*TeamPowerData) int) int {
+
+	divisionRank := 1
+	for _, other := range divisionTeams {
+		if rank(other) < rank(teamData) {
+			divisionRank++
+		}
+	}
+	return divisionRank
+}
+
+// calculateDivisionAllPlayRecords returns the 'All-Play' record of each team
+// against the other teams in its division by team key
+func calculateDivisionAllPlayRecords(
+	divisionByTeamKey map[string]string,
+	headToHead *HeadToHead) map[string]*goff.Record {
+
+	records := make(map[string]*goff.Record)
+	if headToHead == nil {
+		return records
+	}
+	for i, team := range headToHead.Teams {
+		division, ok := divisionByTeamKey[team.TeamKey]
+		if !ok {
+			continue
+		}
+		record := &goff.Record{}
+		for j, opponent := range headToHead.Teams {
+			if i != j && divisionByTeamKey[opponent.TeamKey] == division {
+				addRecord(record, headToHead.AllPlayRecords[i][j])
+			}
+		}
+		records[team.TeamKey] = record
+	}
+	return records
+}
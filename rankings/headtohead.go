@@ -0,0 +1,134 @@
+package rankings
+
+import (
+	"github.com/Forestmb/goff"
+)
+
+//
+// Data structures
+//
+
+// HeadToHead describes how every team in a league has done against every
+// other team, both in the matchups they actually played and when comparing
+// their fantasy scores each week.
+type HeadToHead struct {
+	// Teams in the order of both the rows and the columns of the records
+	Teams []*goff.Team
+
+	// Records[i][j] is the record of Teams[i] in its matchups against
+	// Teams[j]
+	Records [][]*goff.Record
+
+	// AllPlayRecords[i][j] is the record of Teams[i] against Teams[j] using
+	// the fantasy scores of both teams each week, whether or not they played
+	// each other
+	AllPlayRecords [][]*goff.Record
+
+	// Weeks is the number of weeks used to calculate the records
+	Weeks int
+}
+
+//
+// Functions
+//
+
+// CalculateHeadToHead compares every pair of teams for each week up to the
+// given week. Actual records come from the matchups, and all-play records
+// from the fantasy scores of each team in the weekly rankings.
+func CalculateHeadToHead(
+	standings []goff.Team,
+	allMatchups map[int][]goff.Matchup,
+	byWeek []*WeeklyRanking,
+	throughWeek int) *HeadToHead {
+
+	teams := getMatchupTeams(
+		&goff.League{Standings: standings},
+		allMatchups,
+		throughWeek)
+	indexByTeamKey := make(map[string]int)
+	headToHead := &HeadToHead{
+		Teams:          make([]*goff.Team, len(teams)),
+		Records:        make([][]*goff.Record, len(teams)),
+		AllPlayRecords: make([][]*goff.Record, len(teams)),
+		Weeks:          throughWeek,
+	}
+	for i := range teams {
+		headToHead.Teams[i] = &teams[i]
+		headToHead.Records[i] = make([]*goff.Record, len(teams))
+		headToHead.AllPlayRecords[i] = make([]*goff.Record, len(teams))
+		for j := range teams {
+			headToHead.Records[i][j] = &goff.Record{}
+			headToHead.AllPlayRecords[i][j] = &goff.Record{}
+		}
+		indexByTeamKey[teams[i].TeamKey] = i
+	}
+
+	for week := 1; week <= throughWeek; week++ {
+		schedule := getWeeklySchedule(allMatchups[week])
+		for teamKey, opponent := range schedule.Opponents {
+			i, iOK := indexByTeamKey[teamKey]
+			j, jOK := indexByTeamKey[opponent]
+			if !iOK || !jOK {
+				continue
+			}
+			addResult(
+				headToHead.Records[i][j],
+				schedule.Scores[teamKey],
+				schedule.Scores[opponent])
+		}
+
+		if week > len(byWeek) || byWeek[week-1] == nil || byWeek[week-1].Projected {
+			continue
+		}
+		scores := byWeek[week-1].Rankings
+		for _, teamScore := range scores {
+			i, ok := indexByTeamKey[teamScore.Team.TeamKey]
+			if !ok {
+				continue
+			}
+			for _, otherScore := range scores {
+				j, ok := indexByTeamKey[otherScore.Team.TeamKey]
+				if !ok || i == j {
+					continue
+				}
+				addResult(
+					headToHead.AllPlayRecords[i][j],
+					teamScore.FantasyScore,
+					otherScore.FantasyScore)
+			}
+		}
+	}
+	return headToHead
+}
+
+// addHeadToHead updates each league power data with the head-to-head records
+// of every pair of teams through the current week, using the fantasy scores
+// of the 'All-Play' scheme
+func addHeadToHead(
+	leaguePowerData []*LeaguePowerData,
+	standings []goff.Team,
+	allMatchups map[int][]goff.Matchup,
+	currentWeek int) {
+
+	var byWeek []*WeeklyRanking
+	for _, powerData := range leaguePowerData {
+		if powerData.RankingScheme.ID() == (allPlayRecord{}).ID() {
+			byWeek = powerData.ByWeek
+		}
+	}
+	headToHead := CalculateHeadToHead(standings, allMatchups, byWeek, currentWeek)
+	for _, powerData := range leaguePowerData {
+		powerData.HeadToHead = headToHead
+	}
+}
+
+// addResult adds a win, loss or tie to a record by comparing two scores
+func addResult(record *goff.Record, score float64, otherScore float64) {
+	if score > otherScore {
+		record.Wins++
+	} else if score == otherScore {
+		record.Ties++
+	} else {
+		record.Losses++
+	}
+}
@@ -0,0 +1,289 @@
+package rankings
+
+import (
+	"math"
+	"strings"
+
+	"github.com/Forestmb/goff"
+	"github.com/golang/glog"
+)
+
+//
+// Configuration variables
+//
+
+// OptimalLineups enables the 'Optimal Lineup' scheme. It requires the roster
+// of every team for every week, which results in many more calls to the
+// fantasy sports provider.
+var OptimalLineups = false
+
+// nonStartingPositions are roster positions whose players do not score points
+// for their team
+var nonStartingPositions = map[string]bool{
+	"BN":  true,
+	"IR":  true,
+	"IR+": true,
+	"NA":  true,
+}
+
+// flexPositions are roster positions that can be filled by players from
+// more than one position, when not listed in a player's eligible positions
+var flexPositions = map[string][]string{
+	"W/R/T":   []string{"WR", "RB", "TE"},
+	"W/R":     []string{"WR", "RB"},
+	"W/T":     []string{"WR", "TE"},
+	"R/T":     []string{"RB", "TE"},
+	"Q/W/R/T": []string{"QB", "WR", "RB", "TE"},
+	"G":       []string{"PG", "SG"},
+	"F":       []string{"SF", "PF"},
+	"P":       []string{"SP", "RP"},
+}
+
+// utilityPosition is the roster position that can be filled by players from
+// any position except those in nonUtilityPositions
+const utilityPosition = "Util"
+
+// nonUtilityPositions are positions whose players can't be started at
+// utilityPosition, since baseball's utility spot is only for hitters
+var nonUtilityPositions = map[string]bool{
+	"SP": true,
+	"RP": true,
+	"P":  true,
+}
+
+//
+// Data structures
+//
+
+// TeamLineup describes the points a team scored with the lineup it started
+// and the most points it could have scored with the players on its roster
+type TeamLineup struct {
+	ActualPoints  float64
+	OptimalPoints float64
+}
+
+//
+// Functions
+//
+
+// GetRosterRanking ranks teams for a given week using the players on their
+// rosters. Rosters are only retrieved for weeks that have been played.
+// Projections use the projected points of the teams in each matchup.
+func GetRosterRanking(
+	client RosterClient,
+	leagueKey string,
+	week int,
+	teams []goff.Team,
+	matchups []goff.Matchup,
+	projected bool,
+	results chan *WeeklyRanking,
+	errors chan error,
+	schemes []RosterScheme) {
+
+	teamsForWeek := make([]goff.Team, len(teams))
+	copy(teamsForWeek, teams)
+	matchupTeams := make(map[string]goff.Team)
+	for _, matchup := range matchups {
+		for _, team := range matchup.Teams {
+			matchupTeams[team.TeamKey] = team
+		}
+	}
+	for index := range teamsForWeek {
+		team := &teamsForWeek[index]
+		matchupTeam := matchupTeams[team.TeamKey]
+		team.TeamPoints = matchupTeam.TeamPoints
+		team.TeamProjectedPoints = matchupTeam.TeamProjectedPoints
+	}
+
+	var rosters map[string][]goff.Player
+	if !projected {
+		rosters = make(map[string][]goff.Player)
+		for _, team := range teamsForWeek {
+			roster, err := client.GetTeamRosterStats(leagueKey, team.TeamKey, week)
+			if err != nil {
+				glog.Warningf("couldn't retrieve roster for week %d: %s", week, err.Error())
+				errors <- err
+				return
+			}
+			rosters[team.TeamKey] = roster
+		}
+	}
+
+	for _, scheme := range schemes {
+		teamsForScheme := make([]goff.Team, len(teamsForWeek))
+		copy(teamsForScheme, teamsForWeek)
+		results <- scheme.CalculateRosterRankings(week, teamsForScheme, rosters, projected)
+	}
+}
+
+// GetLineups returns the actual and optimal points of each team's lineup.
+//
+// The roster positions of a league aren't available from the client, so they
+// are inferred each week as the most players any team in the league started
+// at each position. Positions every team left empty that week are missed, so
+// optimal points are an approximation.
+func GetLineups(rosters map[string][]goff.Player) map[string]*TeamLineup {
+	positionCounts := make(map[string]int)
+	for _, roster := range rosters {
+		teamCounts := make(map[string]int)
+		for _, player := range roster {
+			position := player.SelectedPosition.Position
+			if position != "" && !nonStartingPositions[position] {
+				teamCounts[position]++
+			}
+		}
+		for position, count := range teamCounts {
+			if count > positionCounts[position] {
+				positionCounts[position] = count
+			}
+		}
+	}
+
+	var slots []string
+	for position, count := range positionCounts {
+		for i := 0; i < count; i++ {
+			slots = append(slots, position)
+		}
+	}
+
+	lineups := make(map[string]*TeamLineup)
+	for teamKey, roster := range rosters {
+		lineup := &TeamLineup{}
+		for _, player := range roster {
+			position := player.SelectedPosition.Position
+			if position != "" && !nonStartingPositions[position] {
+				lineup.ActualPoints += player.PlayerPoints.Total
+			}
+		}
+		lineup.OptimalPoints = optimalLineupPoints(slots, roster)
+		lineups[teamKey] = lineup
+	}
+	return lineups
+}
+
+// optimalLineupPoints returns the most points the given players could score
+// when each roster position is filled by at most one eligible player
+func optimalLineupPoints(slots []string, players []goff.Player) float64 {
+	if len(slots) == 0 {
+		return 0.0
+	}
+
+	// Every slot can be left empty, so add a player worth no points for each
+	// slot that is eligible everywhere
+	columns := len(players) + len(slots)
+	ineligible := math.Inf(1)
+	cost := make([][]float64, len(slots))
+	for i, slot := range slots {
+		cost[i] = make([]float64, columns)
+		for j, player := range players {
+			if isEligible(player, slot) {
+				cost[i][j] = -player.PlayerPoints.Total
+			} else {
+				cost[i][j] = ineligible
+			}
+		}
+	}
+
+	points := 0.0
+	for i, j := range minimumCostAssignment(cost) {
+		if j < len(players) {
+			points -= cost[i][j]
+		}
+	}
+	return points
+}
+
+// isEligible returns whether a player can be started at a roster position
+func isEligible(player goff.Player, slot string) bool {
+	if player.SelectedPosition.Position == slot {
+		return true
+	}
+	positions := player.ElligiblePositions
+	if len(positions) == 0 {
+		positions = strings.Split(player.DisplayPosition, ",")
+	}
+	for _, position := range positions {
+		position = strings.TrimSpace(position)
+		if position == slot ||
+			(slot == utilityPosition && !nonUtilityPositions[position]) {
+			return true
+		}
+		for _, flexPosition := range flexPositions[slot] {
+			if position == flexPosition {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// minimumCostAssignment assigns each row of a cost matrix to a different
+// column so that the total cost is as small as possible, using the Hungarian
+// algorithm. The matrix must have at least as many columns as rows. The
+// column assigned to each row is returned.
+func minimumCostAssignment(cost [][]float64) []int {
+	rows := len(cost)
+	columns := len(cost[0])
+
+	// Potentials and matches use 1-based indexes, with 0 as a placeholder
+	rowPotential := make([]float64, rows+1)
+	columnPotential := make([]float64, columns+1)
+	columnMatch := make([]int, columns+1)
+	way := make([]int, columns+1)
+
+	for row := 1; row <= rows; row++ {
+		columnMatch[0] = row
+		column := 0
+		minimum := make([]float64, columns+1)
+		used := make([]bool, columns+1)
+		for i := range minimum {
+			minimum[i] = math.Inf(1)
+		}
+
+		for columnMatch[column] != 0 {
+			used[column] = true
+			matchedRow := columnMatch[column]
+			delta := math.Inf(1)
+			next := 0
+			for j := 1; j <= columns; j++ {
+				if used[j] {
+					continue
+				}
+				current := cost[matchedRow-1][j-1] -
+					rowPotential[matchedRow] -
+					columnPotential[j]
+				if current < minimum[j] {
+					minimum[j] = current
+					way[j] = column
+				}
+				if minimum[j] < delta {
+					delta = minimum[j]
+					next = j
+				}
+			}
+			for j := 0; j <= columns; j++ {
+				if used[j] {
+					rowPotential[columnMatch[j]] += delta
+					columnPotential[j] -= delta
+				} else {
+					minimum[j] -= delta
+				}
+			}
+			column = next
+		}
+
+		for column != 0 {
+			previous := way[column]
+			columnMatch[column] = columnMatch[previous]
+			column = previous
+		}
+	}
+
+	assignment := make([]int, rows)
+	for j := 1; j <= columns; j++ {
+		if columnMatch[j] != 0 {
+			assignment[columnMatch[j]-1] = j - 1
+		}
+	}
+	return assignment
+}
@@ -0,0 +1,104 @@
+package rankings
+
+import (
+	"github.com/Forestmb/goff"
+)
+
+//
+// Data structures
+//
+
+// liveClient ranks the week being played using the points scored so far,
+// blended with the projected points for the rest of the week. Every other
+// week is returned unchanged by the wrapped client.
+type liveClient struct {
+	client   PowerRankingsClient
+	liveWeek int
+
+	// Fraction of the week still to be played
+	remaining float64
+}
+
+//
+// Functions
+//
+
+// liveScore returns the points a team is expected to finish a week in progress
+// with: the points it has already scored, plus its projected points for the
+// week weighted by the fraction of the week still to be played.
+func liveScore(team *goff.Team, remaining float64) float64 {
+	if remaining < 0 {
+		remaining = 0
+	} else if remaining > 1 {
+		remaining = 1
+	}
+	return team.TeamPoints.Total + remaining*team.TeamProjectedPoints.Total
+}
+
+// setLiveScores replaces the points of each team with its live score
+func setLiveScores(teams []goff.Team, remaining float64) {
+	for index := range teams {
+		teams[index].TeamPoints.Total = liveScore(&teams[index], remaining)
+	}
+}
+
+// markLive flags a weekly ranking and the scores of its teams as coming from
+// the week being played
+func markLive(weeklyRanking *WeeklyRanking) {
+	weeklyRanking.Live = true
+	for _, teamScore := range weeklyRanking.Rankings {
+		teamScore.Live = true
+	}
+}
+
+func (l *liveClient) GetAllTeamStats(
+	leagueKey string,
+	week int,
+	projected bool) ([]goff.Team, error) {
+
+	if week != l.liveWeek {
+		return l.client.GetAllTeamStats(leagueKey, week, projected)
+	}
+	providerTeams, err := l.client.GetAllTeamStats(leagueKey, week, false)
+	if err != nil {
+		return nil, err
+	}
+
+	// The teams returned by the wrapped client may be cached and shared, so
+	// only a copy is changed
+	teams := make([]goff.Team, len(providerTeams))
+	copy(teams, providerTeams)
+	setLiveScores(teams, l.remaining)
+	return teams, nil
+}
+
+func (l *liveClient) GetMatchupsForWeekRange(
+	leagueKey string,
+	startWeek int,
+	endWeek int) (map[int][]goff.Matchup, error) {
+
+	providerMatchups, err := l.client.GetMatchupsForWeekRange(leagueKey, startWeek, endWeek)
+	if err != nil {
+		return nil, err
+	}
+
+	allMatchups := make(map[int][]goff.Matchup)
+	for week, matchups := range providerMatchups {
+		if week != l.liveWeek {
+			allMatchups[week] = matchups
+			continue
+		}
+		for _, matchup := range matchups {
+			teams := make([]goff.Team, len(matchup.Teams))
+			copy(teams, matchup.Teams)
+			setLiveScores(teams, l.remaining)
+			matchup.Teams = teams
+			allMatchups[week] = append(allMatchups[week], matchup)
+		}
+	}
+	return allMatchups, nil
+}
+
+func (l *liveClient) GetLeagueStandings(leagueKey string) (*goff.League, error) {
+	return l.client.GetLeagueStandings(leagueKey)
+}
@@ -0,0 +1,415 @@
+package rankings
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+
+	"github.com/Forestmb/goff"
+	"github.com/golang/glog"
+)
+
+//
+// Configuration variables
+//
+
+// DefaultScoreDeviation is the standard deviation of a team's weekly score, as
+// a fraction of its average score, used when there aren't enough weeks played
+// in a league to measure how much teams' scores vary
+var DefaultScoreDeviation = 0.15
+
+// PlayoffSimulations is the number of times the rest of a league's regular
+// season is simulated to find each team's playoff odds
+var PlayoffSimulations = 10000
+
+//
+// Data structures
+//
+
+// PlayoffOdds describes how likely each team in a league is to finish the
+// regular season with each playoff seed
+type PlayoffOdds struct {
+	Simulations  int
+	PlayoffTeams int
+	ByeTeams     int
+
+	// Teams ordered by their odds of making the playoffs, then by their
+	// average seed
+	Teams  []*TeamPlayoffOdds
+	ByTeam map[string]*TeamPlayoffOdds
+}
+
+// TeamPlayoffOdds describes the playoff chances of a single team. SeedOdds[i]
+// is the probability of the team finishing the regular season as seed i+1.
+type TeamPlayoffOdds struct {
+	Team        *goff.Team
+	SeedOdds    []float64
+	PlayoffOdds float64
+	ByeOdds     float64
+	AverageWins float64
+	AverageSeed float64
+}
+
+// PlayoffSimulator plays out the remainder of a league's regular season
+// many times to estimate each team's playoff odds. Results are reproducible
+// for simulators created with the same seed.
+type PlayoffSimulator struct {
+	Simulations  int
+	PlayoffTeams int
+	ByeTeams     int
+	rand         *rand.Rand
+}
+
+// scoreDistribution describes the weekly scores of a single team
+type scoreDistribution struct {
+	Scores    []float64
+	Deviation float64
+}
+
+// simulatedStanding is the record and points scored of a single team in one
+// simulation of a season
+type simulatedStanding struct {
+	Index     int
+	Wins      float64
+	PointsFor float64
+}
+
+// simulatedStandings allows the teams in a simulation to be sorted by their
+// records, using total points scored as a tie-breaker
+type simulatedStandings []*simulatedStanding
+
+func (s simulatedStandings) Len() int {
+	return len(s)
+}
+
+func (s simulatedStandings) Less(i, j int) bool {
+	if s[i].Wins == s[j].Wins {
+		if s[i].PointsFor == s[j].PointsFor {
+			return s[i].Index < s[j].Index
+		}
+		return s[i].PointsFor > s[j].PointsFor
+	}
+	return s[i].Wins > s[j].Wins
+}
+
+func (s simulatedStandings) Swap(i, j int) {
+	s[i], s[j] = s[j], s[i]
+}
+
+// PlayoffOddsSorter orders teams by their odds of making the playoffs
+type PlayoffOddsSorter []*TeamPlayoffOdds
+
+func (p PlayoffOddsSorter) Len() int {
+	return len(p)
+}
+
+func (p PlayoffOddsSorter) Less(i, j int) bool {
+	if p[i].PlayoffOdds == p[j].PlayoffOdds {
+		return p[i].AverageSeed < p[j].AverageSeed
+	}
+	return p[i].PlayoffOdds > p[j].PlayoffOdds
+}
+
+func (p PlayoffOddsSorter) Swap(i, j int) {
+	p[i], p[j] = p[j], p[i]
+}
+
+//
+// Functions
+//
+
+// NewPlayoffSimulator creates a simulator that runs the given number of
+// simulations, seeding its random number generator with the given seed
+func NewPlayoffSimulator(
+	seed int64,
+	simulations int,
+	playoffTeams int,
+	byeTeams int) *PlayoffSimulator {
+
+	return &PlayoffSimulator{
+		Simulations:  simulations,
+		PlayoffTeams: playoffTeams,
+		ByeTeams:     byeTeams,
+		rand:         rand.New(rand.NewSource(seed)),
+	}
+}
+
+// PlayoffByeTeams returns how many of the given number of playoff teams get a
+// bye in the first round, filling out a bracket with a power of two teams
+func PlayoffByeTeams(playoffTeams int) int {
+	if playoffTeams <= 0 {
+		return 0
+	}
+	bracket := 1
+	for bracket < playoffTeams {
+		bracket *= 2
+	}
+	return bracket - playoffTeams
+}
+
+// GetPlayoffOdds returns the playoff odds of each team in a league, with
+// results through the given week and the rest of the regular season simulated.
+// No odds are returned if no games have been played and there are no
+// projections to simulate the rest of the season with.
+func GetPlayoffOdds(
+	client PowerRankingsClient,
+	l *goff.League,
+	currentWeek int,
+	simulator *PlayoffSimulator) (*PlayoffOdds, error) {
+
+	calendar, err := FindSeasonCalendar(client, l)
+	if err != nil {
+		return nil, err
+	}
+	client = calendar.wrapClient(client)
+	leagueKey := l.LeagueKey
+	league, err := client.GetLeagueStandings(leagueKey)
+	if err != nil {
+		return nil, err
+	}
+
+	var allMatchups map[int][]goff.Matchup
+	lastWeek := regularSeasonEndWeek(calendar.Periods(), league)
+	if lastWeek > 0 {
+		glog.V(2).Infof("getting weekly matchups -- weekStart=%d, weekEnd=%d",
+			1,
+			lastWeek)
+		allMatchups, err = client.GetMatchupsForWeekRange(leagueKey, 1, lastWeek)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return simulator.Simulate(league, allMatchups, currentWeek, lastWeek), nil
+}
+
+// Simulate plays out the regular season from the week after the current week
+// through the last week. Each team's weekly score is drawn from a normal
+// distribution centered on its average score, blended with its projected
+// score for that week when one is available.
+//
+// Without any scores or projections every simulated game would be a tie, so
+// nil is returned instead.
+func (s *PlayoffSimulator) Simulate(
+	league *goff.League,
+	allMatchups map[int][]goff.Matchup,
+	currentWeek int,
+	lastWeek int) *PlayoffOdds {
+
+	teams := getMatchupTeams(league, allMatchups, lastWeek)
+	indexByTeamKey := make(map[string]int)
+	for i, team := range teams {
+		indexByTeamKey[team.TeamKey] = i
+	}
+
+	if currentWeek > lastWeek {
+		currentWeek = lastWeek
+	}
+
+	// Results that have already happened are the same for every simulation
+	actual := make([]simulatedStanding, len(teams))
+	distributions := make([]*scoreDistribution, len(teams))
+	for i := range teams {
+		actual[i].Index = i
+		distributions[i] = &scoreDistribution{}
+	}
+	var allScores []float64
+	for week := 1; week <= currentWeek; week++ {
+		for _, matchup := range allMatchups[week] {
+			if len(matchup.Teams) != 2 {
+				continue
+			}
+			first := indexByTeamKey[matchup.Teams[0].TeamKey]
+			second := indexByTeamKey[matchup.Teams[1].TeamKey]
+			firstScore := matchup.Teams[0].TeamPoints.Total
+			secondScore := matchup.Teams[1].TeamPoints.Total
+			addSimulatedResult(&actual[first], &actual[second], firstScore, secondScore)
+
+			distributions[first].Scores = append(distributions[first].Scores, firstScore)
+			distributions[second].Scores = append(distributions[second].Scores, secondScore)
+			allScores = append(allScores, firstScore, secondScore)
+		}
+	}
+
+	if len(allScores) == 0 && !hasProjections(allMatchups, currentWeek, lastWeek) {
+		glog.V(2).Infoln("no scores or projections, not simulating playoffs")
+		return nil
+	}
+
+	// Teams that have not played at least two weeks use the deviation of
+	// every score in the league
+	leagueDeviation := standardDeviation(allScores)
+	for _, distribution := range distributions {
+		if len(distribution.Scores) >= 2 {
+			distribution.Deviation = standardDeviation(distribution.Scores)
+		} else {
+			distribution.Deviation = leagueDeviation
+		}
+	}
+
+	odds := &PlayoffOdds{
+		Simulations:  s.Simulations,
+		PlayoffTeams: s.PlayoffTeams,
+		ByeTeams:     s.ByeTeams,
+		Teams:        make([]*TeamPlayoffOdds, len(teams)),
+		ByTeam:       make(map[string]*TeamPlayoffOdds),
+	}
+	for i := range teams {
+		odds.Teams[i] = &TeamPlayoffOdds{
+			Team:     &teams[i],
+			SeedOdds: make([]float64, len(teams)),
+		}
+		odds.ByTeam[teams[i].TeamKey] = odds.Teams[i]
+	}
+	if s.Simulations <= 0 || len(teams) == 0 {
+		return odds
+	}
+
+	leagueAverage := average(allScores)
+	standings := make(simulatedStandings, len(teams))
+	for simulation := 0; simulation < s.Simulations; simulation++ {
+		for i := range actual {
+			standing := actual[i]
+			standings[i] = &standing
+		}
+
+		for week := currentWeek + 1; week <= lastWeek; week++ {
+			for _, matchup := range allMatchups[week] {
+				if len(matchup.Teams) != 2 {
+					continue
+				}
+				first := indexByTeamKey[matchup.Teams[0].TeamKey]
+				second := indexByTeamKey[matchup.Teams[1].TeamKey]
+				addSimulatedResult(
+					standings[first],
+					standings[second],
+					s.simulateScore(
+						distributions[first],
+						matchup.Teams[0].TeamProjectedPoints.Total,
+						leagueAverage),
+					s.simulateScore(
+						distributions[second],
+						matchup.Teams[1].TeamProjectedPoints.Total,
+						leagueAverage))
+			}
+		}
+
+		sort.Sort(standings)
+		for i, standing := range standings {
+			teamOdds := odds.Teams[standing.Index]
+			teamOdds.SeedOdds[i]++
+			teamOdds.AverageWins += standing.Wins
+			teamOdds.AverageSeed += float64(i + 1)
+			if i < s.PlayoffTeams {
+				teamOdds.PlayoffOdds++
+			}
+			if i < s.ByeTeams {
+				teamOdds.ByeOdds++
+			}
+		}
+	}
+
+	simulations := float64(s.Simulations)
+	for _, teamOdds := range odds.Teams {
+		for i := range teamOdds.SeedOdds {
+			teamOdds.SeedOdds[i] /= simulations
+		}
+		teamOdds.PlayoffOdds /= simulations
+		teamOdds.ByeOdds /= simulations
+		teamOdds.AverageWins /= simulations
+		teamOdds.AverageSeed /= simulations
+	}
+	sort.Stable(PlayoffOddsSorter(odds.Teams))
+
+	return odds
+}
+
+// simulateScore draws a random weekly score for a team
+func (s *PlayoffSimulator) simulateScore(
+	distribution *scoreDistribution,
+	projected float64,
+	leagueAverage float64) float64 {
+
+	weeks := float64(len(distribution.Scores))
+	mean := leagueAverage
+	if projected > 0 {
+		mean = (average(distribution.Scores)*weeks + projected) / (weeks + 1)
+	} else if weeks > 0 {
+		mean = average(distribution.Scores)
+	}
+
+	deviation := distribution.Deviation
+	if deviation == 0 {
+		deviation = mean * DefaultScoreDeviation
+	}
+	return mean + s.rand.NormFloat64()*deviation
+}
+
+// hasProjections returns whether any team has projected points in the weeks
+// after the current week through the last week
+func hasProjections(allMatchups map[int][]goff.Matchup, currentWeek int, lastWeek int) bool {
+	for week := currentWeek + 1; week <= lastWeek; week++ {
+		for _, matchup := range allMatchups[week] {
+			for _, team := range matchup.Teams {
+				if team.TeamProjectedPoints.Total > 0 {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// addSimulatedResult updates the standings of two teams that played each
+// other. Ties count as half a win.
+func addSimulatedResult(
+	first *simulatedStanding,
+	second *simulatedStanding,
+	firstScore float64,
+	secondScore float64) {
+
+	first.PointsFor += firstScore
+	second.PointsFor += secondScore
+	if firstScore > secondScore {
+		first.Wins++
+	} else if firstScore < secondScore {
+		second.Wins++
+	} else {
+		first.Wins += 0.5
+		second.Wins += 0.5
+	}
+}
+
+// regularSeasonEndWeek returns the last week of a league's regular season
+func regularSeasonEndWeek(endWeek int, l *goff.League) int {
+	if l.Settings.UsesPlayoff && l.Settings.PlayoffStartWeek > 0 {
+		return l.Settings.PlayoffStartWeek - 1
+	}
+	return endWeek
+}
+
+// average returns the mean of the given values
+func average(values []float64) float64 {
+	if len(values) == 0 {
+		return 0.0
+	}
+	total := 0.0
+	for _, value := range values {
+		total += value
+	}
+	return total / float64(len(values))
+}
+
+// standardDeviation returns the population standard deviation of the given
+// values
+func standardDeviation(values []float64) float64 {
+	if len(values) == 0 {
+		return 0.0
+	}
+	mean := average(values)
+	variance := 0.0
+	for _, value := range values {
+		variance += (value - mean) * (value - mean)
+	}
+	return math.Sqrt(variance / float64(len(values)))
+}
@@ -0,0 +1,336 @@
+package rankings
+
+import (
+	"github.com/Forestmb/goff"
+	"github.com/golang/glog"
+)
+
+//
+// Configuration variables
+//
+
+// ProjectionTrailingWeeks is the number of most recent weeks averaged by the
+// 'Trailing Mean' projector
+var ProjectionTrailingWeeks = 3
+
+// ProjectionRegressionWeeks is how many weeks of the league's mean score are
+// blended into each team's mean score by the 'Regressed Mean' projector
+var ProjectionRegressionWeeks = 3.0
+
+//
+// Interface
+//
+
+// A Projector estimates how many points each team will score in the weeks
+// after the current week, in place of the projections of the fantasy sports
+// provider
+type Projector interface {
+	ID() string
+	DisplayName() string
+
+	// Project returns the points each team is projected to score in each
+	// remaining week by team key, using the points scored in completed weeks
+	Project(history ScoreHistory) map[string]float64
+}
+
+//
+// Data structures
+//
+
+// ScoreHistory maps the key of each team to the points it scored in each
+// completed week it played, in order
+type ScoreHistory map[string][]float64
+
+// ProjectorComparison contains the points each team is projected to score in
+// each remaining week by a projector
+type ProjectorComparison struct {
+	Projector   Projector
+	Projections map[string]float64
+}
+
+// projectorClient replaces the projections of the wrapped client with the
+// projections of a Projector. Weeks through the last completed week are
+// returned unchanged.
+type projectorClient struct {
+	client        PowerRankingsClient
+	lastWeek      int
+	teams         []goff.Team
+	projections   map[string]float64
+	fallback      float64
+	projectorName string
+}
+
+//
+// Functions
+//
+
+// GetProjectors returns the supported projectors
+func GetProjectors() []Projector {
+	return []Projector{
+		seasonMean{},
+		trailingMean{weeks: ProjectionTrailingWeeks},
+		regressedMean{weeks: ProjectionRegressionWeeks},
+	}
+}
+
+// GetScoreHistory returns the points each team in a league scored through the
+// given week, using the matchups of each week
+func GetScoreHistory(
+	client PowerRankingsClient,
+	leagueKey string,
+	throughWeek int) (ScoreHistory, error) {
+
+	history := make(ScoreHistory)
+	if throughWeek < 1 {
+		return history, nil
+	}
+	allMatchups, err := client.GetMatchupsForWeekRange(leagueKey, 1, throughWeek)
+	if err != nil {
+		return nil, err
+	}
+	return getMatchupHistory(allMatchups, throughWeek), nil
+}
+
+// getMatchupHistory returns the points each team scored in its matchups
+// through the given week. Weeks a team didn't play a matchup are left out.
+func getMatchupHistory(allMatchups map[int][]goff.Matchup, throughWeek int) ScoreHistory {
+	history := make(ScoreHistory)
+	for week := 1; week <= throughWeek; week++ {
+		for _, matchup := range allMatchups[week] {
+			for i := range matchup.Teams {
+				team := &matchup.Teams[i]
+				history[team.TeamKey] = append(
+					history[team.TeamKey],
+					matchupScore(team, false))
+			}
+		}
+	}
+	return history
+}
+
+// CompareProjectors returns the projections of each projector for a league's
+// remaining weeks
+func CompareProjectors(
+	client PowerRankingsClient,
+	l *goff.League,
+	currentWeek int,
+	projectors []Projector) ([]*ProjectorComparison, error) {
+
+	calendar, err := FindSeasonCalendar(client, l)
+	if err != nil {
+		return nil, err
+	}
+	client = calendar.wrapClient(client)
+	history, err := GetScoreHistory(client, l.LeagueKey, currentWeek)
+	if err != nil {
+		return nil, err
+	}
+
+	comparisons := make([]*ProjectorComparison, len(projectors))
+	for i, projector := range projectors {
+		comparisons[i] = &ProjectorComparison{
+			Projector:   projector,
+			Projections: projector.Project(history),
+		}
+	}
+	return comparisons, nil
+}
+
+// newProjectorClient creates a client that projects the weeks after the last
+// week using a projector. Teams in the given standings are used for projected
+// weeks when there are any, so the provider's projections aren't needed. Teams
+// the projector has no projection for are projected to score the league's
+// mean.
+func newProjectorClient(
+	client PowerRankingsClient,
+	projector Projector,
+	history ScoreHistory,
+	standings []goff.Team,
+	lastWeek int) *projectorClient {
+
+	return &projectorClient{
+		client:        client,
+		lastWeek:      lastWeek,
+		teams:         standings,
+		projections:   projector.Project(history),
+		fallback:      leagueMean(history),
+		projectorName: projector.DisplayName(),
+	}
+}
+
+// setProjections replaces the projected points of each team
+func (p *projectorClient) setProjections(teams []goff.Team) {
+	for index := range teams {
+		projection, ok := p.projections[teams[index].TeamKey]
+		if !ok {
+			projection = p.fallback
+		}
+		teams[index].TeamProjectedPoints = goff.Points{
+			CoverageType: teams[index].TeamProjectedPoints.CoverageType,
+			Total:        projection,
+		}
+	}
+}
+
+func (p *projectorClient) GetAllTeamStats(
+	leagueKey string,
+	week int,
+	projected bool) ([]goff.Team, error) {
+
+	if !projected || week <= p.lastWeek {
+		return p.client.GetAllTeamStats(leagueKey, week, projected)
+	}
+
+	var teams []goff.Team
+	if len(p.teams) > 0 {
+		teams = make([]goff.Team, len(p.teams))
+		copy(teams, p.teams)
+		for index := range teams {
+			teams[index].TeamPoints = goff.Points{}
+		}
+	} else {
+		var err error
+		teams, err = p.client.GetAllTeamStats(leagueKey, week, projected)
+		if err != nil {
+			return nil, err
+		}
+	}
+	glog.V(4).Infof("projecting week -- week=%d, projector=%s",
+		week,
+		p.projectorName)
+	p.setProjections(teams)
+	return teams, nil
+}
+
+func (p *projectorClient) GetMatchupsForWeekRange(
+	leagueKey string,
+	startWeek int,
+	endWeek int) (map[int][]goff.Matchup, error) {
+
+	providerMatchups, err := p.client.GetMatchupsForWeekRange(leagueKey, startWeek, endWeek)
+	if err != nil {
+		return nil, err
+	}
+
+	allMatchups := make(map[int][]goff.Matchup)
+	for week, matchups := range providerMatchups {
+		if week <= p.lastWeek {
+			allMatchups[week] = matchups
+			continue
+		}
+		for _, matchup := range matchups {
+			teams := make([]goff.Team, len(matchup.Teams))
+			copy(teams, matchup.Teams)
+			p.setProjections(teams)
+			matchup.Teams = teams
+			allMatchups[week] = append(allMatchups[week], matchup)
+		}
+	}
+	return allMatchups, nil
+}
+
+func (p *projectorClient) GetLeagueStandings(leagueKey string) (*goff.League, error) {
+	return p.client.GetLeagueStandings(leagueKey)
+}
+
+// mean returns the mean of the given scores, or the fallback if there are none
+func mean(scores []float64, fallback float64) float64 {
+	if len(scores) == 0 {
+		return fallback
+	}
+	return average(scores)
+}
+
+// leagueMean returns the mean of every score in the history
+func leagueMean(history ScoreHistory) float64 {
+	var allScores []float64
+	for _, scores := range history {
+		allScores = append(allScores, scores...)
+	}
+	return mean(allScores, 0.0)
+}
+
+// 'Season Mean' projector
+type seasonMean struct {
+}
+
+func (s seasonMean) ID() string {
+	return "season-mean"
+}
+
+func (s seasonMean) DisplayName() string {
+	return "Season Mean"
+}
+
+// Project for a 'Season Mean' projector projects each team to score its mean
+// score from every completed week. Teams that haven't played are projected to
+// score the league's mean.
+func (s seasonMean) Project(history ScoreHistory) map[string]float64 {
+	fallback := leagueMean(history)
+	projections := make(map[string]float64)
+	for teamKey, scores := range history {
+		projections[teamKey] = mean(scores, fallback)
+	}
+	return projections
+}
+
+// 'Trailing Mean' projector
+type trailingMean struct {
+	weeks int
+}
+
+func (t trailingMean) ID() string {
+	return "trailing-mean"
+}
+
+func (t trailingMean) DisplayName() string {
+	return "Trailing Mean"
+}
+
+// Project for a 'Trailing Mean' projector projects each team to score its mean
+// score from its most recent weeks, so teams that have recently gotten better
+// or worse are projected to stay that way
+func (t trailingMean) Project(history ScoreHistory) map[string]float64 {
+	fallback := leagueMean(history)
+	projections := make(map[string]float64)
+	for teamKey, scores := range history {
+		if t.weeks > 0 && len(scores) > t.weeks {
+			scores = scores[len(scores)-t.weeks:]
+		}
+		projections[teamKey] = mean(scores, fallback)
+	}
+	return projections
+}
+
+// 'Regressed Mean' projector
+type regressedMean struct {
+	weeks float64
+}
+
+func (r regressedMean) ID() string {
+	return "regressed-mean"
+}
+
+func (r regressedMean) DisplayName() string {
+	return "Regressed Mean"
+}
+
+// Project for a 'Regressed Mean' projector pulls each team's mean score toward
+// the league's mean score, as if every team had also played a few weeks
+// scoring exactly the league's mean. Early in the season, when a few big weeks
+// can skew a team's mean, teams are projected closer to the rest of the
+// league.
+func (r regressedMean) Project(history ScoreHistory) map[string]float64 {
+	overall := leagueMean(history)
+	projections := make(map[string]float64)
+	for teamKey, scores := range history {
+		weeks := float64(len(scores))
+		if weeks+r.weeks == 0 {
+			projections[teamKey] = overall
+			continue
+		}
+		total := mean(scores, 0.0) * weeks
+		projections[teamKey] = (total + r.weeks*overall) / (weeks + r.weeks)
+	}
+	return projections
+}
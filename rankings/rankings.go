@@ -3,6 +3,7 @@
 package rankings
 
 import (
+	"context"
 	"fmt"
 	"sort"
 
@@ -10,6 +11,18 @@ import (
 	"github.com/golang/glog"
 )
 
+// SeasonModes are the parts of a season that can be included in the rankings
+var SeasonModes = struct {
+	// REGULAR includes only the weeks before the playoffs start
+	REGULAR string
+
+	// PLAYOFFS includes every week, including playoff and consolation weeks
+	PLAYOFFS string
+}{
+	"regular",
+	"playoffs",
+}
+
 //
 // Configuration variables
 //
@@ -17,6 +30,34 @@ import (
 // MinimizeAPICalls to the fantasy sports provider, whenever possible
 var MinimizeAPICalls = true
 
+// EloKFactor is the maximum number of rating points a team can gain or lose
+// from a single matchup in the 'Elo Rating' scheme
+var EloKFactor = 32.0
+
+// EloMarginOfVictory scales the rating points exchanged in the 'Elo Rating'
+// scheme by how much the winner of a matchup won by
+var EloMarginOfVictory = true
+
+// RecencyDecay is how much each week is worth compared to the week after it
+// in the 'Recency Weighted' scheme
+var RecencyDecay = 0.85
+
+// PythagoreanExponent is the exponent applied to points scored for and against
+// a team in the 'Pythagorean Expectation' scheme
+var PythagoreanExponent = 2.37
+
+// DefaultCompositeWeights are used by the 'Composite' scheme when no other
+// weights are given
+var DefaultCompositeWeights = CompositeWeights{
+	"all-play":               50.0,
+	"total-points":           30.0,
+	CompositeRecordComponent: 20.0,
+}
+
+// RecencyWindow is the number of most recent weeks counted by the 'Recency
+// Weighted' scheme. When set, it is used instead of RecencyDecay.
+var RecencyWindow = 0
+
 //
 // Data structures
 //
@@ -24,10 +65,21 @@ var MinimizeAPICalls = true
 // LeaguePowerData for an entire league over the course of multiple weeks
 type LeaguePowerData struct {
 	RankingScheme     Scheme
+	CurrentWeek       int
 	OverallRankings   []*TeamPowerData
 	ProjectedRankings []*TeamPowerData
 	ByTeam            map[string]*TeamPowerData
 	ByWeek            []*WeeklyRanking
+
+	// Records between every pair of teams in the league, which are the same
+	// for every scheme
+	HeadToHead *HeadToHead
+
+	// Power rankings within each division, if the league has divisions
+	Divisions []*DivisionPowerData
+
+	// Odds of each team making the playoffs, if they were simulated
+	PlayoffOdds *PlayoffOdds
 }
 
 // WeeklyRanking of teams based on their performance for a specific week
@@ -36,6 +88,14 @@ type WeeklyRanking struct {
 	Week      int
 	Rankings  []*TeamScoreData
 	Projected bool
+
+	// Whether the week is still being played, so its results include the
+	// points teams are projected to score for the rest of the week
+	Live bool
+
+	// Standings of each team through the week by team key, only set by a
+	// SeasonScheme
+	Standings map[string]*TeamRankingData
 }
 
 // TeamScoreData describes the score information for a single team
@@ -46,6 +106,7 @@ type TeamScoreData struct {
 	PowerScore   float64
 	Record       *goff.Record
 	Projected    bool
+	Live         bool
 }
 
 // TeamRankingData describes how a team was ranked in comparison to their
@@ -70,6 +131,62 @@ type TeamPowerData struct {
 	AllRankings            []*TeamRankingData
 	AllScores              []*TeamScoreData
 	HasProjections         bool
+	ExpectedWins           float64
+	Luck                   float64
+
+	// Average 'All-Play' win percentage of the opponents a team has played
+	// and has yet to play
+	StrengthOfSchedule          float64
+	RemainingStrengthOfSchedule float64
+
+	// Points scored by a team's starting lineups divided by the most points
+	// its rosters could have scored, when the 'Optimal Lineup' scheme is used
+	ManagerEfficiency float64
+
+	// Rankings of a team among the teams in its division, along with its
+	// 'All-Play' record against only those teams, if the league has divisions
+	DivisionID            string
+	DivisionRank          int
+	ProjectedDivisionRank int
+	DivisionAllPlayRecord *goff.Record
+
+	// Distribution of a team's fantasy scores in the weeks that have been
+	// played. Boom and bust weeks are scores above BoomPercentile and below
+	// BustPercentile of every score in the league.
+	MeanScore              float64
+	ScoreStandardDeviation float64
+	CoefficientOfVariation float64
+	Floor                  float64
+	Ceiling                float64
+	BoomWeeks              int
+	BustWeeks              int
+}
+
+// PowerDataOptions choose how a league's power rankings are calculated
+type PowerDataOptions struct {
+	// Weeks of the season that are ranked, one of SeasonModes. Every week is
+	// ranked by default.
+	SeasonMode string
+
+	// Tie-breakers used for the overall rankings of each scheme
+	TieBreakers TieBreakers
+
+	// Rank the week after the current week, if it's being played, using the
+	// points scored so far and the points still projected to be scored
+	Live bool
+
+	// Fraction of the week being played that is still to come, from 0 to 1,
+	// used to weight each team's projected points for the live week. See
+	// SeasonCalendar.WeekRemaining.
+	LiveRemaining float64
+
+	// Projects the weeks after the current week in place of the projections
+	// of the fantasy sports provider, if set
+	Projector Projector
+
+	// Simulates the rest of the regular season to find each team's playoff
+	// odds, if set
+	PlayoffSimulator *PlayoffSimulator
 }
 
 // schemeRankingWorkbook keeps track of information needed to calculate
@@ -204,6 +321,47 @@ func (p ProjectedPowerRankings) Swap(i, j int) {
 	p[i], p[j] = p[j], p[i]
 }
 
+// StandingsRankings orders teams by the overall ranks assigned by a
+// SeasonScheme
+type StandingsRankings []*TeamPowerData
+
+func (p StandingsRankings) Len() int {
+	return len(p)
+}
+
+func (p StandingsRankings) Less(i, j int) bool {
+	if p[i].Rank == p[j].Rank {
+		if p[i].ProjectedRank == p[j].ProjectedRank {
+			return p[i].Team.Name < p[j].Team.Name
+		}
+		return p[i].ProjectedRank < p[j].ProjectedRank
+	}
+	return p[i].Rank < p[j].Rank
+}
+
+func (p StandingsRankings) Swap(i, j int) {
+	p[i], p[j] = p[j], p[i]
+}
+
+// ProjectedStandingsRankings orders teams by the projected ranks assigned by
+// a SeasonScheme
+type ProjectedStandingsRankings []*TeamPowerData
+
+func (p ProjectedStandingsRankings) Len() int {
+	return len(p)
+}
+
+func (p ProjectedStandingsRankings) Less(i, j int) bool {
+	if p[i].ProjectedRank == p[j].ProjectedRank {
+		return p[i].Team.Name < p[j].Team.Name
+	}
+	return p[i].ProjectedRank < p[j].ProjectedRank
+}
+
+func (p ProjectedStandingsRankings) Swap(i, j int) {
+	p[i], p[j] = p[j], p[i]
+}
+
 //
 // Interface
 //
@@ -216,6 +374,12 @@ type PowerRankingsClient interface {
 	GetMatchupsForWeekRange(leagueKey string, startWeek, endWeek int) (map[int][]goff.Matchup, error)
 }
 
+// RosterClient is implemented by a PowerRankingsClient that can get the
+// players on each team's roster, along with the points each player scored
+type RosterClient interface {
+	GetTeamRosterStats(leagueKey string, teamKey string, week int) ([]goff.Player, error)
+}
+
 //
 // Functions
 //
@@ -240,7 +404,7 @@ func GetWeeklyRanking(
 	for _, scheme := range schemes {
 		teamsForSchemes := make([]goff.Team, len(teams))
 		copy(teamsForSchemes, teams)
-		go scheme.CalculateWeeklyRankings(week, teamsForSchemes, projection, results)
+		scheme.CalculateWeeklyRankings(week, teamsForSchemes, projection, results)
 	}
 }
 
@@ -254,6 +418,9 @@ func GetWeeklyRankingFromMatchups(
 
 	var teams []goff.Team
 	for _, matchup := range matchups {
+		if len(matchup.Teams) != 2 {
+			continue
+		}
 		teams = append(teams, matchup.Teams[0])
 		teams = append(teams, matchup.Teams[1])
 	}
@@ -261,24 +428,191 @@ func GetWeeklyRankingFromMatchups(
 	for _, scheme := range schemes {
 		teamsForSchemes := make([]goff.Team, len(teams))
 		copy(teamsForSchemes, teams)
-		go scheme.CalculateWeeklyRankings(week, teamsForSchemes, false, results)
+		scheme.CalculateWeeklyRankings(week, teamsForSchemes, false, results)
 	}
 }
 
 // GetPowerData returns a league's power rankings up to the given week and
 // projections until the end of the season.
 func GetPowerData(client PowerRankingsClient, l *goff.League, currentWeek int) ([]*LeaguePowerData, error) {
-	endWeek := l.EndWeek
+	return GetPowerDataForSchemes(context.Background(), client, l, currentWeek, GetSchemes())
+}
+
+// GetPowerDataForSchemes returns a league's power rankings for the given
+// schemes up to the given week and projections until the end of the season.
+//
+// Weekly data is requested from the fantasy sports provider concurrently, with
+// at most MaxConcurrentRequests at a time. When the context is cancelled or a
+// request fails, requests that haven't started are skipped and every error
+// that occurred is returned once the outstanding requests finish.
+//
+// Teams tied in the overall rankings are ordered using DefaultTieBreakers.
+func GetPowerDataForSchemes(
+	ctx context.Context,
+	client PowerRankingsClient,
+	l *goff.League,
+	currentWeek int,
+	schemes []Scheme) ([]*LeaguePowerData, error) {
+
+	return GetPowerDataWithOptions(
+		ctx,
+		client,
+		l,
+		currentWeek,
+		schemes,
+		PowerDataOptions{TieBreakers: DefaultTieBreakers})
+}
+
+// GetPowerDataWithOptions returns a league's power rankings like
+// GetPowerDataForSchemes, using the given options to choose the weeks that are
+// ranked and how teams tied in the overall rankings of each scheme are
+// ordered.
+func GetPowerDataWithOptions(
+	ctx context.Context,
+	client PowerRankingsClient,
+	l *goff.League,
+	currentWeek int,
+	schemes []Scheme,
+	options PowerDataOptions) ([]*LeaguePowerData, error) {
+
+	tieBreakers := options.TieBreakers
 	leagueKey := l.LeagueKey
 
+	// Requests stop once the context is done, and weeks are numbered by the
+	// scoring periods of the league's season, so the client's optional
+	// interfaces are found before it's wrapped
+	rosterClient, hasRosters := client.(RosterClient)
+	categoryClient, hasCategories := client.(CategoryClient)
+	divisionClient, hasDivisions := client.(DivisionClient)
+	client = &contextClient{client: client, ctx: ctx}
+	if hasDivisions {
+		divisionClient = client.(DivisionClient)
+	}
+
+	calendar, err := FindSeasonCalendar(client, l)
+	if err != nil {
+		return nil, err
+	}
+	client = calendar.wrapClient(client)
+	if hasRosters {
+		rosterClient = client.(RosterClient)
+	}
+	if hasCategories {
+		categoryClient = client.(CategoryClient)
+	}
+
 	league, err := client.GetLeagueStandings(leagueKey)
 	if err != nil {
 		return nil, err
 	}
 
-	resultsChan := make(chan *WeeklyRanking)
-	errorsChan := make(chan error)
-	schemes := GetSchemes()
+	var divisions []Division
+	if hasDivisions {
+		divisions, err = divisionClient.GetDivisions(leagueKey)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Weeks after the regular season are left out of the rankings entirely,
+	// so the overall results can't be through a later week
+	endWeek := calendar.Periods()
+	if options.SeasonMode == SeasonModes.REGULAR {
+		endWeek = regularSeasonEndWeek(endWeek, league)
+		if currentWeek > endWeek {
+			currentWeek = endWeek
+		}
+	}
+
+	// Weeks after the current week are projected using the points scored in
+	// the completed weeks, instead of the projections of the provider
+	if options.Projector != nil && currentWeek < endWeek {
+		history, err := GetScoreHistory(client, leagueKey, currentWeek)
+		if err != nil {
+			return nil, err
+		}
+		lastWeek := currentWeek
+		if options.Live && !league.IsFinished {
+			lastWeek++
+		}
+		client = newProjectorClient(
+			client,
+			options.Projector,
+			history,
+			league.Standings,
+			lastWeek)
+	}
+
+	// The week being played is ranked along with the completed weeks, using
+	// the points scored so far
+	playedWeeks := currentWeek
+	liveWeek := 0
+	if options.Live && !league.IsFinished && currentWeek < endWeek {
+		liveWeek = currentWeek + 1
+		currentWeek = liveWeek
+		client = &liveClient{
+			client:    client,
+			liveWeek:  liveWeek,
+			remaining: options.LiveRemaining,
+		}
+	}
+
+	// Category leagues don't score fantasy points, so they can only be ranked
+	// using the stats of each team
+	var categories []StatCategory
+	if IsCategoryLeague(l) || IsCategoryLeague(league) {
+		if !hasCategories {
+			return nil, ErrCategoryLeague
+		}
+		categories, err = categoryClient.GetStatCategories(leagueKey)
+		if err != nil {
+			return nil, err
+		}
+		schemes = GetCategorySchemes()
+	}
+
+	// Schemes that use the whole season are calculated separately since they
+	// need to process every week in order, and schemes that use rosters need
+	// to get them for each team
+	var supportedSchemes []Scheme
+	var weeklySchemes []Scheme
+	var seasonSchemes []SeasonScheme
+	var rosterSchemes []RosterScheme
+	var categorySchemes []CategoryScheme
+	for _, scheme := range schemes {
+		if categoryScheme, ok := scheme.(CategoryScheme); ok {
+			if !hasCategories {
+				glog.Warningf("client can't get team stats, skipping scheme -- "+
+					"scheme=%s",
+					scheme.ID())
+				continue
+			}
+			categorySchemes = append(categorySchemes, categoryScheme)
+		} else if rosterScheme, ok := scheme.(RosterScheme); ok {
+			if !hasRosters {
+				glog.Warningf("client can't get rosters, skipping scheme -- "+
+					"scheme=%s",
+					scheme.ID())
+				continue
+			}
+			rosterSchemes = append(rosterSchemes, rosterScheme)
+		} else if seasonScheme, ok := scheme.(SeasonScheme); ok {
+			seasonSchemes = append(seasonSchemes, seasonScheme)
+		} else if _, ok := scheme.(MatchupScheme); ok {
+			// Ratings are carried over each week by the adapted scheme
+			seasonSchemes = append(seasonSchemes, GetSeasonScheme(scheme))
+		} else {
+			weeklySchemes = append(weeklySchemes, scheme)
+		}
+		supportedSchemes = append(supportedSchemes, scheme)
+	}
+	schemes = supportedSchemes
+
+	// Every weekly ranking fits in the results channel, so goroutines never
+	// block when rankings stop being received
+	resultsChan := make(chan *WeeklyRanking, endWeek*len(schemes))
+	requests := newRequestGroup(ctx, MaxConcurrentRequests)
+	defer requests.cancel()
 
 	// Getting matchups for a span of multiple weeks results in less API calls
 	// to the fantasy sports provider, and thus a lower risk of being
@@ -287,26 +621,120 @@ func GetPowerData(client PowerRankingsClient, l *goff.League, currentWeek int) (
 	matchupsEnd := 0
 	if MinimizeAPICalls {
 		matchupsEnd = lastWeekMatchupsAreAvailable(currentWeek, league)
+	}
+
+	// Schemes and tie-breakers that use matchups need them for the entire
+	// season
+	requestMatchupsEnd := matchupsEnd
+	if len(seasonSchemes) > 0 || len(rosterSchemes) > 0 ||
+		tieBreakers.NeedsMatchups() || options.PlayoffSimulator != nil {
+		requestMatchupsEnd = endWeek
+	}
+
+	// Consistency is measured using the matchups of the weeks played
+	if requestMatchupsEnd < playedWeeks {
+		requestMatchupsEnd = playedWeeks
+	}
+
+	var allMatchups map[int][]goff.Matchup
+	if requestMatchupsEnd > 0 {
 		glog.V(2).Infof("getting weekly matchups -- weekStart=%d, weekEnd=%d",
 			1,
-			matchupsEnd)
-		allMatchups, err := client.GetMatchupsForWeekRange(leagueKey, 1, matchupsEnd)
+			requestMatchupsEnd)
+		allMatchups, err = client.GetMatchupsForWeekRange(leagueKey, 1, requestMatchupsEnd)
 		if err != nil {
 			return nil, err
 		}
+	}
+
+	for week := 1; week <= matchupsEnd; week++ {
+		week := week
+		matchups, ok := allMatchups[week]
+		if !ok {
+			// Weeks without any matchups are ranked using the points of
+			// every team instead
+			requests.Go(func(errors chan error) {
+				GetWeeklyRanking(
+					client,
+					leagueKey,
+					week,
+					resultsChan,
+					errors,
+					week > currentWeek,
+					weeklySchemes)
+			})
+			continue
+		}
+		requests.Go(func(errors chan error) {
+			GetWeeklyRankingFromMatchups(week, matchups, resultsChan, weeklySchemes)
+		})
+	}
 
-		for week, matchups := range allMatchups {
-			go GetWeeklyRankingFromMatchups(week, matchups, resultsChan, schemes)
+	if len(weeklySchemes) > 0 {
+		for week := matchupsEnd + 1; week <= endWeek; week++ {
+			// Weeks after the current week are projections
+			week := week
+			requests.Go(func(errors chan error) {
+				GetWeeklyRanking(
+					client,
+					leagueKey,
+					week,
+					resultsChan,
+					errors,
+					week > currentWeek,
+					weeklySchemes)
+			})
 		}
 	}
 
-	for week := matchupsEnd + 1; week <= currentWeek; week++ {
-		go GetWeeklyRanking(client, leagueKey, week, resultsChan, errorsChan, false, schemes)
+	if len(categorySchemes) > 0 {
+		for week := 1; week <= endWeek; week++ {
+			week := week
+			requests.Go(func(errors chan error) {
+				GetCategoryWeeklyRanking(
+					categoryClient,
+					leagueKey,
+					week,
+					league.Standings,
+					categories,
+					resultsChan,
+					errors,
+					week > currentWeek,
+					categorySchemes)
+			})
+		}
 	}
 
-	// Get projections
-	for week := currentWeek + 1; week <= endWeek; week++ {
-		go GetWeeklyRanking(client, leagueKey, week, resultsChan, errorsChan, true, schemes)
+	matchupTeams := getMatchupTeams(league, allMatchups, endWeek)
+	season := &Season{
+		Teams:       matchupTeams,
+		Matchups:    allMatchups,
+		CurrentWeek: currentWeek,
+		EndWeek:     endWeek,
+	}
+	for _, scheme := range seasonSchemes {
+		scheme := scheme
+		requests.Go(func(errors chan error) {
+			GetSeasonRankings(scheme, season, resultsChan)
+		})
+	}
+
+	if len(rosterSchemes) > 0 {
+		for week := 1; week <= endWeek; week++ {
+			week := week
+			requests.Go(func(errors chan error) {
+				GetRosterRanking(
+					rosterClient,
+					leagueKey,
+					week,
+					matchupTeams,
+					allMatchups[week],
+					week > currentWeek,
+					resultsChan,
+					errors,
+					rosterSchemes)
+			})
+		}
 	}
 
 	teamDataByTeamKey := make(map[string]goff.Team)
@@ -326,7 +754,11 @@ func GetPowerData(client PowerRankingsClient, l *goff.League, currentWeek int) (
 	// Calculate power score for each team
 	for week := 1; week <= endWeek*len(schemes); week++ {
 		select {
-		case err := <-errorsChan:
+		case <-requests.ctx.Done():
+			err := requests.Wait()
+			if err == nil {
+				err = ctx.Err()
+			}
 			glog.Warningf("error calculating weekly ranking -- "+
 				"league=%s, error=%s",
 				leagueKey,
@@ -344,6 +776,9 @@ func GetPowerData(client PowerRankingsClient, l *goff.League, currentWeek int) (
 				weeklyRanking.Week,
 				scheme.DisplayName())
 
+			if weeklyRanking.Week == liveWeek {
+				markLive(weeklyRanking)
+			}
 			weekIndex := weeklyRanking.Week - 1
 			weeklyRankings[weekIndex] = weeklyRanking
 			for _, teamScoreData := range weeklyRanking.Rankings {
@@ -389,7 +824,17 @@ func GetPowerData(client PowerRankingsClient, l *goff.League, currentWeek int) (
 		workbook := schemeWorkbooks[scheme.ID()]
 		powerDataByTeamKey := workbook.PowerDataByTeamKey
 		weeklyRankings := workbook.WeeklyRankings
-		createWeeklyTeamRankings(scheme, powerDataByTeamKey, endWeek)
+		// Schemes that only rank a single week at a time are given standings
+		// the same way as when they're adapted to be a SeasonScheme
+		_, hasStandings := scheme.(SeasonScheme)
+		if _, ok := scheme.(MatchupScheme); ok {
+			hasStandings = true
+		}
+		if !hasStandings {
+			addWeeklyStandings(scheme, weeklyRankings)
+		}
+		addSeasonStandings(powerDataByTeamKey, weeklyRankings, currentWeek, endWeek)
+
 		glog.V(2).Infof("ranking teams -- league=%s, numTeams=%d",
 			leagueKey,
 			len(powerDataByTeamKey))
@@ -400,45 +845,10 @@ func GetPowerData(client PowerRankingsClient, l *goff.League, currentWeek int) (
 			index++
 		}
 
-		if scheme.Type() == Types.RECORD {
-			sort.Sort(RecordRankings(sortedPowerData))
-			for i, powerData := range sortedPowerData {
-				// Handle ties
-				if i > 0 &&
-					powerData.OverallRecord.Wins ==
-						sortedPowerData[i-1].OverallRecord.Wins &&
-					powerData.OverallRecord.Ties ==
-						sortedPowerData[i-1].OverallRecord.Ties {
-					powerData.Rank = sortedPowerData[i-1].Rank
-				} else {
-					powerData.Rank = i + 1
-				}
-				glog.V(4).Infof("overall rankings -- league=%s, rank=%d, team=%s, "+
-					"total=%s",
-					leagueKey,
-					powerData.Rank,
-					powerData.Team.Name,
-					recordString(powerData.OverallRecord))
-			}
-		} else {
-			sort.Sort(PowerRankings(sortedPowerData))
-			for i, powerData := range sortedPowerData {
-				// Handle ties
-				if i > 0 &&
-					powerData.TotalScore ==
-						sortedPowerData[i-1].TotalScore {
-					powerData.Rank = sortedPowerData[i-1].Rank
-				} else {
-					powerData.Rank = i + 1
-				}
-				glog.V(4).Infof("overall rankings -- league=%s, rank=%d, team=%s, "+
-					"total=%f",
-					leagueKey,
-					powerData.Rank,
-					powerData.Team.Name,
-					powerData.TotalScore)
-			}
-		}
+		// Ranks are assigned by the standings
+		sort.Sort(StandingsRankings(sortedPowerData))
+
+		breakTies(sortedPowerData, tieBreakers.Chain(scheme.ID()), season, false)
 
 		glog.V(2).Infof("projecting rankings -- league=%s", leagueKey)
 		sortedProjectionData := make([]*TeamPowerData, len(powerDataByTeamKey))
@@ -447,48 +857,13 @@ func GetPowerData(client PowerRankingsClient, l *goff.League, currentWeek int) (
 			sortedProjectionData[index] = powerData
 			index++
 		}
-		if scheme.Type() == Types.RECORD {
-			sort.Sort(ProjectedRecordRankings(sortedProjectionData))
-			for i, powerData := range sortedProjectionData {
-				// Handle ties
-				if i > 0 &&
-					powerData.ProjectedOverallRecord.Wins ==
-						sortedProjectionData[i-1].ProjectedOverallRecord.Wins &&
-					powerData.ProjectedOverallRecord.Ties ==
-						sortedProjectionData[i-1].ProjectedOverallRecord.Ties {
-					powerData.ProjectedRank = sortedProjectionData[i-1].ProjectedRank
-				} else {
-					powerData.ProjectedRank = i + 1
-				}
-				glog.V(4).Infof("projected rankings -- league=%s, rank=%d, team=%s, "+
-					"projected=%s",
-					leagueKey,
-					powerData.ProjectedRank,
-					powerData.Team.Name,
-					recordString(powerData.ProjectedOverallRecord))
-			}
-		} else {
-			sort.Sort(ProjectedPowerRankings(sortedProjectionData))
-			for i, powerData := range sortedProjectionData {
-				// Handle ties
-				if i > 0 &&
-					powerData.ProjectedTotalScore ==
-						sortedProjectionData[i-1].ProjectedTotalScore {
-					powerData.ProjectedRank = sortedProjectionData[i-1].ProjectedRank
-				} else {
-					powerData.ProjectedRank = i + 1
-				}
-				glog.V(4).Infof("projected rankings -- league=%s, rank=%d, team=%s, "+
-					"projected=%f",
-					leagueKey,
-					powerData.ProjectedRank,
-					powerData.Team.Name,
-					powerData.ProjectedTotalScore)
-			}
-		}
+		sort.Sort(ProjectedStandingsRankings(sortedProjectionData))
+
+		breakTies(sortedProjectionData, tieBreakers.Chain(scheme.ID()), season, true)
 
 		leaguePowerData = append(leaguePowerData, &LeaguePowerData{
 			RankingScheme:     scheme,
+			CurrentWeek:       currentWeek,
 			OverallRankings:   sortedPowerData,
 			ProjectedRankings: sortedProjectionData,
 			ByTeam:            powerDataByTeamKey,
@@ -496,9 +871,215 @@ func GetPowerData(client PowerRankingsClient, l *goff.League, currentWeek int) (
 		})
 	}
 
+	recordWeeks := regularSeasonEndWeek(endWeek, league)
+	if currentWeek < recordWeeks {
+		recordWeeks = currentWeek
+	}
+	addLuckIndex(leaguePowerData, recordWeeks)
+	addStrengthOfSchedule(leaguePowerData, allMatchups, currentWeek, endWeek)
+	addManagerEfficiency(leaguePowerData)
+	addConsistency(leaguePowerData, allMatchups, playedWeeks)
+	addHeadToHead(leaguePowerData, league.Standings, allMatchups, currentWeek)
+	if len(leaguePowerData) > 0 {
+		addDivisions(leaguePowerData, divisions, leaguePowerData[0].HeadToHead)
+	}
+
+	// Only the weeks that have been completed are known results, and there's
+	// nothing left to simulate once the regular season is over
+	if options.PlayoffSimulator != nil {
+		lastWeek := regularSeasonEndWeek(calendar.Periods(), league)
+		if playedWeeks < lastWeek {
+			odds := options.PlayoffSimulator.Simulate(
+				league,
+				allMatchups,
+				playedWeeks,
+				lastWeek)
+			for _, powerData := range leaguePowerData {
+				powerData.PlayoffOdds = odds
+			}
+		}
+	}
+
 	return leaguePowerData, nil
 }
 
+// addLuckIndex updates each team with how many wins it was expected to have
+// and how lucky it has been, comparing its actual record in the league to
+// its 'All-Play' record. A team's luck is the number of wins it has above (or
+// below, if negative) its expected wins.
+//
+// The actual record only covers the regular season, so only the 'All-Play'
+// records of the regular season weeks that have been played through the
+// given week are used.
+func addLuckIndex(leaguePowerData []*LeaguePowerData, throughWeek int) {
+	var allPlayData *LeaguePowerData
+	for _, powerData := range leaguePowerData {
+		if powerData.RankingScheme.ID() == (allPlayRecord{}).ID() {
+			allPlayData = powerData
+		}
+	}
+	if allPlayData == nil {
+		return
+	}
+
+	allPlayRecords := make(map[string]*goff.Record)
+	for teamKey, teamData := range allPlayData.ByTeam {
+		record := &goff.Record{}
+		for week := 1; week <= throughWeek && week <= len(teamData.AllScores); week++ {
+			teamScore := teamData.AllScores[week-1]
+			if teamScore != nil && !teamScore.Projected && !teamScore.Live {
+				addRecord(record, teamScore.Record)
+			}
+		}
+		allPlayRecords[teamKey] = record
+	}
+
+	for _, powerData := range leaguePowerData {
+		for teamKey, teamData := range powerData.ByTeam {
+			allPlayRecord, ok := allPlayRecords[teamKey]
+			if ok {
+				teamData.ExpectedWins, teamData.Luck = calculateLuck(
+					allPlayRecord,
+					&teamData.Team.TeamStandings.Record)
+			}
+		}
+	}
+}
+
+// addManagerEfficiency updates each team with the points scored by its
+// starting lineups as a fraction of the points scored by its optimal lineups,
+// for the weeks that have been played
+func addManagerEfficiency(leaguePowerData []*LeaguePowerData) {
+	var optimalData *LeaguePowerData
+	for _, powerData := range leaguePowerData {
+		if powerData.RankingScheme.ID() == (optimalLineup{}).ID() {
+			optimalData = powerData
+		}
+	}
+	if optimalData == nil {
+		return
+	}
+
+	efficiencies := make(map[string]float64)
+	for teamKey, teamData := range optimalData.ByTeam {
+		actual := 0.0
+		optimal := 0.0
+		for _, teamScore := range teamData.AllScores {
+			if teamScore != nil && !teamScore.Projected {
+				actual += teamScore.FantasyScore
+				optimal += teamScore.PowerScore
+			}
+		}
+		if optimal > 0 {
+			efficiencies[teamKey] = actual / optimal
+		}
+	}
+
+	for _, powerData := range leaguePowerData {
+		for teamKey, teamData := range powerData.ByTeam {
+			teamData.ManagerEfficiency = efficiencies[teamKey]
+		}
+	}
+}
+
+// calculateLuck returns the expected wins for a team based on its all-play
+// win percentage and number of games played, and the difference between its
+// actual wins and those expected wins. Ties count as half a win.
+func calculateLuck(allPlay *goff.Record, actual *goff.Record) (float64, float64) {
+	allPlayGames := allPlay.Wins + allPlay.Losses + allPlay.Ties
+	gamesPlayed := actual.Wins + actual.Losses + actual.Ties
+	if allPlayGames == 0 || gamesPlayed == 0 {
+		return 0.0, 0.0
+	}
+
+	expectedWins := winPercentage(allPlay) * float64(gamesPlayed)
+	actualWins := float64(actual.Wins) + 0.5*float64(actual.Ties)
+	return expectedWins, actualWins - expectedWins
+}
+
+// addStrengthOfSchedule updates each team with the strength of the opponents
+// it has played through the current week and the opponents it has left to
+// play, using the 'All-Play' win percentage of each opponent
+func addStrengthOfSchedule(
+	leaguePowerData []*LeaguePowerData,
+	allMatchups map[int][]goff.Matchup,
+	currentWeek int,
+	endWeek int) {
+
+	var allPlayData *LeaguePowerData
+	for _, powerData := range leaguePowerData {
+		if powerData.RankingScheme.ID() == (allPlayRecord{}).ID() {
+			allPlayData = powerData
+		}
+	}
+	if allPlayData == nil {
+		return
+	}
+
+	// Before any games are played, the projected records are the best
+	// indication of how strong each opponent is
+	opponentStrength := make(map[string]float64)
+	for teamKey, teamData := range allPlayData.ByTeam {
+		if teamData.OverallRecord.Wins+
+			teamData.OverallRecord.Losses+
+			teamData.OverallRecord.Ties > 0 {
+			opponentStrength[teamKey] = winPercentage(teamData.OverallRecord)
+		} else {
+			opponentStrength[teamKey] = winPercentage(teamData.ProjectedOverallRecord)
+		}
+	}
+
+	past := calculateStrengthOfSchedule(allMatchups, opponentStrength, 1, currentWeek)
+	remaining := calculateStrengthOfSchedule(allMatchups, opponentStrength, currentWeek+1, endWeek)
+	for _, powerData := range leaguePowerData {
+		for teamKey, teamData := range powerData.ByTeam {
+			teamData.StrengthOfSchedule = past[teamKey]
+			teamData.RemainingStrengthOfSchedule = remaining[teamKey]
+		}
+	}
+}
+
+// calculateStrengthOfSchedule returns the average strength of the opponents
+// each team plays from the start week through the end week
+func calculateStrengthOfSchedule(
+	allMatchups map[int][]goff.Matchup,
+	opponentStrength map[string]float64,
+	startWeek int,
+	endWeek int) map[string]float64 {
+
+	totals := make(map[string]float64)
+	games := make(map[string]int)
+	for week := startWeek; week <= endWeek; week++ {
+		for _, matchup := range allMatchups[week] {
+			if len(matchup.Teams) != 2 {
+				continue
+			}
+			first := matchup.Teams[0].TeamKey
+			second := matchup.Teams[1].TeamKey
+			totals[first] += opponentStrength[second]
+			totals[second] += opponentStrength[first]
+			games[first]++
+			games[second]++
+		}
+	}
+
+	strength := make(map[string]float64)
+	for teamKey, total := range totals {
+		strength[teamKey] = total / float64(games[teamKey])
+	}
+	return strength
+}
+
+// winPercentage returns the percentage of games won for a record, with ties
+// counting as half a win
+func winPercentage(r *goff.Record) float64 {
+	games := r.Wins + r.Losses + r.Ties
+	if games == 0 {
+		return 0.0
+	}
+	return (float64(r.Wins) + 0.5*float64(r.Ties)) / float64(games)
+}
+
 // Find the last week in a season that matchups can be used to gather data for
 // the power rankings. (Matchups cannot be used for playoff games or
 // projections)
@@ -509,69 +1090,47 @@ func lastWeekMatchupsAreAvailable(currentWeek int, l *goff.League) int {
 	return currentWeek
 }
 
-// Update each team in the power data map to have their overall ranking
-// in the power league for each week in a season
-func createWeeklyTeamRankings(
-	scheme Scheme,
-	powerDataByTeamKey map[string]*TeamPowerData,
-	endWeek int) {
+// getMatchupTeams returns every team in a league, using the standings and any
+// team that played in one of the given matchups
+func getMatchupTeams(
+	league *goff.League,
+	allMatchups map[int][]goff.Matchup,
+	endWeek int) []goff.Team {
 
-	// Calculate the overall rankings for each week
-	weeklyTeamRankings := make([][]*TeamRankingData, endWeek)
-	for i := 0; i < endWeek; i++ {
-		weeklyTeamRankings[i] = make([]*TeamRankingData, len(powerDataByTeamKey))
-		j := 0
-		for _, powerData := range powerDataByTeamKey {
-			weeklyScore := powerData.AllScores[i]
-			powerData.AllRankings[i] = &TeamRankingData{
-				Week:  i + 1,
-				Score: weeklyScore.PowerScore,
-				Record: &goff.Record{
-					Wins:   weeklyScore.Record.Wins,
-					Losses: weeklyScore.Record.Losses,
-					Ties:   weeklyScore.Record.Ties,
-				},
-				Projected: weeklyScore.Projected,
-			}
-			if i > 0 {
-				previousRanking := powerData.AllRankings[i-1]
-				powerData.AllRankings[i].Score +=
-					previousRanking.Score
-				addRecord(
-					powerData.AllRankings[i].Record,
-					previousRanking.Record)
-			}
-			weeklyTeamRankings[i][j] = powerData.AllRankings[i]
-			j++
-		}
-
-		// Sort by the cumulative power scores and assign ranks for this week
-		if scheme.Type() == Types.RECORD {
-			sort.Sort(RecordSortedTeamRankingsData(weeklyTeamRankings[i]))
-			for j, rankingsData := range weeklyTeamRankings[i] {
-				if j > 0 &&
-					rankingsData.Record.Wins ==
-						weeklyTeamRankings[i][j-1].Record.Wins &&
-					rankingsData.Record.Ties ==
-						weeklyTeamRankings[i][j-1].Record.Ties {
-					rankingsData.Rank = weeklyTeamRankings[i][j-1].Rank
-				} else {
-					rankingsData.Rank = j + 1
-				}
-			}
-		} else {
-			sort.Sort(PowerSortedTeamRankingsData(weeklyTeamRankings[i]))
-			for j, rankingsData := range weeklyTeamRankings[i] {
-				if j > 0 &&
-					rankingsData.Score ==
-						weeklyTeamRankings[i][j-1].Score {
-					rankingsData.Rank = weeklyTeamRankings[i][j-1].Rank
-				} else {
-					rankingsData.Rank = j + 1
+	var teams []goff.Team
+	seen := make(map[string]bool)
+	for _, team := range league.Standings {
+		if !seen[team.TeamKey] {
+			seen[team.TeamKey] = true
+			teams = append(teams, team)
+		}
+	}
+	for week := 1; week <= endWeek; week++ {
+		for _, matchup := range allMatchups[week] {
+			for _, team := range matchup.Teams {
+				if !seen[team.TeamKey] {
+					seen[team.TeamKey] = true
+					teams = append(teams, team)
 				}
 			}
 		}
 	}
+	return teams
+}
+
+// weightedScore returns the sum of a team's weekly power scores up to and
+// including the given week, weighted by the scheme
+func weightedScore(
+	scheme WeightedScheme,
+	allScores []*TeamScoreData,
+	throughWeek int) float64 {
+
+	score := 0.0
+	for week := 1; week <= throughWeek; week++ {
+		score += scheme.WeekWeight(week, throughWeek) *
+			allScores[week-1].PowerScore
+	}
+	return score
 }
 
 // addRecord adds to the first record the wins/losses/ties of the second
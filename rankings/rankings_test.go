@@ -1,9 +1,15 @@
 package rankings
 
 import (
+	"context"
 	"errors"
+	"math"
+	"runtime"
 	"sort"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/Forestmb/goff"
 )
@@ -80,6 +86,29 @@ func TestGetWeeklyProjectedRankingPowerScoreOrder(t *testing.T) {
 	}
 }
 
+func TestGetWeeklyRankingFromMatchupsSkipsByes(t *testing.T) {
+	matchups := []goff.Matchup{
+		goff.Matchup{
+			Teams: []goff.Team{
+				goff.Team{TeamKey: "a", TeamPoints: goff.Points{Total: 3.0}},
+				goff.Team{TeamKey: "b", TeamPoints: goff.Points{Total: 4.0}},
+			},
+		},
+		goff.Matchup{
+			Teams: []goff.Team{
+				goff.Team{TeamKey: "c", TeamPoints: goff.Points{Total: 5.0}},
+			},
+		},
+	}
+	results := make(chan *WeeklyRanking, 1)
+	GetWeeklyRankingFromMatchups(1, matchups, results, []Scheme{allPlayRecord{}})
+	weeklyRanking := <-results
+	if len(weeklyRanking.Rankings) != 2 {
+		t.Fatalf("Unexpected rankings for matchups with a bye: %+v",
+			weeklyRanking.Rankings)
+	}
+}
+
 func TestGetWeeklyRankingCorrectPowerScores(t *testing.T) {
 	m := mockClient{
 		WeekStats: map[int][]goff.Team{
@@ -277,148 +306,1423 @@ func TestGetWeeklyRankingError(t *testing.T) {
 	}
 }
 
-func TestGetPowerDataOverallRankings(t *testing.T) {
-	league := &goff.League{
-		LeagueKey: "leagueID",
-		EndWeek:   3,
+func TestMedianRecordWeeklyRankings(t *testing.T) {
+	m := mockClient{
+		WeekStats: map[int][]goff.Team{
+			// Week 1
+			1: []goff.Team{
+				goff.Team{TeamKey: "a", TeamPoints: goff.Points{Total: 3.0}},
+				goff.Team{TeamKey: "b", TeamPoints: goff.Points{Total: 6.0}},
+				goff.Team{TeamKey: "c", TeamPoints: goff.Points{Total: 4.0}},
+				goff.Team{TeamKey: "d", TeamPoints: goff.Points{Total: 5.0}},
+			},
+		},
+		WeekErrors: map[int]error{},
+	}
+	results := make(chan *WeeklyRanking)
+	errorsChan := make(chan error)
+	schemes := []Scheme{medianRecord{}}
+	go GetWeeklyRanking(m, "", 1, results, errorsChan, false, schemes)
+	weeklyRanking := <-results
+
+	expectedWins := map[string]int{"a": 0, "b": 1, "c": 0, "d": 1}
+	for _, teamData := range weeklyRanking.Rankings {
+		record := teamData.Record
+		expected := expectedWins[teamData.Team.TeamKey]
+		if record.Wins != expected ||
+			record.Losses != 1-expected ||
+			record.Ties != 0 {
+			t.Fatalf("Incorrect median record for team %s:\n\t"+
+				"Expected: %d-%d-0\n\tActual: %s",
+				teamData.Team.TeamKey,
+				expected,
+				1-expected,
+				recordString(record))
+		}
 	}
+
+	if weeklyRanking.Rankings[0].Team.TeamKey != "b" ||
+		weeklyRanking.Rankings[0].Rank != 1 {
+		t.Fatalf("Highest scoring team not ranked first: %+v",
+			weeklyRanking.Rankings[0])
+	}
+}
+
+func TestMedianRecordWeeklyRankingsTieAtMedian(t *testing.T) {
 	m := mockClient{
-		Matchups: map[int][]goff.Matchup{
+		WeekStats: map[int][]goff.Team{
 			// Week 1
-			1: []goff.Matchup{
-				goff.Matchup{
-					Teams: []goff.Team{
-						goff.Team{TeamKey: "a", TeamPoints: goff.Points{Total: 4.0}},
-						goff.Team{TeamKey: "b", TeamPoints: goff.Points{Total: 3.0}},
-					},
-				},
-				goff.Matchup{
-					Teams: []goff.Team{
-						goff.Team{TeamKey: "c", TeamPoints: goff.Points{Total: 2.0}},
-						goff.Team{TeamKey: "d", TeamPoints: goff.Points{Total: 1.0}},
-					},
-				},
+			1: []goff.Team{
+				goff.Team{TeamKey: "a", TeamPoints: goff.Points{Total: 3.0}},
+				goff.Team{TeamKey: "b", TeamPoints: goff.Points{Total: 4.0}},
+				goff.Team{TeamKey: "c", TeamPoints: goff.Points{Total: 5.0}},
 			},
-			2: []goff.Matchup{
-				goff.Matchup{
-					Teams: []goff.Team{
-						goff.Team{TeamKey: "a", TeamPoints: goff.Points{Total: 4.0}},
-						goff.Team{TeamKey: "b", TeamPoints: goff.Points{Total: 3.0}},
-					},
-				},
-				goff.Matchup{
-					Teams: []goff.Team{
-						goff.Team{TeamKey: "c", TeamPoints: goff.Points{Total: 2.0}},
-						goff.Team{TeamKey: "d", TeamPoints: goff.Points{Total: 1.0}},
-					},
-				},
+		},
+		WeekErrors: map[int]error{},
+	}
+	results := make(chan *WeeklyRanking)
+	errorsChan := make(chan error)
+	schemes := []Scheme{medianRecord{}}
+	go GetWeeklyRanking(m, "", 1, results, errorsChan, false, schemes)
+	weeklyRanking := <-results
+
+	expected := map[string]string{"a": "0-1-0", "b": "0-0-1", "c": "1-0-0"}
+	for _, teamData := range weeklyRanking.Rankings {
+		actual := recordString(teamData.Record)
+		if actual != expected[teamData.Team.TeamKey] {
+			t.Fatalf("Incorrect median record for team %s:\n\t"+
+				"Expected: %s\n\tActual: %s",
+				teamData.Team.TeamKey,
+				expected[teamData.Team.TeamKey],
+				actual)
+		}
+	}
+}
+
+func TestEloRatingSeasonRankings(t *testing.T) {
+	scheme := eloRating{kFactor: 32.0, marginOfVictory: false}
+	teams := []goff.Team{
+		goff.Team{TeamKey: "a"},
+		goff.Team{TeamKey: "b"},
+		goff.Team{TeamKey: "c"},
+	}
+	matchups := []goff.Matchup{
+		goff.Matchup{
+			Teams: []goff.Team{
+				goff.Team{TeamKey: "a", TeamPoints: goff.Points{Total: 4.0}},
+				goff.Team{TeamKey: "b", TeamPoints: goff.Points{Total: 3.0}},
 			},
-			3: []goff.Matchup{
-				goff.Matchup{
-					Teams: []goff.Team{
-						goff.Team{TeamKey: "a", TeamPoints: goff.Points{Total: 4.0}},
-						goff.Team{TeamKey: "b", TeamPoints: goff.Points{Total: 3.0}},
-					},
-				},
-				goff.Matchup{
-					Teams: []goff.Team{
-						goff.Team{TeamKey: "c", TeamPoints: goff.Points{Total: 2.0}},
-						goff.Team{TeamKey: "d", TeamPoints: goff.Points{Total: 1.0}},
-					},
-				},
+		},
+	}
+	season := &Season{
+		Teams:       teams,
+		Matchups:    map[int][]goff.Matchup{1: matchups, 2: matchups},
+		CurrentWeek: 2,
+		EndWeek:     2,
+	}
+
+	weeklyRanking, state := scheme.CalculateSeasonRankings(1, season, nil)
+
+	expectedRatings := TeamRatings{"a": 1516.0, "b": 1484.0, "c": 1500.0}
+	for teamKey, expected := range expectedRatings {
+		standing := weeklyRanking.Standings[teamKey]
+		if standing == nil || standing.Score != expected {
+			t.Fatalf("Incorrect Elo rating for team %s after week 1:\n\t"+
+				"Expected: %f\n\tActual: %+v",
+				teamKey,
+				expected,
+				standing)
+		}
+	}
+
+	if len(weeklyRanking.Rankings) != len(teams) {
+		t.Fatalf("Not every team was ranked:\n\tExpected: %d\n\tActual: %d",
+			len(teams),
+			len(weeklyRanking.Rankings))
+	}
+	for i, teamData := range weeklyRanking.Rankings {
+		teamKey := teamData.Team.TeamKey
+		if teamData.Rank != i+1 ||
+			weeklyRanking.Standings[teamKey].Rank != i+1 ||
+			teamData.PowerScore != expectedRatings[teamKey]-EloInitialRating {
+			t.Fatalf("Unexpected ranking for team %s: rank=%d, score=%f",
+				teamKey,
+				teamData.Rank,
+				teamData.PowerScore)
+		}
+	}
+
+	// Ratings carry over, so the power score is only the change in rating
+	weeklyRanking, _ = scheme.CalculateSeasonRankings(2, season, state)
+	for _, teamData := range weeklyRanking.Rankings {
+		if teamData.Team.TeamKey == "a" &&
+			(teamData.PowerScore <= 0.0 || teamData.PowerScore >= 16.0) {
+			t.Fatalf("Unexpected rating change for favorite winning again: %f",
+				teamData.PowerScore)
+		}
+	}
+	standings := weeklyRanking.Standings
+	if standings["a"].Score+standings["b"].Score != 3000.0 {
+		t.Fatalf("Rating points were not exchanged evenly: a=%f, b=%f",
+			standings["a"].Score,
+			standings["b"].Score)
+	}
+}
+
+func TestEloRatingMarginOfVictory(t *testing.T) {
+	withMargin := eloRating{kFactor: 32.0, marginOfVictory: true}
+	closeWin := withMargin.ratingChange(1500.0, 1500.0, 101.0, 100.0)
+	blowout := withMargin.ratingChange(1500.0, 1500.0, 150.0, 100.0)
+	if blowout <= closeWin {
+		t.Fatalf("Blowout win did not gain more rating than a close win:\n\t"+
+			"Close: %f\n\tBlowout: %f",
+			closeWin,
+			blowout)
+	}
+
+	tie := withMargin.ratingChange(1500.0, 1500.0, 100.0, 100.0)
+	if tie != 0.0 {
+		t.Fatalf("Tie between equally rated teams changed rating: %f", tie)
+	}
+}
+
+func TestRecencyWeightedWeekWeight(t *testing.T) {
+	decay := recencyWeighted{decay: 0.5}
+	for weeksAgo, expected := range []float64{1.0, 0.5, 0.25, 0.125} {
+		actual := decay.WeekWeight(10-weeksAgo, 10)
+		if actual != expected {
+			t.Fatalf("Unexpected decay weight for a week %d weeks ago:\n\t"+
+				"Expected: %f\n\tActual: %f",
+				weeksAgo,
+				expected,
+				actual)
+		}
+	}
+
+	window := recencyWeighted{decay: 0.5, window: 3}
+	for weeksAgo, expected := range []float64{1.0, 1.0, 1.0, 0.0} {
+		actual := window.WeekWeight(10-weeksAgo, 10)
+		if actual != expected {
+			t.Fatalf("Unexpected window weight for a week %d weeks ago:\n\t"+
+				"Expected: %f\n\tActual: %f",
+				weeksAgo,
+				expected,
+				actual)
+		}
+	}
+}
+
+func TestAddWeeklyStandingsRecencyWeighted(t *testing.T) {
+	scores := map[string][]float64{
+		"early": []float64{100.0, 100.0, 10.0},
+		"late":  []float64{10.0, 10.0, 150.0},
+	}
+	newWeeklyRankings := func() []*WeeklyRanking {
+		weeklyRankings := make([]*WeeklyRanking, 3)
+		for i := range weeklyRankings {
+			weeklyRankings[i] = &WeeklyRanking{Week: i + 1}
+			for teamKey, weeklyScores := range scores {
+				weeklyRankings[i].Rankings = append(weeklyRankings[i].Rankings, &TeamScoreData{
+					Team:       &goff.Team{TeamKey: teamKey},
+					PowerScore: weeklyScores[i],
+					Record:     &goff.Record{},
+				})
+			}
+		}
+		return weeklyRankings
+	}
+
+	weeklyRankings := newWeeklyRankings()
+	addWeeklyStandings(recencyWeighted{decay: 0.5}, weeklyRankings)
+
+	// early: 100*0.25 + 100*0.5 + 10 = 85, late: 10*0.25 + 10*0.5 + 150 = 157.5
+	for teamKey, expected := range map[string][]float64{
+		"early": []float64{100.0, 150.0, 85.0},
+		"late":  []float64{10.0, 15.0, 157.5},
+	} {
+		for i, expectedScore := range expected {
+			actual := weeklyRankings[i].Standings[teamKey].Score
+			if actual != expectedScore {
+				t.Fatalf("Unexpected weighted score for team %s, week %d:\n\t"+
+					"Expected: %f\n\tActual: %f",
+					teamKey,
+					i+1,
+					expectedScore,
+					actual)
+			}
+		}
+	}
+	if weeklyRankings[2].Standings["late"].Rank != 1 {
+		t.Fatalf("Team with the best recent scores not ranked first")
+	}
+
+	// Unweighted schemes add up every week
+	weeklyRankings = newWeeklyRankings()
+	addWeeklyStandings(totalPoints{}, weeklyRankings)
+	if weeklyRankings[2].Standings["early"].Rank != 1 {
+		t.Fatalf("Team with the best total score not ranked first")
+	}
+}
+
+func TestCompositeSchemeMatchupRankings(t *testing.T) {
+	matchups := []goff.Matchup{
+		goff.Matchup{
+			Teams: []goff.Team{
+				goff.Team{TeamKey: "a", TeamPoints: goff.Points{Total: 10.0}},
+				goff.Team{TeamKey: "b", TeamPoints: goff.Points{Total: 5.0}},
+			},
+		},
+		goff.Matchup{
+			Teams: []goff.Team{
+				goff.Team{TeamKey: "c", TeamPoints: goff.Points{Total: 8.0}},
+				goff.Team{TeamKey: "d", TeamPoints: goff.Points{Total: 1.0}},
 			},
 		},
-		WeekErrors:      map[int]error{},
-		StandingsLeague: league,
 	}
-	data, err := GetPowerData(m, league, 3)
+	teams := []goff.Team{
+		goff.Team{TeamKey: "a"},
+		goff.Team{TeamKey: "b"},
+		goff.Team{TeamKey: "c"},
+		goff.Team{TeamKey: "d"},
+		goff.Team{TeamKey: "e"},
+	}
+	scheme := NewCompositeScheme(CompositeWeights{
+		"all-play":               5.0,
+		"total-points":           3.0,
+		CompositeRecordComponent: 2.0,
+	})
+
+	ranking, _ := scheme.CalculateMatchupRankings(1, teams, matchups, false, nil)
+
+	expected := []struct {
+		TeamKey string
+		Score   float64
+		Rank    int
+	}{
+		{"a", 100.0, 1},
+		{"c", 50.0*(2.0/3.0) + 30.0*0.8 + 20.0, 2},
+		{"b", 50.0*(1.0/3.0) + 30.0*0.5, 3},
+		{"d", 30.0 * 0.1, 4},
+		{"e", 0.0, 5},
+	}
+	for i, expectedTeam := range expected {
+		actual := ranking.Rankings[i]
+		if actual.Team.TeamKey != expectedTeam.TeamKey ||
+			math.Abs(actual.PowerScore-expectedTeam.Score) > 0.000001 ||
+			actual.Rank != expectedTeam.Rank {
+			t.Fatalf("Unexpected composite ranking %d:\n\t"+
+				"Expected: team=%s, score=%f, rank=%d\n\t"+
+				"Actual: team=%s, score=%f, rank=%d",
+				i+1,
+				expectedTeam.TeamKey,
+				expectedTeam.Score,
+				expectedTeam.Rank,
+				actual.Team.TeamKey,
+				actual.PowerScore,
+				actual.Rank)
+		}
+	}
+}
 
+func TestParseCompositeWeights(t *testing.T) {
+	weights, err := ParseCompositeWeights(" record:20, all-play:50,total-points:30")
 	if err != nil {
-		t.Fatalf("GetPowerData returned unexpected error: %s\n", err)
+		t.Fatalf("Unexpected error parsing composite weights: %s", err)
+	}
+	expected := "all-play:50,record:20,total-points:30"
+	if weights.String() != expected {
+		t.Fatalf("Unexpected composite weights:\n\tExpected: %s\n\tActual: %s",
+			expected,
+			weights.String())
 	}
 
-	allPlayData := data[0]
-	rankings := allPlayData.OverallRankings
-	if rankings[0].Team.TeamKey != "a" ||
-		rankings[1].Team.TeamKey != "b" ||
-		rankings[2].Team.TeamKey != "c" ||
-		rankings[3].Team.TeamKey != "d" {
-		t.Fatalf("GetPowerData returned incorrect rankings.\n"+
-			"\trankings: %+v",
-			rankings)
+	for _, invalid := range []string{
+		"",
+		"all-play",
+		"all-play:fifty",
+		"all-play:-1",
+		"elo:10",
+		"all-play:0,record:0",
+	} {
+		weights, err := ParseCompositeWeights(invalid)
+		if err == nil {
+			t.Fatalf("No error returned parsing invalid composite weights "+
+				"'%s': %+v",
+				invalid,
+				weights)
+		}
 	}
+}
 
-	if rankings[0].OverallRecord.Wins != 9 ||
-		rankings[1].OverallRecord.Wins != 6 ||
-		rankings[2].OverallRecord.Wins != 3 ||
-		rankings[3].OverallRecord.Wins != 0 {
-		t.Fatalf("GetPowerData returned incorrect scores.\n"+
-			"\trankings: %+v",
-			rankings)
+func TestGetLineups(t *testing.T) {
+	player := func(position string, eligible string, points float64) goff.Player {
+		return goff.Player{
+			DisplayPosition:  eligible,
+			SelectedPosition: goff.SelectedPosition{Position: position},
+			PlayerPoints:     goff.Points{Total: points},
+		}
+	}
+	rosters := map[string][]goff.Player{
+		// Benched the best RB, and started the only WR in the flex spot
+		"a": []goff.Player{
+			player("RB", "RB", 5.0),
+			player("W/R/T", "WR", 8.0),
+			player("BN", "RB", 20.0),
+			player("BN", "WR,RB", 12.0),
+			player("IR", "TE", 0.0),
+		},
+		// Started the best possible lineup
+		"b": []goff.Player{
+			player("RB", "RB", 10.0),
+			player("WR", "WR", 9.0),
+			player("W/R/T", "TE", 6.0),
+			player("BN", "QB", 30.0),
+		},
 	}
 
-	if rankings[0].Rank != 1 ||
-		rankings[1].Rank != 2 ||
-		rankings[2].Rank != 3 ||
-		rankings[3].Rank != 4 {
-		t.Fatalf("GetPowerData returned incorrect ranks.\n"+
-			"\trankings: %+v",
-			rankings)
+	lineups := GetLineups(rosters)
+
+	// Slots: RB, WR, W/R/T
+	for teamKey, expected := range map[string]TeamLineup{
+		"a": TeamLineup{ActualPoints: 13.0, OptimalPoints: 40.0},
+		"b": TeamLineup{ActualPoints: 25.0, OptimalPoints: 25.0},
+	} {
+		actual := lineups[teamKey]
+		if *actual != expected {
+			t.Fatalf("Unexpected lineup for team %s:\n\tExpected: %+v\n\t"+
+				"Actual: %+v",
+				teamKey,
+				expected,
+				*actual)
+		}
 	}
 }
 
-func TestGetProjectedPowerDataOverallRankings(t *testing.T) {
+func TestGetLineupsUtilityExcludesPitchers(t *testing.T) {
+	player := func(position string, eligible string, points float64) goff.Player {
+		return goff.Player{
+			DisplayPosition:  eligible,
+			SelectedPosition: goff.SelectedPosition{Position: position},
+			PlayerPoints:     goff.Points{Total: points},
+		}
+	}
+	rosters := map[string][]goff.Player{
+		// The benched pitchers can't be started at Util, but the benched
+		// hitter can
+		"a": []goff.Player{
+			player("Util", "1B", 3.0),
+			player("SP", "SP", 10.0),
+			player("BN", "SP", 25.0),
+			player("BN", "RP", 15.0),
+			player("BN", "OF", 5.0),
+		},
+	}
+
+	lineups := GetLineups(rosters)
+
+	// Slots: Util, SP
+	expected := TeamLineup{ActualPoints: 13.0, OptimalPoints: 30.0}
+	if *lineups["a"] != expected {
+		t.Fatalf("Unexpected lineup with a Util spot:\n\tExpected: %+v\n\t"+
+			"Actual: %+v",
+			expected,
+			*lineups["a"])
+	}
+}
+
+func TestOptimalLineupRosterRankings(t *testing.T) {
+	teams := []goff.Team{
+		goff.Team{TeamKey: "a", TeamPoints: goff.Points{Total: 13.0}},
+		goff.Team{TeamKey: "b", TeamPoints: goff.Points{Total: 25.0}},
+		goff.Team{TeamKey: "c", TeamPoints: goff.Points{Total: 30.0}},
+	}
+	rosters := map[string][]goff.Player{
+		"a": []goff.Player{
+			goff.Player{
+				DisplayPosition:  "QB",
+				SelectedPosition: goff.SelectedPosition{Position: "BN"},
+				PlayerPoints:     goff.Points{Total: 40.0},
+			},
+			goff.Player{
+				DisplayPosition:  "QB",
+				SelectedPosition: goff.SelectedPosition{Position: "QB"},
+				PlayerPoints:     goff.Points{Total: 13.0},
+			},
+		},
+		"b": []goff.Player{
+			goff.Player{
+				DisplayPosition:  "QB",
+				SelectedPosition: goff.SelectedPosition{Position: "QB"},
+				PlayerPoints:     goff.Points{Total: 25.0},
+			},
+		},
+	}
+
+	ranking := optimalLineup{}.CalculateRosterRankings(1, teams, rosters, false)
+
+	expected := []struct {
+		TeamKey string
+		Actual  float64
+		Optimal float64
+	}{
+		{"a", 13.0, 40.0},
+		{"c", 30.0, 30.0},
+		{"b", 25.0, 25.0},
+	}
+	for i, expectedTeam := range expected {
+		actual := ranking.Rankings[i]
+		if actual.Team.TeamKey != expectedTeam.TeamKey ||
+			actual.FantasyScore != expectedTeam.Actual ||
+			actual.PowerScore != expectedTeam.Optimal ||
+			actual.Rank != i+1 {
+			t.Fatalf("Unexpected optimal lineup ranking %d:\n\t"+
+				"Expected: team=%s, actual=%f, optimal=%f\n\t"+
+				"Actual: team=%s, actual=%f, optimal=%f, rank=%d",
+				i+1,
+				expectedTeam.TeamKey,
+				expectedTeam.Actual,
+				expectedTeam.Optimal,
+				actual.Team.TeamKey,
+				actual.FantasyScore,
+				actual.PowerScore,
+				actual.Rank)
+		}
+	}
+}
+
+func TestGetPowerDataManagerEfficiency(t *testing.T) {
 	league := &goff.League{
 		LeagueKey: "leagueID",
-		EndWeek:   3,
-		Standings: []goff.Team{
-			goff.Team{
-				TeamKey: "a",
-				TeamStandings: goff.TeamStandings{
-					Rank: 1,
-					Record: goff.Record{
-						Wins:   5,
-						Losses: 2,
-						Ties:   0,
-					},
-					PointsFor:     12345.0,
-					PointsAgainst: 54321.0,
-				},
+		EndWeek:   1,
+	}
+	matchups := []goff.Matchup{
+		goff.Matchup{
+			Teams: []goff.Team{
+				goff.Team{TeamKey: "a", TeamPoints: goff.Points{Total: 10.0}},
+				goff.Team{TeamKey: "b", TeamPoints: goff.Points{Total: 20.0}},
 			},
-			goff.Team{
-				TeamKey: "b",
-				TeamStandings: goff.TeamStandings{
-					Rank: 2,
-					Record: goff.Record{
-						Wins:   3,
-						Losses: 3,
-						Ties:   1,
-					},
-					PointsFor:     1234.0,
-					PointsAgainst: 4321.0,
+		},
+	}
+	m := mockRosterClient{
+		mockClient: mockClient{
+			Matchups:        map[int][]goff.Matchup{1: matchups},
+			WeekErrors:      map[int]error{},
+			StandingsLeague: league,
+		},
+		Rosters: map[string][]goff.Player{
+			"a": []goff.Player{
+				goff.Player{
+					DisplayPosition:  "QB",
+					SelectedPosition: goff.SelectedPosition{Position: "QB"},
+					PlayerPoints:     goff.Points{Total: 10.0},
+				},
+				goff.Player{
+					DisplayPosition:  "QB",
+					SelectedPosition: goff.SelectedPosition{Position: "BN"},
+					PlayerPoints:     goff.Points{Total: 40.0},
 				},
 			},
-			goff.Team{
-				TeamKey: "c",
-				TeamStandings: goff.TeamStandings{
-					Rank: 3,
-					Record: goff.Record{
-						Wins:   0,
-						Losses: 6,
-						Ties:   1,
-					},
-					PointsFor:     234.0,
-					PointsAgainst: 321.0,
+			"b": []goff.Player{
+				goff.Player{
+					DisplayPosition:  "QB",
+					SelectedPosition: goff.SelectedPosition{Position: "QB"},
+					PlayerPoints:     goff.Points{Total: 20.0},
 				},
 			},
 		},
 	}
-	m := mockClient{
-		Matchups: map[int][]goff.Matchup{
-			1: []goff.Matchup{
-				goff.Matchup{
-					Teams: []goff.Team{
-						goff.Team{
+
+	data, err := GetPowerDataForSchemes(
+		context.Background(),
+		m,
+		league,
+		1,
+		[]Scheme{allPlayRecord{}, optimalLineup{}})
+	if err != nil {
+		t.Fatalf("GetPowerData returned unexpected error: %s\n", err)
+	}
+	if len(data) != 2 {
+		t.Fatalf("Unexpected number of schemes returned: %d", len(data))
+	}
+	for _, powerData := range data {
+		if powerData.ByTeam["a"].ManagerEfficiency != 0.25 ||
+			powerData.ByTeam["b"].ManagerEfficiency != 1.0 {
+			t.Fatalf("Incorrect manager efficiency for scheme %s: a=%f, b=%f",
+				powerData.RankingScheme.ID(),
+				powerData.ByTeam["a"].ManagerEfficiency,
+				powerData.ByTeam["b"].ManagerEfficiency)
+		}
+	}
+
+	// Clients that can't get rosters skip the scheme
+	data, err = GetPowerDataForSchemes(
+		context.Background(),
+		m.mockClient,
+		league,
+		1,
+		[]Scheme{allPlayRecord{}, optimalLineup{}})
+	if err != nil {
+		t.Fatalf("GetPowerData returned unexpected error: %s\n", err)
+	}
+	if len(data) != 1 || data[0].ByTeam["a"].ManagerEfficiency != 0.0 {
+		t.Fatalf("Optimal lineup scheme not skipped for client without rosters")
+	}
+}
+
+func TestAddManagerEfficiencyMissingWeek(t *testing.T) {
+	// Team 'a' has no roster stats for the second week
+	teamData := &TeamPowerData{
+		AllScores: []*TeamScoreData{
+			&TeamScoreData{FantasyScore: 5.0, PowerScore: 10.0},
+			nil,
+		},
+	}
+	powerData := &LeaguePowerData{
+		RankingScheme: optimalLineup{},
+		ByTeam:        map[string]*TeamPowerData{"a": teamData},
+	}
+
+	addManagerEfficiency([]*LeaguePowerData{powerData})
+
+	if teamData.ManagerEfficiency != 0.5 {
+		t.Fatalf("Unexpected manager efficiency with a missing week: %f",
+			teamData.ManagerEfficiency)
+	}
+}
+
+func TestPythagoreanSeasonRankings(t *testing.T) {
+	teams := []goff.Team{
+		goff.Team{TeamKey: "a"},
+		goff.Team{TeamKey: "b"},
+		goff.Team{TeamKey: "c"},
+	}
+	allMatchups := map[int][]goff.Matchup{
+		1: []goff.Matchup{
+			goff.Matchup{
+				Teams: []goff.Team{
+					goff.Team{TeamKey: "a", TeamPoints: goff.Points{Total: 10.0}},
+					goff.Team{TeamKey: "b", TeamPoints: goff.Points{Total: 5.0}},
+				},
+			},
+		},
+		2: []goff.Matchup{
+			goff.Matchup{
+				Teams: []goff.Team{
+					goff.Team{TeamKey: "a", TeamPoints: goff.Points{Total: 6.0}},
+					goff.Team{TeamKey: "b", TeamPoints: goff.Points{Total: 12.0}},
+				},
+			},
+		},
+	}
+	scheme := pythagorean{exponent: 2.0}
+	season := &Season{
+		Teams:       teams,
+		Matchups:    allMatchups,
+		CurrentWeek: 2,
+		EndWeek:     2,
+	}
+
+	var state SchemeState
+	var ranking *WeeklyRanking
+	for week := 1; week <= 2; week++ {
+		ranking, state = scheme.CalculateSeasonRankings(week, season, state)
+	}
+
+	// a: 16 for, 17 against. b: 17 for, 16 against. c: no games played
+	expected := []struct {
+		TeamKey     string
+		Expectation float64
+		Rank        int
+	}{
+		{"b", 289.0 / (289.0 + 256.0), 1},
+		{"c", 0.5, 2},
+		{"a", 256.0 / (256.0 + 289.0), 3},
+	}
+	for i, expectedTeam := range expected {
+		actual := ranking.Rankings[i]
+		total := ranking.Standings[expectedTeam.TeamKey].Score
+		if actual.Team.TeamKey != expectedTeam.TeamKey ||
+			math.Abs(total-expectedTeam.Expectation) > 0.000001 ||
+			actual.Rank != expectedTeam.Rank {
+			t.Fatalf("Unexpected pythagorean ranking %d:\n\t"+
+				"Expected: team=%s, expectation=%f, rank=%d\n\t"+
+				"Actual: team=%s, expectation=%f, rank=%d",
+				i+1,
+				expectedTeam.TeamKey,
+				expectedTeam.Expectation,
+				expectedTeam.Rank,
+				actual.Team.TeamKey,
+				total,
+				actual.Rank)
+		}
+	}
+}
+
+func TestCategoryAllPlayRankings(t *testing.T) {
+	categories, statLines := mockCategoryStats()
+	ranking := categoryAllPlay{}.CalculateCategoryRankings(
+		1,
+		categories,
+		statLines,
+		false)
+
+	expected := []struct {
+		TeamKey string
+		Record  goff.Record
+		Rank    int
+	}{
+		{"a", goff.Record{Wins: 2, Losses: 1, Ties: 1}, 1},
+		{"b", goff.Record{Wins: 2, Losses: 2, Ties: 0}, 2},
+		{"c", goff.Record{Wins: 1, Losses: 2, Ties: 1}, 3},
+		{"d", goff.Record{}, 4},
+	}
+	for i, expectedTeam := range expected {
+		actual := ranking.Rankings[i]
+		if actual.Team.TeamKey != expectedTeam.TeamKey ||
+			*actual.Record != expectedTeam.Record ||
+			actual.Rank != expectedTeam.Rank {
+			t.Fatalf("Unexpected category all-play ranking %d:\n\t"+
+				"Expected: team=%s, record=%+v, rank=%d\n\t"+
+				"Actual: team=%s, record=%+v, rank=%d",
+				i,
+				expectedTeam.TeamKey,
+				expectedTeam.Record,
+				expectedTeam.Rank,
+				actual.Team.TeamKey,
+				*actual.Record,
+				actual.Rank)
+		}
+	}
+}
+
+func TestRotoPointsRankings(t *testing.T) {
+	categories, statLines := mockCategoryStats()
+	ranking := rotoPoints{}.CalculateCategoryRankings(
+		1,
+		categories,
+		statLines,
+		false)
+
+	// R: a and c tie for first, b is last. ERA: b, a, then c
+	expected := []struct {
+		TeamKey    string
+		PowerScore float64
+		Rank       int
+	}{
+		{"a", 4.5, 1},
+		{"b", 4.0, 2},
+		{"c", 3.5, 3},
+		{"d", 0.0, 4},
+	}
+	for i, expectedTeam := range expected {
+		actual := ranking.Rankings[i]
+		if actual.Team.TeamKey != expectedTeam.TeamKey ||
+			actual.PowerScore != expectedTeam.PowerScore ||
+			actual.Rank != expectedTeam.Rank {
+			t.Fatalf("Unexpected roto points ranking %d:\n\t"+
+				"Expected: team=%s, score=%f, rank=%d\n\t"+
+				"Actual: team=%s, score=%f, rank=%d",
+				i,
+				expectedTeam.TeamKey,
+				expectedTeam.PowerScore,
+				expectedTeam.Rank,
+				actual.Team.TeamKey,
+				actual.PowerScore,
+				actual.Rank)
+		}
+	}
+}
+
+func TestGetPowerDataCategoryLeagueUnsupported(t *testing.T) {
+	league := &goff.League{
+		LeagueKey: "leagueID",
+		EndWeek:   2,
+		Settings:  goff.Settings{ScoringType: "head"},
+	}
+	m := mockClient{
+		WeekErrors:      map[int]error{},
+		StandingsLeague: league,
+	}
+	_, err := GetPowerData(m, league, 1)
+	if err != ErrCategoryLeague {
+		t.Fatalf("GetPowerData returned unexpected error for category league"+
+			"\n\tExpected: %s\n\tActual: %v",
+			ErrCategoryLeague,
+			err)
+	}
+}
+
+func TestGetPowerDataCategoryLeague(t *testing.T) {
+	categories, statLines := mockCategoryStats()
+	league := &goff.League{
+		LeagueKey: "leagueID",
+		EndWeek:   2,
+		Settings:  goff.Settings{ScoringType: "roto"},
+	}
+	for _, statLine := range statLines {
+		league.Standings = append(league.Standings, statLine.Team)
+	}
+	m := mockCategoryClient{
+		mockClient: mockClient{
+			WeekErrors:      map[int]error{},
+			StandingsLeague: league,
+		},
+		Categories: categories,
+		StatLines:  map[int][]TeamStatLine{1: statLines},
+	}
+	data, err := GetPowerData(m, league, 1)
+	if err != nil {
+		t.Fatalf("GetPowerData returned unexpected error: %s\n", err)
+	}
+	if len(data) != len(GetCategorySchemes()) {
+		t.Fatalf("GetPowerData returned data for unexpected schemes: %+v", data)
+	}
+
+	for _, powerData := range data {
+		if powerData.RankingScheme.ID() != (rotoPoints{}).ID() {
+			continue
+		}
+		rankings := powerData.OverallRankings
+		if len(rankings) != 4 ||
+			rankings[0].Team.TeamKey != "a" ||
+			rankings[0].TotalScore != 4.5 ||
+			rankings[0].ProjectedTotalScore != 4.5 {
+			t.Fatalf("GetPowerData returned incorrect roto rankings.\n"+
+				"\trankings: %+v",
+				rankings)
+		}
+		return
+	}
+	t.Fatal("GetPowerData did not return roto points data")
+}
+
+func TestGetSport(t *testing.T) {
+	tests := []struct {
+		URL   string
+		Sport string
+	}{
+		{"https://football.fantasysports.yahoo.com/f1/1234", Sports.FOOTBALL},
+		{"https://baseball.fantasysports.yahoo.com/b1/1234", Sports.BASEBALL},
+		{"https://basketball.fantasysports.yahoo.com/nba/1234", Sports.BASKETBALL},
+		{"https://hockey.fantasysports.yahoo.com/hockey/1234", Sports.HOCKEY},
+		{"", Sports.FOOTBALL},
+	}
+	for _, test := range tests {
+		sport := GetSport(&goff.League{URL: test.URL})
+		if sport != test.Sport {
+			t.Fatalf("Unexpected sport for league URL '%s'\n\t"+
+				"Expected: %s\n\tActual: %s",
+				test.URL,
+				test.Sport,
+				sport)
+		}
+	}
+}
+
+func TestSeasonCalendarCompletedPeriods(t *testing.T) {
+	tests := []struct {
+		League    goff.League
+		Completed int
+	}{
+		{goff.League{StartWeek: 1, EndWeek: 16, CurrentWeek: 5}, 4},
+		{goff.League{StartWeek: 3, EndWeek: 23, CurrentWeek: 5}, 2},
+		{goff.League{StartWeek: 3, EndWeek: 23, CurrentWeek: 3}, 0},
+		{goff.League{StartWeek: 3, EndWeek: 23, CurrentWeek: 1}, 0},
+		{goff.League{StartWeek: 3, EndWeek: 23, CurrentWeek: 23, IsFinished: true}, 21},
+	}
+	for _, test := range tests {
+		completed := GetSeasonCalendar(&test.League).CompletedPeriods(&test.League)
+		if completed != test.Completed {
+			t.Fatalf("Unexpected completed periods for league %+v\n\t"+
+				"Expected: %d\n\tActual: %d",
+				test.League,
+				test.Completed,
+				completed)
+		}
+	}
+}
+
+func TestSeasonCalendarBreakWeeks(t *testing.T) {
+	calendar := &SeasonCalendar{
+		Sport:      Sports.HOCKEY,
+		StartWeek:  2,
+		EndWeek:    8,
+		BreakWeeks: []int{5},
+	}
+	if periods := calendar.Periods(); periods != 6 {
+		t.Fatalf("Unexpected periods with a break week\n\t"+
+			"Expected: %d\n\tActual: %d",
+			6,
+			periods)
+	}
+
+	// The break week is part of the period after it
+	periods := map[int]int{2: 1, 4: 3, 5: 4, 6: 4, 8: 6}
+	for week, expected := range periods {
+		if period := calendar.Period(week); period != expected {
+			t.Fatalf("Unexpected period of week %d\n\t"+
+				"Expected: %d\n\tActual: %d",
+				week,
+				expected,
+				period)
+		}
+	}
+	weeks := map[int]int{1: 2, 3: 4, 4: 6, 6: 8}
+	for period, expected := range weeks {
+		if week := calendar.ProviderWeek(period); week != expected {
+			t.Fatalf("Unexpected week of period %d\n\t"+
+				"Expected: %d\n\tActual: %d",
+				period,
+				expected,
+				week)
+		}
+	}
+
+	league := &goff.League{StartWeek: 2, EndWeek: 8, CurrentWeek: 6}
+	if completed := calendar.CompletedPeriods(league); completed != 3 {
+		t.Fatalf("Unexpected completed periods after a break week\n\t"+
+			"Expected: %d\n\tActual: %d",
+			3,
+			completed)
+	}
+}
+
+func TestFindSeasonCalendar(t *testing.T) {
+	matchup := goff.Matchup{
+		Teams: []goff.Team{goff.Team{TeamKey: "a"}, goff.Team{TeamKey: "b"}},
+	}
+	m := mockClient{
+		Matchups: map[int][]goff.Matchup{
+			2: []goff.Matchup{matchup},
+			3: []goff.Matchup{matchup},
+			5: []goff.Matchup{matchup},
+		},
+	}
+	league := &goff.League{
+		LeagueKey: "leagueID",
+		URL:       "https://hockey.fantasysports.yahoo.com/hockey/1234",
+		StartWeek: 2,
+		EndWeek:   7,
+	}
+	calendar, err := FindSeasonCalendar(m, league)
+	if err != nil {
+		t.Fatalf("FindSeasonCalendar returned unexpected error: %s", err)
+	}
+
+	// Weeks after the last matchup haven't been scheduled yet
+	if len(calendar.BreakWeeks) != 1 || calendar.BreakWeeks[0] != 4 {
+		t.Fatalf("Unexpected break weeks\n\tExpected: %v\n\tActual: %v",
+			[]int{4},
+			calendar.BreakWeeks)
+	}
+
+	// Football leagues don't have breaks, so matchups aren't requested
+	m.MatchupsError = errors.New("matchups requested")
+	league.URL = "https://football.fantasysports.yahoo.com/f1/1234"
+	calendar, err = FindSeasonCalendar(m, league)
+	if err != nil {
+		t.Fatalf("FindSeasonCalendar requested matchups of a football league: %s", err)
+	}
+	if len(calendar.BreakWeeks) > 0 {
+		t.Fatalf("Unexpected break weeks of a football league: %v", calendar.BreakWeeks)
+	}
+
+	league.URL = "https://hockey.fantasysports.yahoo.com/hockey/1234"
+	if _, err = FindSeasonCalendar(m, league); err == nil {
+		t.Fatalf("FindSeasonCalendar did not return the error getting matchups")
+	}
+}
+
+func TestGetPowerDataAllStarBreak(t *testing.T) {
+	league := &goff.League{
+		LeagueKey: "leagueID",
+		URL:       "https://hockey.fantasysports.yahoo.com/hockey/1234",
+		StartWeek: 1,
+		EndWeek:   3,
+	}
+	matchup := func(week int, firstPoints, secondPoints float64) goff.Matchup {
+		return goff.Matchup{
+			Week: week,
+			Teams: []goff.Team{
+				goff.Team{TeamKey: "a", TeamPoints: goff.Points{Total: firstPoints}},
+				goff.Team{TeamKey: "b", TeamPoints: goff.Points{Total: secondPoints}},
+			},
+		}
+	}
+	m := mockClient{
+		WeekStats: map[int][]goff.Team{
+			1: matchup(1, 4.0, 3.0).Teams,
+			3: matchup(3, 2.0, 6.0).Teams,
+		},
+		Matchups: map[int][]goff.Matchup{
+			1: []goff.Matchup{matchup(1, 4.0, 3.0)},
+			3: []goff.Matchup{matchup(3, 2.0, 6.0)},
+		},
+		WeekErrors: map[int]error{
+			2: errors.New("all-star break"),
+		},
+		StandingsLeague: league,
+	}
+	data, err := GetPowerDataForSchemes(context.Background(), m, league, 2, []Scheme{totalPoints{}, eloRating{kFactor: 32.0}})
+	if err != nil {
+		t.Fatalf("GetPowerData returned unexpected error: %s\n", err)
+	}
+
+	for _, powerData := range data {
+		rankings := powerData.OverallRankings
+		if len(rankings) != 2 || len(rankings[0].AllScores) != 2 {
+			t.Fatalf("GetPowerData returned incorrect periods for scheme %s.\n"+
+				"\trankings: %+v",
+				powerData.RankingScheme.ID(),
+				rankings)
+		}
+		if rankings[0].Team.TeamKey != "b" {
+			t.Fatalf("GetPowerData returned incorrect rankings for scheme %s.\n"+
+				"\trankings: %+v",
+				powerData.RankingScheme.ID(),
+				rankings)
+		}
+	}
+}
+
+func TestGetPowerDataLateStartWeek(t *testing.T) {
+	league := &goff.League{
+		LeagueKey: "leagueID",
+		URL:       "https://basketball.fantasysports.yahoo.com/nba/1234",
+		StartWeek: 3,
+		EndWeek:   4,
+	}
+	matchup := func(week int, firstPoints, secondPoints float64) goff.Matchup {
+		return goff.Matchup{
+			Week: week,
+			Teams: []goff.Team{
+				goff.Team{TeamKey: "a", TeamPoints: goff.Points{Total: firstPoints}},
+				goff.Team{TeamKey: "b", TeamPoints: goff.Points{Total: secondPoints}},
+			},
+		}
+	}
+	m := mockClient{
+		WeekStats: map[int][]goff.Team{
+			3: matchup(3, 4.0, 3.0).Teams,
+			4: matchup(4, 2.0, 6.0).Teams,
+		},
+		Matchups: map[int][]goff.Matchup{
+			3: []goff.Matchup{matchup(3, 4.0, 3.0)},
+			4: []goff.Matchup{matchup(4, 2.0, 6.0)},
+		},
+		WeekErrors: map[int]error{
+			1: errors.New("week before the league started"),
+			2: errors.New("week before the league started"),
+		},
+		StandingsLeague: league,
+	}
+	data, err := GetPowerDataForSchemes(context.Background(), m, league, 2, []Scheme{totalPoints{}, eloRating{kFactor: 32.0}})
+	if err != nil {
+		t.Fatalf("GetPowerData returned unexpected error: %s\n", err)
+	}
+
+	for _, powerData := range data {
+		rankings := powerData.OverallRankings
+		if len(rankings) != 2 || len(rankings[0].AllScores) != 2 {
+			t.Fatalf("GetPowerData returned incorrect periods for scheme %s.\n"+
+				"\trankings: %+v",
+				powerData.RankingScheme.ID(),
+				rankings)
+		}
+		if rankings[0].Team.TeamKey != "b" {
+			t.Fatalf("GetPowerData returned incorrect rankings for scheme %s.\n"+
+				"\trankings: %+v",
+				powerData.RankingScheme.ID(),
+				rankings)
+		}
+	}
+}
+
+func TestGetPowerDataErrorCancelsRequests(t *testing.T) {
+	defer func(max int) { MaxConcurrentRequests = max }(MaxConcurrentRequests)
+	MaxConcurrentRequests = 1
+	goroutines := runtime.NumGoroutine()
+
+	league := &goff.League{
+		LeagueKey: "leagueID",
+		EndWeek:   5,
+	}
+	calls := int32(0)
+	m := mockCountingClient{
+		mockClient: mockClient{
+			WeekErrors: map[int]error{
+				1: errors.New("error"),
+				2: errors.New("error"),
+				3: errors.New("error"),
+				4: errors.New("error"),
+				5: errors.New("error"),
+			},
+			StandingsLeague: league,
+		},
+		Calls: &calls,
+	}
+	_, err := GetPowerDataForSchemes(context.Background(), m, league, 0, []Scheme{totalPoints{}})
+	if err == nil || err.Error() != "error" {
+		t.Fatalf("GetPowerData returned unexpected error: %v", err)
+	}
+	if calls != 1 {
+		t.Fatalf("GetPowerData made requests after an error\n\t"+
+			"Expected: 1\n\tActual: %d",
+			calls)
+	}
+	assertNoGoroutinesRemain(t, goroutines)
+}
+
+func TestGetPowerDataCancelledContext(t *testing.T) {
+	goroutines := runtime.NumGoroutine()
+	league := &goff.League{
+		LeagueKey: "leagueID",
+		EndWeek:   5,
+	}
+	calls := int32(0)
+	m := mockCountingClient{
+		mockClient: mockClient{
+			WeekErrors:      map[int]error{},
+			StandingsLeague: league,
+		},
+		Calls: &calls,
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := GetPowerDataForSchemes(ctx, m, league, 0, []Scheme{totalPoints{}})
+	if err != context.Canceled {
+		t.Fatalf("GetPowerData returned unexpected error for cancelled context"+
+			"\n\tExpected: %s\n\tActual: %v",
+			context.Canceled,
+			err)
+	}
+	if calls != 0 {
+		t.Fatalf("GetPowerData made %d requests with a cancelled context", calls)
+	}
+	assertNoGoroutinesRemain(t, goroutines)
+}
+
+func TestGetPowerDataCancelledDuringRequest(t *testing.T) {
+	goroutines := runtime.NumGoroutine()
+	league := &goff.League{
+		LeagueKey: "leagueID",
+		EndWeek:   5,
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	calls := int32(0)
+	m := mockCancellingClient{
+		mockCountingClient: mockCountingClient{
+			mockClient: mockClient{
+				WeekErrors:      map[int]error{},
+				StandingsLeague: league,
+			},
+			Calls: &calls,
+		},
+		Cancel: cancel,
+	}
+	_, err := GetPowerDataForSchemes(ctx, m, league, 0, GetSchemes())
+	if err != context.Canceled {
+		t.Fatalf("GetPowerData returned unexpected error for cancelled context"+
+			"\n\tExpected: %s\n\tActual: %v",
+			context.Canceled,
+			err)
+	}
+	if calls != 0 {
+		t.Fatalf("GetPowerData made %d requests after the context was cancelled", calls)
+	}
+	assertNoGoroutinesRemain(t, goroutines)
+}
+
+func TestGetPowerDataAllErrors(t *testing.T) {
+	defer func(max int) { MaxConcurrentRequests = max }(MaxConcurrentRequests)
+	MaxConcurrentRequests = 3
+	goroutines := runtime.NumGoroutine()
+
+	league := &goff.League{
+		LeagueKey: "leagueID",
+		EndWeek:   3,
+	}
+	errDenied := errors.New("denied")
+	calls := int32(0)
+
+	// Every request waits until all of them have started, so none are skipped
+	barrier := &sync.WaitGroup{}
+	barrier.Add(3)
+	m := mockCountingClient{
+		mockClient: mockClient{
+			WeekErrors: map[int]error{
+				1: errors.New("error"),
+				2: errDenied,
+				3: errors.New("error"),
+			},
+			StandingsLeague: league,
+		},
+		Calls:   &calls,
+		Barrier: barrier,
+	}
+	_, err := GetPowerDataForSchemes(context.Background(), m, league, 0, []Scheme{totalPoints{}})
+	powerDataErrors, ok := err.(PowerDataErrors)
+	if !ok || len(powerDataErrors) != 3 {
+		t.Fatalf("GetPowerData did not return every error: %v", err)
+	}
+	if !errors.Is(err, errDenied) {
+		t.Fatalf("GetPowerData errors did not include '%s': %v", errDenied, err)
+	}
+	assertNoGoroutinesRemain(t, goroutines)
+}
+
+func TestGetSeasonSchemeWeeklyAdapter(t *testing.T) {
+	teams := []goff.Team{
+		goff.Team{TeamKey: "a", Name: "A"},
+		goff.Team{TeamKey: "b", Name: "B"},
+	}
+	matchup := func(firstPoints, secondPoints float64) []goff.Matchup {
+		return []goff.Matchup{
+			goff.Matchup{
+				Teams: []goff.Team{
+					goff.Team{TeamKey: "a", TeamPoints: goff.Points{Total: firstPoints}},
+					goff.Team{TeamKey: "b", TeamPoints: goff.Points{Total: secondPoints}},
+				},
+			},
+		}
+	}
+	season := &Season{
+		Teams:       teams,
+		Matchups:    map[int][]goff.Matchup{1: matchup(10.0, 4.0), 2: matchup(3.0, 8.0)},
+		CurrentWeek: 2,
+		EndWeek:     2,
+	}
+
+	scheme := GetSeasonScheme(totalPoints{})
+	var state SchemeState
+	var ranking *WeeklyRanking
+	for week := 1; week <= 2; week++ {
+		ranking, state = scheme.CalculateSeasonRankings(week, season, state)
+	}
+
+	// Weekly results are summed, so a leads b 13 to 12
+	standings := ranking.Standings
+	if standings["a"].Score != 13.0 || standings["a"].Rank != 1 ||
+		standings["b"].Score != 12.0 || standings["b"].Rank != 2 {
+		t.Fatalf("Unexpected standings from adapted scheme: a=%+v, b=%+v",
+			standings["a"],
+			standings["b"])
+	}
+	if ranking.Rankings[0].Team.TeamKey != "b" {
+		t.Fatalf("Weekly ranking was not for the week alone: %+v",
+			ranking.Rankings[0])
+	}
+}
+
+func TestGetPowerDataSeasonSchemeRanks(t *testing.T) {
+	league := &goff.League{
+		LeagueKey: "leagueID",
+		EndWeek:   2,
+	}
+	matchup := goff.Matchup{
+		Teams: []goff.Team{
+			goff.Team{TeamKey: "a", Name: "A", TeamPoints: goff.Points{Total: 4.0}},
+			goff.Team{TeamKey: "b", Name: "B", TeamPoints: goff.Points{Total: 3.0}},
+		},
+	}
+	m := mockClient{
+		Matchups: map[int][]goff.Matchup{
+			1: []goff.Matchup{matchup},
+			2: []goff.Matchup{matchup},
+		},
+		WeekErrors:      map[int]error{},
+		StandingsLeague: league,
+	}
+	data, err := GetPowerDataForSchemes(
+		context.Background(),
+		m,
+		league,
+		1,
+		[]Scheme{mockSeasonScheme{}})
+	if err != nil {
+		t.Fatalf("GetPowerData returned unexpected error: %s\n", err)
+	}
+
+	// Both teams have the same score, but the scheme breaks the tie
+	rankings := data[0].OverallRankings
+	if rankings[0].Team.TeamKey != "b" || rankings[0].Rank != 1 ||
+		rankings[1].Team.TeamKey != "a" || rankings[1].Rank != 2 ||
+		rankings[0].TotalScore != 1.0 || rankings[0].ProjectedTotalScore != 2.0 {
+		t.Fatalf("GetPowerData did not use the ranks of the season scheme: "+
+			"first=%+v, second=%+v",
+			rankings[0],
+			rankings[1])
+	}
+}
+
+func TestGetPowerDataEloRating(t *testing.T) {
+	league := &goff.League{
+		LeagueKey: "leagueID",
+		EndWeek:   2,
+	}
+	matchup := goff.Matchup{
+		Teams: []goff.Team{
+			goff.Team{TeamKey: "a", TeamPoints: goff.Points{Total: 4.0}},
+			goff.Team{TeamKey: "b", TeamPoints: goff.Points{Total: 3.0}},
+		},
+	}
+	m := mockClient{
+		Matchups: map[int][]goff.Matchup{
+			1: []goff.Matchup{matchup},
+			2: []goff.Matchup{matchup},
+		},
+		WeekErrors:      map[int]error{},
+		StandingsLeague: league,
+	}
+	data, err := GetPowerData(m, league, 2)
+	if err != nil {
+		t.Fatalf("GetPowerData returned unexpected error: %s\n", err)
+	}
+
+	var eloData *LeaguePowerData
+	for _, powerData := range data {
+		if powerData.RankingScheme.ID() == "elo" {
+			eloData = powerData
+		}
+	}
+	if eloData == nil {
+		t.Fatal("GetPowerData did not return Elo rating data")
+	}
+
+	rankings := eloData.OverallRankings
+	if len(rankings) != 2 ||
+		rankings[0].Team.TeamKey != "a" ||
+		rankings[0].TotalScore <= EloInitialRating ||
+		rankings[1].TotalScore >= EloInitialRating {
+		t.Fatalf("GetPowerData returned incorrect Elo rankings.\n"+
+			"\trankings: %+v",
+			rankings)
+	}
+}
+
+func TestGetPowerDataOverallRankings(t *testing.T) {
+	league := &goff.League{
+		LeagueKey: "leagueID",
+		EndWeek:   3,
+	}
+	m := mockClient{
+		Matchups: map[int][]goff.Matchup{
+			// Week 1
+			1: []goff.Matchup{
+				goff.Matchup{
+					Teams: []goff.Team{
+						goff.Team{TeamKey: "a", TeamPoints: goff.Points{Total: 4.0}},
+						goff.Team{TeamKey: "b", TeamPoints: goff.Points{Total: 3.0}},
+					},
+				},
+				goff.Matchup{
+					Teams: []goff.Team{
+						goff.Team{TeamKey: "c", TeamPoints: goff.Points{Total: 2.0}},
+						goff.Team{TeamKey: "d", TeamPoints: goff.Points{Total: 1.0}},
+					},
+				},
+			},
+			2: []goff.Matchup{
+				goff.Matchup{
+					Teams: []goff.Team{
+						goff.Team{TeamKey: "a", TeamPoints: goff.Points{Total: 4.0}},
+						goff.Team{TeamKey: "b", TeamPoints: goff.Points{Total: 3.0}},
+					},
+				},
+				goff.Matchup{
+					Teams: []goff.Team{
+						goff.Team{TeamKey: "c", TeamPoints: goff.Points{Total: 2.0}},
+						goff.Team{TeamKey: "d", TeamPoints: goff.Points{Total: 1.0}},
+					},
+				},
+			},
+			3: []goff.Matchup{
+				goff.Matchup{
+					Teams: []goff.Team{
+						goff.Team{TeamKey: "a", TeamPoints: goff.Points{Total: 4.0}},
+						goff.Team{TeamKey: "b", TeamPoints: goff.Points{Total: 3.0}},
+					},
+				},
+				goff.Matchup{
+					Teams: []goff.Team{
+						goff.Team{TeamKey: "c", TeamPoints: goff.Points{Total: 2.0}},
+						goff.Team{TeamKey: "d", TeamPoints: goff.Points{Total: 1.0}},
+					},
+				},
+			},
+		},
+		WeekErrors:      map[int]error{},
+		StandingsLeague: league,
+	}
+	data, err := GetPowerData(m, league, 3)
+
+	if err != nil {
+		t.Fatalf("GetPowerData returned unexpected error: %s\n", err)
+	}
+
+	allPlayData := data[0]
+	rankings := allPlayData.OverallRankings
+	if rankings[0].Team.TeamKey != "a" ||
+		rankings[1].Team.TeamKey != "b" ||
+		rankings[2].Team.TeamKey != "c" ||
+		rankings[3].Team.TeamKey != "d" {
+		t.Fatalf("GetPowerData returned incorrect rankings.\n"+
+			"\trankings: %+v",
+			rankings)
+	}
+
+	if rankings[0].OverallRecord.Wins != 9 ||
+		rankings[1].OverallRecord.Wins != 6 ||
+		rankings[2].OverallRecord.Wins != 3 ||
+		rankings[3].OverallRecord.Wins != 0 {
+		t.Fatalf("GetPowerData returned incorrect scores.\n"+
+			"\trankings: %+v",
+			rankings)
+	}
+
+	if rankings[0].Rank != 1 ||
+		rankings[1].Rank != 2 ||
+		rankings[2].Rank != 3 ||
+		rankings[3].Rank != 4 {
+		t.Fatalf("GetPowerData returned incorrect ranks.\n"+
+			"\trankings: %+v",
+			rankings)
+	}
+}
+
+func TestGetProjectedPowerDataOverallRankings(t *testing.T) {
+	league := &goff.League{
+		LeagueKey: "leagueID",
+		EndWeek:   3,
+		Standings: []goff.Team{
+			goff.Team{
+				TeamKey: "a",
+				TeamStandings: goff.TeamStandings{
+					Rank: 1,
+					Record: goff.Record{
+						Wins:   5,
+						Losses: 2,
+						Ties:   0,
+					},
+					PointsFor:     12345.0,
+					PointsAgainst: 54321.0,
+				},
+			},
+			goff.Team{
+				TeamKey: "b",
+				TeamStandings: goff.TeamStandings{
+					Rank: 2,
+					Record: goff.Record{
+						Wins:   3,
+						Losses: 3,
+						Ties:   1,
+					},
+					PointsFor:     1234.0,
+					PointsAgainst: 4321.0,
+				},
+			},
+			goff.Team{
+				TeamKey: "c",
+				TeamStandings: goff.TeamStandings{
+					Rank: 3,
+					Record: goff.Record{
+						Wins:   0,
+						Losses: 6,
+						Ties:   1,
+					},
+					PointsFor:     234.0,
+					PointsAgainst: 321.0,
+				},
+			},
+		},
+	}
+	m := mockClient{
+		Matchups: map[int][]goff.Matchup{
+			1: []goff.Matchup{
+				goff.Matchup{
+					Teams: []goff.Team{
+						goff.Team{
 							TeamKey:             "a",
 							TeamPoints:          goff.Points{Total: 4.0},
 							TeamProjectedPoints: goff.Points{Total: 7.0},
@@ -430,199 +1734,1549 @@ func TestGetProjectedPowerDataOverallRankings(t *testing.T) {
 						},
 					},
 				},
-				goff.Matchup{
-					Teams: []goff.Team{
-						goff.Team{
-							TeamKey:             "c",
-							TeamPoints:          goff.Points{Total: 2.0},
-							TeamProjectedPoints: goff.Points{Total: 9.0},
-						},
-						goff.Team{
-							TeamKey:             "d",
-							TeamPoints:          goff.Points{Total: 1.0},
-							TeamProjectedPoints: goff.Points{Total: 10.0},
-						},
-					},
+				goff.Matchup{
+					Teams: []goff.Team{
+						goff.Team{
+							TeamKey:             "c",
+							TeamPoints:          goff.Points{Total: 2.0},
+							TeamProjectedPoints: goff.Points{Total: 9.0},
+						},
+						goff.Team{
+							TeamKey:             "d",
+							TeamPoints:          goff.Points{Total: 1.0},
+							TeamProjectedPoints: goff.Points{Total: 10.0},
+						},
+					},
+				},
+			},
+		},
+		WeekStats: map[int][]goff.Team{
+			2: []goff.Team{
+				goff.Team{
+					TeamKey:             "a",
+					TeamPoints:          goff.Points{Total: 4.0},
+					TeamProjectedPoints: goff.Points{Total: 7.0},
+				},
+				goff.Team{
+					TeamKey:             "b",
+					TeamPoints:          goff.Points{Total: 3.0},
+					TeamProjectedPoints: goff.Points{Total: 8.0},
+				},
+				goff.Team{
+					TeamKey:             "c",
+					TeamPoints:          goff.Points{Total: 2.0},
+					TeamProjectedPoints: goff.Points{Total: 9.0},
+				},
+				goff.Team{
+					TeamKey:             "d",
+					TeamPoints:          goff.Points{Total: 1.0},
+					TeamProjectedPoints: goff.Points{Total: 10.0},
+				},
+			},
+			3: []goff.Team{
+				goff.Team{
+					TeamKey:             "a",
+					TeamPoints:          goff.Points{Total: 4.0},
+					TeamProjectedPoints: goff.Points{Total: 7.0},
+				},
+				goff.Team{
+					TeamKey:             "b",
+					TeamPoints:          goff.Points{Total: 3.0},
+					TeamProjectedPoints: goff.Points{Total: 8.0},
+				},
+				goff.Team{
+					TeamKey:             "c",
+					TeamPoints:          goff.Points{Total: 2.0},
+					TeamProjectedPoints: goff.Points{Total: 9.0},
+				},
+				goff.Team{
+					TeamKey:             "d",
+					TeamPoints:          goff.Points{Total: 1.0},
+					TeamProjectedPoints: goff.Points{Total: 10.0},
+				},
+			},
+		},
+		WeekErrors:      map[int]error{},
+		StandingsLeague: league,
+	}
+	data, err := GetPowerData(m, league, 1)
+
+	if err != nil {
+		t.Fatalf("GetPowerData returned unexpected error: %s\n", err)
+	}
+
+	allPlayData := data[0]
+	rankings := allPlayData.ProjectedRankings
+	if rankings[0].Team.TeamKey != "d" ||
+		rankings[1].Team.TeamKey != "c" ||
+		rankings[2].Team.TeamKey != "b" ||
+		rankings[3].Team.TeamKey != "a" {
+		t.Fatalf("GetPowerData returned incorrect rankings.\n"+
+			"\trankings: %+v\n",
+			rankings)
+	}
+
+	if rankings[0].ProjectedOverallRecord.Wins != 6 ||
+		rankings[1].ProjectedOverallRecord.Wins != 5 ||
+		rankings[2].ProjectedOverallRecord.Wins != 4 ||
+		rankings[3].ProjectedOverallRecord.Wins != 3 {
+		t.Fatalf("GetPowerData returned incorrect scores.\n"+
+			"\trankings: %+v\n",
+			rankings)
+	}
+
+	if rankings[0].ProjectedRank != 1 ||
+		rankings[1].ProjectedRank != 2 ||
+		rankings[2].ProjectedRank != 3 ||
+		rankings[3].ProjectedRank != 4 {
+		t.Fatalf("GetPowerData returned incorrect ranks.\n"+
+			"\trankings: %d, %d, %d, %d\n",
+			rankings[0].ProjectedRank,
+			rankings[1].ProjectedRank,
+			rankings[2].ProjectedRank,
+			rankings[3].ProjectedRank)
+	}
+}
+
+func TestGetPowerDataClientError(t *testing.T) {
+	league := &goff.League{
+		LeagueKey: "leagueID",
+		EndWeek:   3,
+	}
+	m := mockClient{
+		MatchupsError: errors.New("error"),
+		WeekStats: map[int][]goff.Team{
+			// Week 1
+			1: []goff.Team{
+				goff.Team{TeamKey: "a", TeamPoints: goff.Points{Total: 4.0}},
+				goff.Team{TeamKey: "b", TeamPoints: goff.Points{Total: 3.0}},
+				goff.Team{TeamKey: "c", TeamPoints: goff.Points{Total: 2.0}},
+				goff.Team{TeamKey: "d", TeamPoints: goff.Points{Total: 1.0}},
+			},
+			3: []goff.Team{
+				goff.Team{TeamKey: "a", TeamPoints: goff.Points{Total: 4.0}},
+				goff.Team{TeamKey: "b", TeamPoints: goff.Points{Total: 3.0}},
+				goff.Team{TeamKey: "c", TeamPoints: goff.Points{Total: 2.0}},
+				goff.Team{TeamKey: "d", TeamPoints: goff.Points{Total: 1.0}},
+			},
+		},
+		WeekErrors: map[int]error{
+			2: errors.New("error"),
+		},
+		StandingsLeague: league,
+	}
+	data, err := GetPowerData(m, league, 3)
+	if err == nil {
+		t.Fatalf("GetPowerData did not return error\n\tdata: %+v\n", data)
+	}
+}
+
+func TestGetPowerDataTies(t *testing.T) {
+	league := &goff.League{
+		LeagueKey: "leagueID",
+		EndWeek:   4,
+		Settings: goff.Settings{
+			UsesPlayoff:      true,
+			PlayoffStartWeek: 1,
+		},
+	}
+	m := mockClient{
+		// Teams a/b will be tied after 4 weeks
+		WeekStats: map[int][]goff.Team{
+			// Week 1
+			1: []goff.Team{
+				goff.Team{TeamKey: "a", TeamPoints: goff.Points{Total: 4.0}},
+				goff.Team{TeamKey: "b", TeamPoints: goff.Points{Total: 5.0}},
+				goff.Team{TeamKey: "c", TeamPoints: goff.Points{Total: 2.0}},
+				goff.Team{TeamKey: "d", TeamPoints: goff.Points{Total: 1.0}},
+			},
+			2: []goff.Team{
+				goff.Team{TeamKey: "a", TeamPoints: goff.Points{Total: 5.0}},
+				goff.Team{TeamKey: "b", TeamPoints: goff.Points{Total: 4.0}},
+				goff.Team{TeamKey: "c", TeamPoints: goff.Points{Total: 2.0}},
+				goff.Team{TeamKey: "d", TeamPoints: goff.Points{Total: 1.0}},
+			},
+			3: []goff.Team{
+				goff.Team{TeamKey: "a", TeamPoints: goff.Points{Total: 4.0}},
+				goff.Team{TeamKey: "b", TeamPoints: goff.Points{Total: 5.0}},
+				goff.Team{TeamKey: "c", TeamPoints: goff.Points{Total: 2.0}},
+				goff.Team{TeamKey: "d", TeamPoints: goff.Points{Total: 1.0}},
+			},
+			4: []goff.Team{
+				goff.Team{TeamKey: "a", TeamPoints: goff.Points{Total: 5.0}},
+				goff.Team{TeamKey: "b", TeamPoints: goff.Points{Total: 4.0}},
+				goff.Team{TeamKey: "c", TeamPoints: goff.Points{Total: 2.0}},
+				goff.Team{TeamKey: "d", TeamPoints: goff.Points{Total: 1.0}},
+			},
+		},
+		WeekErrors:      map[int]error{},
+		StandingsLeague: league,
+	}
+	data, err := GetPowerData(m, league, 4)
+
+	if err != nil {
+		t.Fatalf("GetPowerData returned unexpected error: %s\n", err)
+	}
+
+	allPlayData := data[0]
+	rankings := allPlayData.OverallRankings
+	if rankings[0].Rank != 1 ||
+		rankings[1].Rank != 1 ||
+		rankings[2].Rank != 3 ||
+		rankings[3].Rank != 4 {
+		t.Fatalf("GetPowerData did not correctly rank teams after a tie\n"+
+			"\trankings: %+v\n",
+			rankings)
+	}
+}
+
+func TestGetPowerDataTieBreakers(t *testing.T) {
+	league := &goff.League{
+		LeagueKey: "leagueID",
+		EndWeek:   4,
+		Settings: goff.Settings{
+			UsesPlayoff:      true,
+			PlayoffStartWeek: 1,
+		},
+	}
+	weekStats := map[int][]goff.Team{}
+	for week := 1; week <= 4; week++ {
+		// Teams a/b will be tied after 4 weeks with the same points
+		aPoints, bPoints := 4.0, 5.0
+		if week%2 == 0 {
+			aPoints, bPoints = bPoints, aPoints
+		}
+		weekStats[week] = []goff.Team{
+			goff.Team{TeamKey: "a", Name: "A", TeamPoints: goff.Points{Total: aPoints}},
+			goff.Team{TeamKey: "b", Name: "B", TeamPoints: goff.Points{Total: bPoints}},
+			goff.Team{TeamKey: "c", Name: "C", TeamPoints: goff.Points{Total: 2.0}},
+			goff.Team{TeamKey: "d", Name: "D", TeamPoints: goff.Points{Total: 1.0}},
+		}
+	}
+	matchup := func(week int, first int, second int) goff.Matchup {
+		return goff.Matchup{
+			Week:  week,
+			Teams: []goff.Team{weekStats[week][first], weekStats[week][second]},
+		}
+	}
+	m := mockClient{
+		WeekStats: weekStats,
+		// b beats a in their only matchup
+		Matchups: map[int][]goff.Matchup{
+			1: []goff.Matchup{matchup(1, 0, 1), matchup(1, 2, 3)},
+			2: []goff.Matchup{matchup(2, 0, 2), matchup(2, 1, 3)},
+			3: []goff.Matchup{matchup(3, 0, 3), matchup(3, 1, 2)},
+			4: []goff.Matchup{matchup(4, 0, 2), matchup(4, 1, 3)},
+		},
+		WeekErrors:      map[int]error{},
+		StandingsLeague: league,
+	}
+
+	tests := []struct {
+		TieBreakers string
+		First       string
+		SecondRank  int
+	}{
+		{"points-for", "a", 1},
+		{"points-for,head-to-head,coin-flip", "b", 2},
+		{"all-play=coin-flip;head-to-head", "b", 2},
+	}
+	for _, test := range tests {
+		tieBreakers, err := ParseTieBreakers(test.TieBreakers)
+		if err != nil {
+			t.Fatalf("Unexpected error parsing tie-breakers: %s", err)
+		}
+		data, err := GetPowerDataWithOptions(
+			context.Background(),
+			m,
+			league,
+			4,
+			[]Scheme{totalPoints{}},
+			PowerDataOptions{TieBreakers: tieBreakers})
+		if err != nil {
+			t.Fatalf("GetPowerData returned unexpected error: %s\n", err)
+		}
+
+		overall := data[0].OverallRankings
+		projected := data[0].ProjectedRankings
+		if overall[0].Team.TeamKey != test.First ||
+			overall[0].Rank != 1 ||
+			overall[1].Rank != test.SecondRank ||
+			overall[2].Rank != 3 ||
+			(test.SecondRank == 2 && projected[0].Team.TeamKey != test.First) ||
+			projected[0].ProjectedRank != 1 ||
+			projected[1].ProjectedRank != test.SecondRank ||
+			projected[2].ProjectedRank != 3 {
+			t.Fatalf("GetPowerData did not break ties using '%s'\n"+
+				"\tfirst: %+v\n\tsecond: %+v",
+				test.TieBreakers,
+				overall[0],
+				overall[1])
+		}
+	}
+}
+
+func TestParseTieBreakers(t *testing.T) {
+	tieBreakers, err := ParseTieBreakers(
+		" total-points = head-to-head, coin-flip:7 ;points-for,all-play,coin-flip")
+	if err != nil {
+		t.Fatalf("Unexpected error parsing tie-breakers: %s", err)
+	}
+	expected := "points-for,all-play,coin-flip;total-points=head-to-head,coin-flip:7"
+	if tieBreakers.String() != expected {
+		t.Fatalf("Unexpected tie-breakers:\n\tExpected: %s\n\tActual: %s",
+			expected,
+			tieBreakers.String())
+	}
+	if tieBreakers.Chain("total-points").String() != "head-to-head,coin-flip:7" ||
+		tieBreakers.Chain("all-play").String() != "points-for,all-play,coin-flip" {
+		t.Fatalf("Unexpected tie-breakers chosen for schemes: %s", tieBreakers)
+	}
+
+	for _, invalid := range []string{
+		"coin",
+		"points-for:1",
+		"coin-flip:heads",
+		"unknown=coin-flip",
+		"points-for;coin-flip",
+	} {
+		tieBreakers, err := ParseTieBreakers(invalid)
+		if err == nil {
+			t.Fatalf("No error returned parsing invalid tie-breakers "+
+				"'%s': %s",
+				invalid,
+				tieBreakers)
+		}
+	}
+}
+
+func TestGetPowerDataRegularSeason(t *testing.T) {
+	league := &goff.League{
+		LeagueKey: "leagueID",
+		EndWeek:   4,
+		Settings: goff.Settings{
+			UsesPlayoff:      true,
+			PlayoffStartWeek: 3,
+		},
+	}
+	weekStats := map[int][]goff.Team{}
+	matchups := map[int][]goff.Matchup{}
+	for week := 1; week <= 4; week++ {
+		// Team b only wins in the playoffs
+		bPoints := 1.0
+		if week > 2 {
+			bPoints = 10.0
+		}
+		weekStats[week] = []goff.Team{
+			goff.Team{TeamKey: "a", Name: "A", TeamPoints: goff.Points{Total: 5.0}},
+			goff.Team{TeamKey: "b", Name: "B", TeamPoints: goff.Points{Total: bPoints}},
+		}
+		matchups[week] = []goff.Matchup{
+			goff.Matchup{Week: week, Teams: weekStats[week]},
+		}
+	}
+	m := mockClient{
+		WeekStats:       weekStats,
+		Matchups:        matchups,
+		WeekErrors:      map[int]error{},
+		StandingsLeague: league,
+	}
+
+	for _, test := range []struct {
+		SeasonMode  string
+		Weeks       int
+		First       string
+		TotalPoints float64
+	}{
+		{SeasonModes.REGULAR, 2, "a", 10.0},
+		{SeasonModes.PLAYOFFS, 4, "b", 22.0},
+	} {
+		data, err := GetPowerDataWithOptions(
+			context.Background(),
+			m,
+			league,
+			4,
+			[]Scheme{totalPoints{}},
+			PowerDataOptions{SeasonMode: test.SeasonMode})
+		if err != nil {
+			t.Fatalf("GetPowerData returned unexpected error: %s\n", err)
+		}
+
+		powerData := data[0]
+		first := powerData.OverallRankings[0]
+		if powerData.CurrentWeek != test.Weeks ||
+			len(powerData.ByWeek) != test.Weeks ||
+			len(first.AllRankings) != test.Weeks ||
+			first.Team.TeamKey != test.First ||
+			first.TotalScore != test.TotalPoints {
+			t.Fatalf("GetPowerData did not rank the weeks of season mode '%s'\n"+
+				"\tcurrent week: %d, weeks: %d, first: %+v",
+				test.SeasonMode,
+				powerData.CurrentWeek,
+				len(powerData.ByWeek),
+				first)
+		}
+	}
+}
+
+func TestGetPowerDataLive(t *testing.T) {
+	league := &goff.League{
+		LeagueKey: "leagueID",
+		EndWeek:   3,
+		Settings: goff.Settings{
+			UsesPlayoff:      true,
+			PlayoffStartWeek: 1,
+		},
+	}
+	team := func(key string, actual float64, projected float64) goff.Team {
+		return goff.Team{
+			TeamKey:             key,
+			Name:                key,
+			TeamPoints:          goff.Points{Total: actual},
+			TeamProjectedPoints: goff.Points{Total: projected},
+		}
+	}
+	m := mockClient{
+		WeekStats: map[int][]goff.Team{
+			1: []goff.Team{team("a", 10.0, 8.0), team("b", 5.0, 8.0)},
+			// Week 2 is being played: a has scored more so far, but b is
+			// projected to score more by the end of the week
+			2: []goff.Team{team("a", 6.0, 9.0), team("b", 4.0, 12.0)},
+			3: []goff.Team{team("a", 0.0, 10.0), team("b", 0.0, 10.0)},
+		},
+		WeekErrors:      map[int]error{},
+		StandingsLeague: league,
+	}
+
+	data, err := GetPowerDataWithOptions(
+		context.Background(),
+		m,
+		league,
+		1,
+		[]Scheme{totalPoints{}},
+		PowerDataOptions{Live: true, LiveRemaining: 0.75})
+	if err != nil {
+		t.Fatalf("GetPowerData returned unexpected error: %s\n", err)
+	}
+
+	powerData := data[0]
+	if powerData.CurrentWeek != 2 {
+		t.Fatalf("Live week not included in the overall rankings: %d",
+			powerData.CurrentWeek)
+	}
+	for _, weeklyRanking := range powerData.ByWeek {
+		live := weeklyRanking.Week == 2
+		if weeklyRanking.Live != live ||
+			weeklyRanking.Projected != (weeklyRanking.Week > 2) ||
+			weeklyRanking.Rankings[0].Live != live {
+			t.Fatalf("Unexpected live or projected flags for week %d: %+v",
+				weeklyRanking.Week,
+				weeklyRanking)
+		}
+	}
+
+	// a: 6 + 0.75*9 = 12.75, b: 4 + 0.75*12 = 13
+	liveWeek := powerData.ByWeek[1]
+	if liveWeek.Rankings[0].Team.TeamKey != "b" ||
+		liveWeek.Rankings[0].FantasyScore != 13.0 ||
+		liveWeek.Rankings[1].FantasyScore != 12.75 {
+		t.Fatalf("Live week not ranked using actual and remaining projected "+
+			"points: first=%+v, second=%+v",
+			liveWeek.Rankings[0],
+			liveWeek.Rankings[1])
+	}
+	if powerData.ByTeam["a"].TotalScore != 22.75 ||
+		powerData.ByTeam["b"].TotalScore != 18.0 {
+		t.Fatalf("Live week not included in the total scores: a=%f, b=%f",
+			powerData.ByTeam["a"].TotalScore,
+			powerData.ByTeam["b"].TotalScore)
+	}
+}
+
+func TestLiveClientLeavesWrappedTeamsUnchanged(t *testing.T) {
+	teams := []goff.Team{
+		goff.Team{
+			TeamKey:             "a",
+			TeamPoints:          goff.Points{Total: 6.0},
+			TeamProjectedPoints: goff.Points{Total: 8.0},
+		},
+	}
+	m := mockClient{
+		WeekStats: map[int][]goff.Team{2: teams},
+		Matchups: map[int][]goff.Matchup{
+			2: []goff.Matchup{goff.Matchup{Week: 2, Teams: teams}},
+		},
+		WeekErrors: map[int]error{},
+	}
+	client := &liveClient{client: m, liveWeek: 2, remaining: 0.5}
+
+	// Each request is live scored from the points of the wrapped client, the
+	// same as the first
+	for i := 0; i < 2; i++ {
+		stats, err := client.GetAllTeamStats("", 2, false)
+		if err != nil || stats[0].TeamPoints.Total != 10.0 {
+			t.Fatalf("Unexpected live team stats on request %d: %+v, %v",
+				i+1,
+				stats,
+				err)
+		}
+		allMatchups, err := client.GetMatchupsForWeekRange("", 1, 2)
+		if err != nil || allMatchups[2][0].Teams[0].TeamPoints.Total != 10.0 {
+			t.Fatalf("Unexpected live matchups on request %d: %+v, %v",
+				i+1,
+				allMatchups,
+				err)
+		}
+	}
+	if teams[0].TeamPoints.Total != 6.0 {
+		t.Fatalf("Live scores changed the wrapped client's teams: %+v", teams)
+	}
+}
+
+func TestLiveScore(t *testing.T) {
+	for _, test := range []struct {
+		Actual    float64
+		Projected float64
+		Remaining float64
+		Expected  float64
+	}{
+		{0.0, 10.0, 1.0, 10.0},
+		{6.0, 9.0, 0.5, 10.5},
+		{6.0, 12.0, 0.25, 9.0},
+		// Once the week is over only the points scored count
+		{12.0, 9.0, 0.0, 12.0},
+		{12.0, 9.0, -0.5, 12.0},
+		{0.0, 10.0, 1.5, 10.0},
+	} {
+		team := &goff.Team{
+			TeamPoints:          goff.Points{Total: test.Actual},
+			TeamProjectedPoints: goff.Points{Total: test.Projected},
+		}
+		if actual := liveScore(team, test.Remaining); actual != test.Expected {
+			t.Fatalf("Unexpected live score for %f actual and %f projected "+
+				"points with %f of the week remaining:\n\tExpected: %f\n\tActual: %f",
+				test.Actual,
+				test.Projected,
+				test.Remaining,
+				test.Expected,
+				actual)
+		}
+	}
+
+	// Part way through the week, a team that has scored some of its
+	// projection is expected to finish with neither
+	team := &goff.Team{
+		TeamPoints:          goff.Points{Total: 6.0},
+		TeamProjectedPoints: goff.Points{Total: 9.0},
+	}
+	if actual := liveScore(team, 0.5); actual == 6.0 || actual == 9.0 {
+		t.Fatalf("Live score is only the actual or projected points: %f", actual)
+	}
+}
+
+func TestSeasonCalendarWeekRemaining(t *testing.T) {
+	// Monday, September 7, 2020 was the start of a week
+	monday := time.Date(2020, time.September, 7, 0, 0, 0, 0, time.UTC)
+	for _, test := range []struct {
+		Sport    string
+		Now      time.Time
+		Expected float64
+	}{
+		{Sports.HOCKEY, monday, 1.0},
+		{Sports.HOCKEY, monday.Add(12 * time.Hour), 6.5 / 7.0},
+		{Sports.HOCKEY, monday.AddDate(0, 0, 6).Add(12 * time.Hour), 0.5 / 7.0},
+		// Football weeks start on Tuesday, with most games on Sunday
+		{Sports.FOOTBALL, monday.AddDate(0, 0, 1), 1.0},
+		{Sports.FOOTBALL, monday.AddDate(0, 0, 4), 14.0 / 15.0},
+		{Sports.FOOTBALL, monday.AddDate(0, 0, 6).Add(12 * time.Hour), 7.5 / 15.0},
+		{Sports.FOOTBALL, monday.AddDate(0, 0, 7).Add(18 * time.Hour), 0.25 / 15.0},
+	} {
+		calendar := &SeasonCalendar{Sport: test.Sport}
+		actual := calendar.WeekRemaining(test.Now)
+		if math.Abs(actual-test.Expected) > 1e-9 {
+			t.Fatalf("Unexpected week remaining for %s at %s:\n\t"+
+				"Expected: %f\n\tActual: %f",
+				test.Sport,
+				test.Now,
+				test.Expected,
+				actual)
+		}
+	}
+}
+
+func TestProjectors(t *testing.T) {
+	history := ScoreHistory{
+		"a": []float64{10.0, 20.0, 30.0, 40.0},
+		"b": []float64{10.0, 10.0},
+	}
+	// The league's mean is 20
+	expected := map[string]map[string]float64{
+		"season-mean":    {"a": 25.0, "b": 10.0},
+		"trailing-mean":  {"a": 30.0, "b": 10.0},
+		"regressed-mean": {"a": 160.0 / 7.0, "b": 16.0},
+	}
+	for _, projector := range []Projector{
+		seasonMean{},
+		trailingMean{weeks: 3},
+		regressedMean{weeks: 3.0},
+	} {
+		projections := projector.Project(history)
+		for teamKey, expectedProjection := range expected[projector.ID()] {
+			if math.Abs(projections[teamKey]-expectedProjection) > 0.0001 {
+				t.Fatalf("Unexpected projection for team %s using %s:\n"+
+					"\tExpected: %f\n\tActual: %f",
+					teamKey,
+					projector.DisplayName(),
+					expectedProjection,
+					projections[teamKey])
+			}
+		}
+	}
+}
+
+func TestGetPowerDataProjector(t *testing.T) {
+	league := &goff.League{
+		LeagueKey: "leagueID",
+		EndWeek:   3,
+		Settings: goff.Settings{
+			UsesPlayoff:      true,
+			PlayoffStartWeek: 1,
+		},
+	}
+	team := func(key string, points float64) goff.Team {
+		return goff.Team{
+			TeamKey:    key,
+			Name:       key,
+			TeamPoints: goff.Points{Total: points},
+		}
+	}
+	matchup := func(week int, aPoints float64, bPoints float64) []goff.Matchup {
+		return []goff.Matchup{
+			goff.Matchup{
+				Week:  week,
+				Teams: []goff.Team{team("a", aPoints), team("b", bPoints)},
+			},
+		}
+	}
+	// There are no projections from the provider, like in a past season
+	m := mockClient{
+		WeekStats: map[int][]goff.Team{
+			1: []goff.Team{team("a", 10.0), team("b", 20.0)},
+			2: []goff.Team{team("a", 30.0), team("b", 10.0)},
+			3: []goff.Team{team("a", 0.0), team("b", 0.0)},
+		},
+		Matchups: map[int][]goff.Matchup{
+			1: matchup(1, 10.0, 20.0),
+			2: matchup(2, 30.0, 10.0),
+			3: matchup(3, 0.0, 0.0),
+		},
+		WeekErrors:      map[int]error{},
+		StandingsLeague: league,
+	}
+
+	data, err := GetPowerDataWithOptions(
+		context.Background(),
+		m,
+		league,
+		2,
+		[]Scheme{totalPoints{}, eloRating{kFactor: 32.0}},
+		PowerDataOptions{Projector: seasonMean{}})
+	if err != nil {
+		t.Fatalf("GetPowerData returned unexpected error: %s\n", err)
+	}
+
+	for _, powerData := range data {
+		projectedWeek := powerData.ByTeam["a"].AllScores[2]
+		if !projectedWeek.Projected || projectedWeek.FantasyScore != 20.0 {
+			t.Fatalf("Week not projected using the season mean for %s: %+v",
+				powerData.RankingScheme.DisplayName(),
+				projectedWeek)
+		}
+	}
+	if data[0].ByTeam["a"].ProjectedTotalScore != 60.0 ||
+		data[0].ByTeam["b"].ProjectedTotalScore != 45.0 {
+		t.Fatalf("Unexpected projected total scores: a=%f, b=%f",
+			data[0].ByTeam["a"].ProjectedTotalScore,
+			data[0].ByTeam["b"].ProjectedTotalScore)
+	}
+}
+
+func TestGetPowerDataLuckIndex(t *testing.T) {
+	league := &goff.League{
+		LeagueKey: "leagueID",
+		EndWeek:   2,
+		Standings: []goff.Team{
+			goff.Team{
+				TeamKey: "a",
+				TeamStandings: goff.TeamStandings{
+					Record: goff.Record{Wins: 2, Losses: 0, Ties: 0},
+				},
+			},
+			goff.Team{
+				TeamKey: "b",
+				TeamStandings: goff.TeamStandings{
+					Record: goff.Record{Wins: 0, Losses: 2, Ties: 0},
 				},
 			},
 		},
-		WeekStats: map[int][]goff.Team{
-			2: []goff.Team{
-				goff.Team{
-					TeamKey:             "a",
-					TeamPoints:          goff.Points{Total: 4.0},
-					TeamProjectedPoints: goff.Points{Total: 7.0},
+	}
+	// Team 'a' wins both matchups but scores fewer points than 'c' each week
+	matchups := []goff.Matchup{
+		goff.Matchup{
+			Teams: []goff.Team{
+				goff.Team{TeamKey: "a", TeamPoints: goff.Points{Total: 2.0}},
+				goff.Team{TeamKey: "b", TeamPoints: goff.Points{Total: 1.0}},
+			},
+		},
+		goff.Matchup{
+			Teams: []goff.Team{
+				goff.Team{TeamKey: "c", TeamPoints: goff.Points{Total: 4.0}},
+				goff.Team{TeamKey: "d", TeamPoints: goff.Points{Total: 3.0}},
+			},
+		},
+	}
+	m := mockClient{
+		Matchups: map[int][]goff.Matchup{
+			1: matchups,
+			2: matchups,
+		},
+		WeekErrors:      map[int]error{},
+		StandingsLeague: league,
+	}
+	data, err := GetPowerData(m, league, 2)
+	if err != nil {
+		t.Fatalf("GetPowerData returned unexpected error: %s\n", err)
+	}
+
+	for _, powerData := range data {
+		teamData := powerData.ByTeam["a"]
+		// All-play record of 2-4-0 over 2 games played
+		expectedWins := 2.0 * (2.0 / 6.0)
+		if teamData.ExpectedWins != expectedWins ||
+			teamData.Luck != 2.0-expectedWins {
+			t.Fatalf("Incorrect luck index for scheme %s:\n\t"+
+				"Expected: %f expected wins, %f luck\n\t"+
+				"Actual: %f expected wins, %f luck",
+				powerData.RankingScheme.ID(),
+				expectedWins,
+				2.0-expectedWins,
+				teamData.ExpectedWins,
+				teamData.Luck)
+		}
+	}
+}
+
+func TestGetPowerDataLuckIndexRegularSeason(t *testing.T) {
+	// The standings only have the record of the regular season, which is
+	// the first week
+	league := &goff.League{
+		LeagueKey: "leagueID",
+		EndWeek:   2,
+		Settings: goff.Settings{
+			UsesPlayoff:      true,
+			PlayoffStartWeek: 2,
+		},
+		Standings: []goff.Team{
+			goff.Team{
+				TeamKey: "a",
+				TeamStandings: goff.TeamStandings{
+					Record: goff.Record{Wins: 1, Losses: 0, Ties: 0},
 				},
-				goff.Team{
-					TeamKey:             "b",
-					TeamPoints:          goff.Points{Total: 3.0},
-					TeamProjectedPoints: goff.Points{Total: 8.0},
+			},
+		},
+	}
+	matchups := func(aPoints float64) []goff.Matchup {
+		return []goff.Matchup{
+			goff.Matchup{
+				Teams: []goff.Team{
+					goff.Team{TeamKey: "a", TeamPoints: goff.Points{Total: aPoints}},
+					goff.Team{TeamKey: "b", TeamPoints: goff.Points{Total: 1.0}},
 				},
-				goff.Team{
-					TeamKey:             "c",
-					TeamPoints:          goff.Points{Total: 2.0},
-					TeamProjectedPoints: goff.Points{Total: 9.0},
+			},
+			goff.Matchup{
+				Teams: []goff.Team{
+					goff.Team{TeamKey: "c", TeamPoints: goff.Points{Total: 4.0}},
+					goff.Team{TeamKey: "d", TeamPoints: goff.Points{Total: 3.0}},
 				},
-				goff.Team{
-					TeamKey:             "d",
-					TeamPoints:          goff.Points{Total: 1.0},
-					TeamProjectedPoints: goff.Points{Total: 10.0},
+			},
+		}
+	}
+	m := mockClient{
+		Matchups: map[int][]goff.Matchup{
+			1: matchups(2.0),
+			2: matchups(5.0),
+		},
+		WeekStats: map[int][]goff.Team{
+			2: append(matchups(5.0)[0].Teams, matchups(5.0)[1].Teams...),
+		},
+		WeekErrors:      map[int]error{},
+		StandingsLeague: league,
+	}
+	data, err := GetPowerDataWithOptions(
+		context.Background(),
+		m,
+		league,
+		2,
+		[]Scheme{allPlayRecord{}},
+		PowerDataOptions{SeasonMode: SeasonModes.PLAYOFFS})
+	if err != nil {
+		t.Fatalf("GetPowerData returned unexpected error: %s\n", err)
+	}
+
+	// All-play record of 1-2-0 in the first week, leaving out the playoff
+	// week where 'a' beat every team
+	teamData := data[0].ByTeam["a"]
+	expectedWins := 1.0 / 3.0
+	if teamData.ExpectedWins != expectedWins || teamData.Luck != 1.0-expectedWins {
+		t.Fatalf("Incorrect luck index with playoffs:\n\t"+
+			"Expected: %f expected wins, %f luck\n\t"+
+			"Actual: %f expected wins, %f luck",
+			expectedWins,
+			1.0-expectedWins,
+			teamData.ExpectedWins,
+			teamData.Luck)
+	}
+}
+
+func TestCalculateLuck(t *testing.T) {
+	expectedWins, luck := calculateLuck(
+		&goff.Record{Wins: 5, Losses: 2, Ties: 1},
+		&goff.Record{Wins: 1, Losses: 2, Ties: 1})
+	if expectedWins != 2.75 || luck != -1.25 {
+		t.Fatalf("Incorrect luck calculated:\n\t"+
+			"Expected: 2.750000 expected wins, -1.250000 luck\n\t"+
+			"Actual: %f expected wins, %f luck",
+			expectedWins,
+			luck)
+	}
+
+	expectedWins, luck = calculateLuck(&goff.Record{}, &goff.Record{Wins: 3})
+	if expectedWins != 0.0 || luck != 0.0 {
+		t.Fatalf("Luck calculated without any all-play games: %f, %f",
+			expectedWins,
+			luck)
+	}
+}
+
+func TestAddConsistency(t *testing.T) {
+	matchup := func(aPoints float64, bPoints float64) []goff.Matchup {
+		return []goff.Matchup{
+			goff.Matchup{
+				Teams: []goff.Team{
+					goff.Team{TeamKey: "a", TeamPoints: goff.Points{Total: aPoints}},
+					goff.Team{TeamKey: "b", TeamPoints: goff.Points{Total: bPoints}},
 				},
 			},
-			3: []goff.Team{
-				goff.Team{
-					TeamKey:             "a",
-					TeamPoints:          goff.Points{Total: 4.0},
-					TeamProjectedPoints: goff.Points{Total: 7.0},
+		}
+	}
+	allMatchups := map[int][]goff.Matchup{
+		1: matchup(10.0, 20.0),
+		2: matchup(20.0, 20.0),
+		3: matchup(30.0, 20.0),
+		// Weeks after the weeks played aren't included
+		4: matchup(100.0, 100.0),
+	}
+
+	// Every scheme gets the same consistency, whatever its weekly scores
+	var leaguePowerData []*LeaguePowerData
+	for i := 0; i < 2; i++ {
+		leaguePowerData = append(leaguePowerData, &LeaguePowerData{
+			ByTeam: map[string]*TeamPowerData{
+				"a": &TeamPowerData{
+					AllScores: []*TeamScoreData{&TeamScoreData{FantasyScore: 5.0}},
 				},
-				goff.Team{
-					TeamKey:             "b",
-					TeamPoints:          goff.Points{Total: 3.0},
-					TeamProjectedPoints: goff.Points{Total: 8.0},
+				"b": &TeamPowerData{},
+				// Teams without any matchups have no consistency
+				"c": &TeamPowerData{},
+			},
+		})
+	}
+
+	addConsistency(leaguePowerData, allMatchups, 3)
+
+	// League scores: 10, 20, 20, 20, 20, 30
+	for _, powerData := range leaguePowerData {
+		a := powerData.ByTeam["a"]
+		b := powerData.ByTeam["b"]
+		c := powerData.ByTeam["c"]
+		if a.MeanScore != 20.0 ||
+			math.Abs(a.ScoreStandardDeviation-math.Sqrt(200.0/3.0)) > 0.000001 ||
+			math.Abs(a.CoefficientOfVariation-math.Sqrt(200.0/3.0)/20.0) > 0.000001 ||
+			a.Floor != 10.0 ||
+			a.Ceiling != 30.0 ||
+			a.BoomWeeks != 1 ||
+			a.BustWeeks != 1 {
+			t.Fatalf("Unexpected consistency for team a: %+v", a)
+		}
+		if b.MeanScore != 20.0 ||
+			b.ScoreStandardDeviation != 0.0 ||
+			b.CoefficientOfVariation != 0.0 ||
+			b.Floor != 20.0 ||
+			b.Ceiling != 20.0 ||
+			b.BoomWeeks != 0 ||
+			b.BustWeeks != 0 {
+			t.Fatalf("Unexpected consistency for team b: %+v", b)
+		}
+		if c.MeanScore != 0.0 || c.Floor != 0.0 || c.BustWeeks != 0 {
+			t.Fatalf("Unexpected consistency for team c: %+v", c)
+		}
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	sorted := []float64{10.0, 20.0, 30.0, 40.0, 50.0}
+	for _, test := range []struct {
+		Percentile float64
+		Expected   float64
+	}{
+		{0.0, 10.0},
+		{0.2, 18.0},
+		{0.5, 30.0},
+		{0.8, 42.0},
+		{1.0, 50.0},
+	} {
+		actual := percentile(sorted, test.Percentile)
+		if math.Abs(actual-test.Expected) > 0.000001 {
+			t.Fatalf("Unexpected value at percentile %f\n\t"+
+				"Expected: %f\n\tActual: %f",
+				test.Percentile,
+				test.Expected,
+				actual)
+		}
+	}
+	if actual := percentile(nil, 0.5); actual != 0.0 {
+		t.Fatalf("Unexpected percentile of no values: %f", actual)
+	}
+}
+
+func TestGetPowerDataStrengthOfSchedule(t *testing.T) {
+	league := &goff.League{
+		LeagueKey: "leagueID",
+		EndWeek:   2,
+	}
+	matchups := []goff.Matchup{
+		goff.Matchup{
+			Teams: []goff.Team{
+				goff.Team{TeamKey: "a", TeamPoints: goff.Points{Total: 2.0}},
+				goff.Team{TeamKey: "b", TeamPoints: goff.Points{Total: 1.0}},
+			},
+		},
+		goff.Matchup{
+			Teams: []goff.Team{
+				goff.Team{TeamKey: "c", TeamPoints: goff.Points{Total: 4.0}},
+				goff.Team{TeamKey: "d", TeamPoints: goff.Points{Total: 3.0}},
+			},
+		},
+	}
+	m := mockClient{
+		Matchups: map[int][]goff.Matchup{
+			1: matchups,
+			2: matchups,
+		},
+		WeekErrors:      map[int]error{},
+		StandingsLeague: league,
+	}
+	data, err := GetPowerData(m, league, 2)
+	if err != nil {
+		t.Fatalf("GetPowerData returned unexpected error: %s\n", err)
+	}
+
+	// All-play records: a 2-4-0, b 0-6-0, c 6-0-0, d 4-2-0
+	expected := map[string]float64{
+		"a": 0.0,
+		"b": 2.0 / 6.0,
+		"c": 4.0 / 6.0,
+		"d": 1.0,
+	}
+	for _, powerData := range data {
+		for teamKey, expectedStrength := range expected {
+			teamData := powerData.ByTeam[teamKey]
+			if teamData.StrengthOfSchedule != expectedStrength ||
+				teamData.RemainingStrengthOfSchedule != 0.0 {
+				t.Fatalf("Incorrect strength of schedule for team %s, "+
+					"scheme %s:\n\tExpected: %f past, %f remaining\n\t"+
+					"Actual: %f past, %f remaining",
+					teamKey,
+					powerData.RankingScheme.ID(),
+					expectedStrength,
+					0.0,
+					teamData.StrengthOfSchedule,
+					teamData.RemainingStrengthOfSchedule)
+			}
+		}
+	}
+}
+
+func TestGetPowerDataDivisions(t *testing.T) {
+	league := &goff.League{
+		LeagueKey: "leagueID",
+		EndWeek:   2,
+	}
+	matchups := []goff.Matchup{
+		goff.Matchup{
+			Teams: []goff.Team{
+				goff.Team{TeamKey: "a", TeamPoints: goff.Points{Total: 2.0}},
+				goff.Team{TeamKey: "b", TeamPoints: goff.Points{Total: 1.0}},
+			},
+		},
+		goff.Matchup{
+			Teams: []goff.Team{
+				goff.Team{TeamKey: "c", TeamPoints: goff.Points{Total: 4.0}},
+				goff.Team{TeamKey: "d", TeamPoints: goff.Points{Total: 3.0}},
+			},
+		},
+	}
+	m := mockDivisionClient{
+		mockClient: mockClient{
+			Matchups: map[int][]goff.Matchup{
+				1: matchups,
+				2: matchups,
+			},
+			WeekErrors:      map[int]error{},
+			StandingsLeague: league,
+		},
+		Divisions: []Division{
+			Division{ID: "1", Name: "East", TeamKeys: []string{"a", "b"}},
+			Division{ID: "2", Name: "West", TeamKeys: []string{"c", "d"}},
+		},
+	}
+	data, err := GetPowerData(m, league, 2)
+	if err != nil {
+		t.Fatalf("GetPowerData returned unexpected error: %s\n", err)
+	}
+
+	for _, powerData := range data {
+		if powerData.RankingScheme.ID() != (allPlayRecord{}).ID() {
+			continue
+		}
+		if len(powerData.Divisions) != 2 {
+			t.Fatalf("Unexpected number of divisions: %d", len(powerData.Divisions))
+		}
+		for i, expected := range [][]string{
+			[]string{"a", "b"},
+			[]string{"c", "d"},
+		} {
+			division := powerData.Divisions[i]
+			if len(division.OverallRankings) != len(expected) ||
+				division.ProjectedWinner == nil ||
+				division.ProjectedWinner.Team.TeamKey != expected[0] {
+				t.Fatalf("Unexpected rankings for division %s: %+v",
+					division.Division.Name,
+					division)
+			}
+			for rank, teamKey := range expected {
+				teamData := division.OverallRankings[rank]
+				expectedRecord := "2-0-0"
+				if rank > 0 {
+					expectedRecord = "0-2-0"
+				}
+				if teamData.Team.TeamKey != teamKey ||
+					teamData.DivisionID != division.Division.ID ||
+					teamData.DivisionRank != rank+1 ||
+					teamData.ProjectedDivisionRank != rank+1 ||
+					recordString(teamData.DivisionAllPlayRecord) != expectedRecord {
+					t.Fatalf("Unexpected division data for team %s:\n\t"+
+						"Expected: team %s, division %s, rank %d, "+
+						"all-play %s\n\tActual: %+v",
+						teamData.Team.TeamKey,
+						teamKey,
+						division.Division.ID,
+						rank+1,
+						expectedRecord,
+						teamData)
+				}
+			}
+		}
+	}
+
+	// A single division is the same as the whole league
+	m.Divisions = m.Divisions[:1]
+	data, err = GetPowerData(m, league, 2)
+	if err != nil {
+		t.Fatalf("GetPowerData returned unexpected error: %s\n", err)
+	}
+	for _, powerData := range data {
+		if powerData.Divisions != nil {
+			t.Fatalf("Divisions added for a league with a single division: %+v",
+				powerData.Divisions)
+		}
+	}
+}
+
+func TestDivisionRankTies(t *testing.T) {
+	division := PowerRankings{
+		&TeamPowerData{Team: &goff.Team{TeamKey: "a"}, Rank: 1, ProjectedRank: 2},
+		&TeamPowerData{Team: &goff.Team{TeamKey: "b"}, Rank: 3, ProjectedRank: 2},
+		&TeamPowerData{Team: &goff.Team{TeamKey: "c"}, Rank: 3, ProjectedRank: 1},
+		&TeamPowerData{Team: &goff.Team{TeamKey: "d"}, Rank: 6, ProjectedRank: 6},
+	}
+	expectedRanks := []int{1, 2, 2, 4}
+	expectedProjectedRanks := []int{2, 2, 1, 4}
+	for i, teamData := range division {
+		rank := divisionRank(division, teamData, func(t *TeamPowerData) int {
+			return t.Rank
+		})
+		projectedRank := divisionRank(division, teamData, func(t *TeamPowerData) int {
+			return t.ProjectedRank
+		})
+		if rank != expectedRanks[i] || projectedRank != expectedProjectedRanks[i] {
+			t.Fatalf("Unexpected division ranks for team %s:\n\t"+
+				"Expected: %d, %d\n\tActual: %d, %d",
+				teamData.Team.TeamKey,
+				expectedRanks[i],
+				expectedProjectedRanks[i],
+				rank,
+				projectedRank)
+		}
+	}
+}
+
+func TestGetPowerDataDivisionsClientError(t *testing.T) {
+	league := &goff.League{LeagueKey: "leagueID", EndWeek: 2}
+	m := mockDivisionClient{
+		mockClient: mockClient{
+			WeekErrors:      map[int]error{},
+			StandingsLeague: league,
+		},
+		DivisionsError: errors.New("error"),
+	}
+	_, err := GetPowerData(m, league, 2)
+	if err == nil {
+		t.Fatalf("GetPowerData did not return error when divisions failed")
+	}
+}
+
+func TestCalculateStrengthOfSchedule(t *testing.T) {
+	allMatchups := map[int][]goff.Matchup{
+		1: []goff.Matchup{
+			goff.Matchup{
+				Teams: []goff.Team{goff.Team{TeamKey: "a"}, goff.Team{TeamKey: "b"}},
+			},
+			goff.Matchup{
+				Teams: []goff.Team{goff.Team{TeamKey: "c"}, goff.Team{TeamKey: "d"}},
+			},
+		},
+		2: []goff.Matchup{
+			goff.Matchup{
+				Teams: []goff.Team{goff.Team{TeamKey: "a"}, goff.Team{TeamKey: "c"}},
+			},
+			goff.Matchup{
+				Teams: []goff.Team{goff.Team{TeamKey: "b"}, goff.Team{TeamKey: "d"}},
+			},
+		},
+		3: []goff.Matchup{
+			goff.Matchup{
+				Teams: []goff.Team{goff.Team{TeamKey: "a"}, goff.Team{TeamKey: "d"}},
+			},
+			goff.Matchup{
+				Teams: []goff.Team{goff.Team{TeamKey: "b"}, goff.Team{TeamKey: "c"}},
+			},
+		},
+	}
+	opponentStrength := map[string]float64{
+		"a": 0.75,
+		"b": 0.5,
+		"c": 0.25,
+		"d": 0.0,
+	}
+
+	past := calculateStrengthOfSchedule(allMatchups, opponentStrength, 1, 2)
+	remaining := calculateStrengthOfSchedule(allMatchups, opponentStrength, 3, 3)
+
+	for teamKey, expected := range map[string][]float64{
+		"a": []float64{0.375, 0.0},
+		"b": []float64{0.375, 0.25},
+		"c": []float64{0.375, 0.5},
+		"d": []float64{0.375, 0.75},
+	} {
+		if past[teamKey] != expected[0] || remaining[teamKey] != expected[1] {
+			t.Fatalf("Incorrect strength of schedule for team %s:\n\t"+
+				"Expected: %f past, %f remaining\n\t"+
+				"Actual: %f past, %f remaining",
+				teamKey,
+				expected[0],
+				expected[1],
+				past[teamKey],
+				remaining[teamKey])
+		}
+	}
+}
+
+func TestCalculateScheduleSwap(t *testing.T) {
+	allMatchups := map[int][]goff.Matchup{
+		1: []goff.Matchup{
+			goff.Matchup{
+				Teams: []goff.Team{
+					goff.Team{TeamKey: "a", TeamPoints: goff.Points{Total: 10.0}},
+					goff.Team{TeamKey: "b", TeamPoints: goff.Points{Total: 5.0}},
+				},
+			},
+			goff.Matchup{
+				Teams: []goff.Team{
+					goff.Team{TeamKey: "c", TeamPoints: goff.Points{Total: 8.0}},
+					goff.Team{TeamKey: "d", TeamPoints: goff.Points{Total: 1.0}},
 				},
-				goff.Team{
-					TeamKey:             "c",
-					TeamPoints:          goff.Points{Total: 2.0},
-					TeamProjectedPoints: goff.Points{Total: 9.0},
+			},
+		},
+		2: []goff.Matchup{
+			goff.Matchup{
+				Teams: []goff.Team{
+					goff.Team{TeamKey: "a", TeamPoints: goff.Points{Total: 3.0}},
+					goff.Team{TeamKey: "c", TeamPoints: goff.Points{Total: 9.0}},
 				},
-				goff.Team{
-					TeamKey:             "d",
-					TeamPoints:          goff.Points{Total: 1.0},
-					TeamProjectedPoints: goff.Points{Total: 10.0},
+			},
+			goff.Matchup{
+				Teams: []goff.Team{
+					goff.Team{TeamKey: "b", TeamPoints: goff.Points{Total: 7.0}},
+					goff.Team{TeamKey: "d", TeamPoints: goff.Points{Total: 6.0}},
 				},
 			},
 		},
-		WeekErrors:      map[int]error{},
+	}
+	standings := []goff.Team{
+		goff.Team{TeamKey: "c"},
+		goff.Team{TeamKey: "a"},
+		goff.Team{TeamKey: "b"},
+		goff.Team{TeamKey: "d"},
+	}
+
+	swap := CalculateScheduleSwap(standings, allMatchups, 2)
+
+	if swap.Weeks != 2 || len(swap.Teams) != 4 {
+		t.Fatalf("Unexpected schedule swap dimensions: weeks=%d, teams=%d",
+			swap.Weeks,
+			len(swap.Teams))
+	}
+	for i, team := range swap.Teams {
+		if team.TeamKey != standings[i].TeamKey {
+			t.Fatalf("Teams not in order of standings:\n\t"+
+				"Expected: %s\n\tActual: %s",
+				standings[i].TeamKey,
+				team.TeamKey)
+		}
+	}
+
+	// Rows and columns: c, a, b, d
+	expected := [][]string{
+		[]string{"2-0-0", "2-0-0", "1-1-0", "2-0-0"},
+		[]string{"1-1-0", "1-1-0", "1-1-0", "1-1-0"},
+		[]string{"2-0-0", "0-2-0", "1-1-0", "1-1-0"},
+		[]string{"1-1-0", "0-2-0", "0-2-0", "0-2-0"},
+	}
+	for i, row := range expected {
+		for j, expectedRecord := range row {
+			actual := recordString(swap.Records[i][j])
+			if actual != expectedRecord {
+				t.Fatalf("Unexpected record for team %s with the schedule "+
+					"of team %s:\n\tExpected: %s\n\tActual: %s",
+					swap.Teams[i].TeamKey,
+					swap.Teams[j].TeamKey,
+					expectedRecord,
+					actual)
+			}
+		}
+	}
+}
+
+func TestCalculateHeadToHead(t *testing.T) {
+	a := &goff.Team{TeamKey: "a", TeamPoints: goff.Points{Total: 10.0}}
+	b := &goff.Team{TeamKey: "b", TeamPoints: goff.Points{Total: 5.0}}
+	c := &goff.Team{TeamKey: "c", TeamPoints: goff.Points{Total: 8.0}}
+	allMatchups := map[int][]goff.Matchup{
+		1: []goff.Matchup{
+			goff.Matchup{Teams: []goff.Team{*a, *b}},
+		},
+		2: []goff.Matchup{
+			goff.Matchup{Teams: []goff.Team{*a, *c}},
+		},
+	}
+	byWeek := []*WeeklyRanking{
+		&WeeklyRanking{
+			Week: 1,
+			Rankings: []*TeamScoreData{
+				&TeamScoreData{Team: a, FantasyScore: 10.0},
+				&TeamScoreData{Team: b, FantasyScore: 5.0},
+				&TeamScoreData{Team: c, FantasyScore: 8.0},
+			},
+		},
+		&WeeklyRanking{
+			Week: 2,
+			Rankings: []*TeamScoreData{
+				&TeamScoreData{Team: a, FantasyScore: 10.0},
+				&TeamScoreData{Team: b, FantasyScore: 5.0},
+				&TeamScoreData{Team: c, FantasyScore: 8.0},
+			},
+		},
+		&WeeklyRanking{
+			Week:      3,
+			Projected: true,
+			Rankings: []*TeamScoreData{
+				&TeamScoreData{Team: a, FantasyScore: 1.0},
+				&TeamScoreData{Team: b, FantasyScore: 20.0},
+				&TeamScoreData{Team: c, FantasyScore: 20.0},
+			},
+		},
+	}
+	standings := []goff.Team{*c, *a, *b}
+
+	headToHead := CalculateHeadToHead(standings, allMatchups, byWeek, 3)
+
+	if headToHead.Weeks != 3 || len(headToHead.Teams) != 3 {
+		t.Fatalf("Unexpected head-to-head dimensions: weeks=%d, teams=%d",
+			headToHead.Weeks,
+			len(headToHead.Teams))
+	}
+
+	// Rows and columns: c, a, b. Projected weeks aren't counted.
+	expectedRecords := [][]string{
+		[]string{"0-0-0", "0-1-0", "0-0-0"},
+		[]string{"1-0-0", "0-0-0", "1-0-0"},
+		[]string{"0-0-0", "0-1-0", "0-0-0"},
+	}
+	expectedAllPlay := [][]string{
+		[]string{"0-0-0", "0-2-0", "2-0-0"},
+		[]string{"2-0-0", "0-0-0", "2-0-0"},
+		[]string{"0-2-0", "0-2-0", "0-0-0"},
+	}
+	for i := range headToHead.Teams {
+		for j := range headToHead.Teams {
+			actual := recordString(headToHead.Records[i][j])
+			actualAllPlay := recordString(headToHead.AllPlayRecords[i][j])
+			if actual != expectedRecords[i][j] ||
+				actualAllPlay != expectedAllPlay[i][j] {
+				t.Fatalf("Unexpected records for team %s against team %s:"+
+					"\n\tExpected: %s, %s all-play\n\tActual: %s, %s all-play",
+					headToHead.Teams[i].TeamKey,
+					headToHead.Teams[j].TeamKey,
+					expectedRecords[i][j],
+					expectedAllPlay[i][j],
+					actual,
+					actualAllPlay)
+			}
+		}
+	}
+}
+
+func TestGetScheduleSwapClientError(t *testing.T) {
+	league := &goff.League{
+		LeagueKey: "leagueID",
+		EndWeek:   3,
+	}
+	m := mockClient{
+		MatchupsError:   errors.New("error"),
 		StandingsLeague: league,
 	}
-	data, err := GetPowerData(m, league, 1)
+	swap, err := GetScheduleSwap(m, league, 3)
+	if err == nil {
+		t.Fatalf("GetScheduleSwap did not return error\n\tswap: %+v\n", swap)
+	}
+}
 
-	if err != nil {
-		t.Fatalf("GetPowerData returned unexpected error: %s\n", err)
+func TestPlayoffSimulatorCompletedSeason(t *testing.T) {
+	allMatchups := mockPlayoffMatchups()
+	league := &goff.League{
+		Settings: goff.Settings{UsesPlayoff: true, PlayoffStartWeek: 3},
 	}
 
-	allPlayData := data[0]
-	rankings := allPlayData.ProjectedRankings
-	if rankings[0].Team.TeamKey != "d" ||
-		rankings[1].Team.TeamKey != "c" ||
-		rankings[2].Team.TeamKey != "b" ||
-		rankings[3].Team.TeamKey != "a" {
-		t.Fatalf("GetPowerData returned incorrect rankings.\n"+
-			"\trankings: %+v\n",
-			rankings)
+	simulator := NewPlayoffSimulator(1, 100, 2, 1)
+	odds := simulator.Simulate(league, allMatchups, 2, 2)
+
+	// a: 2-0, c: 1-1 (11 points), b: 1-1 (10 points), d: 0-2
+	expectedSeeds := []string{"a", "c", "b", "d"}
+	for seed, teamKey := range expectedSeeds {
+		teamOdds := odds.ByTeam[teamKey]
+		if teamOdds.SeedOdds[seed] != 1.0 {
+			t.Fatalf("Unexpected odds of team %s finishing as seed %d in a "+
+				"completed season\n\tExpected: %f\n\tActual: %f",
+				teamKey,
+				seed+1,
+				1.0,
+				teamOdds.SeedOdds[seed])
+		}
+		if odds.Teams[seed] != teamOdds {
+			t.Fatalf("Team %s not sorted by playoff odds", teamKey)
+		}
 	}
 
-	if rankings[0].ProjectedOverallRecord.Wins != 6 ||
-		rankings[1].ProjectedOverallRecord.Wins != 5 ||
-		rankings[2].ProjectedOverallRecord.Wins != 4 ||
-		rankings[3].ProjectedOverallRecord.Wins != 3 {
-		t.Fatalf("GetPowerData returned incorrect scores.\n"+
-			"\trankings: %+v\n",
-			rankings)
+	for teamKey, expected := range map[string][]float64{
+		"a": []float64{1.0, 1.0},
+		"c": []float64{1.0, 0.0},
+		"b": []float64{0.0, 0.0},
+		"d": []float64{0.0, 0.0},
+	} {
+		teamOdds := odds.ByTeam[teamKey]
+		if teamOdds.PlayoffOdds != expected[0] || teamOdds.ByeOdds != expected[1] {
+			t.Fatalf("Unexpected playoff odds for team %s\n\t"+
+				"Expected: playoffs=%f, bye=%f\n\tActual: playoffs=%f, bye=%f",
+				teamKey,
+				expected[0],
+				expected[1],
+				teamOdds.PlayoffOdds,
+				teamOdds.ByeOdds)
+		}
 	}
+}
 
-	if rankings[0].ProjectedRank != 1 ||
-		rankings[1].ProjectedRank != 2 ||
-		rankings[2].ProjectedRank != 3 ||
-		rankings[3].ProjectedRank != 4 {
-		t.Fatalf("GetPowerData returned incorrect ranks.\n"+
-			"\trankings: %d, %d, %d, %d\n",
-			rankings[0].ProjectedRank,
-			rankings[1].ProjectedRank,
-			rankings[2].ProjectedRank,
-			rankings[3].ProjectedRank)
+func TestPlayoffSimulatorReproducible(t *testing.T) {
+	allMatchups := mockPlayoffMatchups()
+	league := &goff.League{
+		Settings: goff.Settings{UsesPlayoff: true, PlayoffStartWeek: 5},
+	}
+
+	first := NewPlayoffSimulator(42, 500, 2, 1).Simulate(league, allMatchups, 2, 4)
+	second := NewPlayoffSimulator(42, 500, 2, 1).Simulate(league, allMatchups, 2, 4)
+
+	for teamKey, firstOdds := range first.ByTeam {
+		secondOdds := second.ByTeam[teamKey]
+		for i := range firstOdds.SeedOdds {
+			if firstOdds.SeedOdds[i] != secondOdds.SeedOdds[i] {
+				t.Fatalf("Simulations with the same seed returned different "+
+					"odds for team %s, seed %d\n\tFirst: %f\n\tSecond: %f",
+					teamKey,
+					i+1,
+					firstOdds.SeedOdds[i],
+					secondOdds.SeedOdds[i])
+			}
+		}
+	}
+
+	for teamKey, teamOdds := range first.ByTeam {
+		total := 0.0
+		for _, seedOdds := range teamOdds.SeedOdds {
+			total += seedOdds
+		}
+		if math.Abs(total-1.0) > 0.000001 {
+			t.Fatalf("Seed odds for team %s do not add up to 1: %f",
+				teamKey,
+				total)
+		}
+		if teamOdds.ByeOdds > teamOdds.PlayoffOdds {
+			t.Fatalf("Team %s more likely to get a bye than make the playoffs",
+				teamKey)
+		}
+	}
+
+	// Team d is projected to score far more than its opponents
+	if first.ByTeam["d"].AverageWins < 1.5 {
+		t.Fatalf("Team d with high projections averaged too few wins: %f",
+			first.ByTeam["d"].AverageWins)
 	}
 }
 
-func TestGetPowerDataClientError(t *testing.T) {
+func TestGetPlayoffOddsClientError(t *testing.T) {
 	league := &goff.League{
 		LeagueKey: "leagueID",
 		EndWeek:   3,
 	}
 	m := mockClient{
-		MatchupsError: errors.New("error"),
-		WeekStats: map[int][]goff.Team{
-			// Week 1
-			1: []goff.Team{
-				goff.Team{TeamKey: "a", TeamPoints: goff.Points{Total: 4.0}},
-				goff.Team{TeamKey: "b", TeamPoints: goff.Points{Total: 3.0}},
-				goff.Team{TeamKey: "c", TeamPoints: goff.Points{Total: 2.0}},
-				goff.Team{TeamKey: "d", TeamPoints: goff.Points{Total: 1.0}},
-			},
-			3: []goff.Team{
-				goff.Team{TeamKey: "a", TeamPoints: goff.Points{Total: 4.0}},
-				goff.Team{TeamKey: "b", TeamPoints: goff.Points{Total: 3.0}},
-				goff.Team{TeamKey: "c", TeamPoints: goff.Points{Total: 2.0}},
-				goff.Team{TeamKey: "d", TeamPoints: goff.Points{Total: 1.0}},
-			},
-		},
-		WeekErrors: map[int]error{
-			2: errors.New("error"),
-		},
+		MatchupsError:   errors.New("error"),
 		StandingsLeague: league,
 	}
-	data, err := GetPowerData(m, league, 3)
+	odds, err := GetPlayoffOdds(m, league, 3, NewPlayoffSimulator(1, 10, 2, 0))
 	if err == nil {
-		t.Fatalf("GetPowerData did not return error\n\tdata: %+v\n", data)
+		t.Fatalf("GetPlayoffOdds did not return error\n\todds: %+v\n", odds)
 	}
 }
 
-func TestGetPowerDataTies(t *testing.T) {
+func TestPlayoffSimulatorNoData(t *testing.T) {
+	allMatchups := mockPlayoffMatchups()
+	for _, matchups := range allMatchups {
+		for i := range matchups {
+			for j := range matchups[i].Teams {
+				matchups[i].Teams[j].TeamPoints.Total = 0.0
+				matchups[i].Teams[j].TeamProjectedPoints.Total = 0.0
+			}
+		}
+	}
 	league := &goff.League{
 		LeagueKey: "leagueID",
 		EndWeek:   4,
-		Settings: goff.Settings{
-			UsesPlayoff:      true,
-			PlayoffStartWeek: 1,
-		},
 	}
+
+	odds := NewPlayoffSimulator(1, 100, 2, 0).Simulate(league, allMatchups, 0, 4)
+	if odds != nil {
+		t.Fatalf("Unexpected odds without scores or projections: %+v", odds)
+	}
+
 	m := mockClient{
-		// Teams a/b will be tied after 4 weeks
-		WeekStats: map[int][]goff.Team{
-			// Week 1
-			1: []goff.Team{
-				goff.Team{TeamKey: "a", TeamPoints: goff.Points{Total: 4.0}},
-				goff.Team{TeamKey: "b", TeamPoints: goff.Points{Total: 5.0}},
-				goff.Team{TeamKey: "c", TeamPoints: goff.Points{Total: 2.0}},
-				goff.Team{TeamKey: "d", TeamPoints: goff.Points{Total: 1.0}},
-			},
-			2: []goff.Team{
-				goff.Team{TeamKey: "a", TeamPoints: goff.Points{Total: 5.0}},
-				goff.Team{TeamKey: "b", TeamPoints: goff.Points{Total: 4.0}},
-				goff.Team{TeamKey: "c", TeamPoints: goff.Points{Total: 2.0}},
-				goff.Team{TeamKey: "d", TeamPoints: goff.Points{Total: 1.0}},
-			},
-			3: []goff.Team{
-				goff.Team{TeamKey: "a", TeamPoints: goff.Points{Total: 4.0}},
-				goff.Team{TeamKey: "b", TeamPoints: goff.Points{Total: 5.0}},
-				goff.Team{TeamKey: "c", TeamPoints: goff.Points{Total: 2.0}},
-				goff.Team{TeamKey: "d", TeamPoints: goff.Points{Total: 1.0}},
-			},
-			4: []goff.Team{
-				goff.Team{TeamKey: "a", TeamPoints: goff.Points{Total: 5.0}},
-				goff.Team{TeamKey: "b", TeamPoints: goff.Points{Total: 4.0}},
-				goff.Team{TeamKey: "c", TeamPoints: goff.Points{Total: 2.0}},
-				goff.Team{TeamKey: "d", TeamPoints: goff.Points{Total: 1.0}},
-			},
-		},
+		Matchups:        allMatchups,
+		StandingsLeague: league,
+	}
+	odds, err := GetPlayoffOdds(m, league, 0, NewPlayoffSimulator(1, 100, 2, 0))
+	if err != nil || odds != nil {
+		t.Fatalf("Unexpected odds without scores or projections: %+v, %v",
+			odds,
+			err)
+	}
+}
+
+func TestGetPowerDataPlayoffOdds(t *testing.T) {
+	allMatchups := mockPlayoffMatchups()
+	league := &goff.League{
+		LeagueKey: "leagueID",
+		EndWeek:   4,
+		Settings:  goff.Settings{UsesPlayoff: true, PlayoffStartWeek: 5},
+	}
+	weekStats := make(map[int][]goff.Team)
+	for week, matchups := range allMatchups {
+		for _, matchup := range matchups {
+			weekStats[week] = append(weekStats[week], matchup.Teams...)
+		}
+	}
+	m := mockClient{
+		WeekStats:       weekStats,
 		WeekErrors:      map[int]error{},
+		Matchups:        allMatchups,
 		StandingsLeague: league,
 	}
-	data, err := GetPowerData(m, league, 4)
 
+	data, err := GetPowerDataWithOptions(
+		context.Background(),
+		m,
+		league,
+		2,
+		[]Scheme{allPlayRecord{}},
+		PowerDataOptions{PlayoffSimulator: NewPlayoffSimulator(42, 500, 2, 1)})
 	if err != nil {
 		t.Fatalf("GetPowerData returned unexpected error: %s\n", err)
 	}
+	expected := NewPlayoffSimulator(42, 500, 2, 1).Simulate(league, allMatchups, 2, 4)
+	odds := data[0].PlayoffOdds
+	if odds == nil || len(odds.Teams) != 4 {
+		t.Fatalf("Unexpected playoff odds: %+v", odds)
+	}
+	for teamKey, teamOdds := range expected.ByTeam {
+		if odds.ByTeam[teamKey].PlayoffOdds != teamOdds.PlayoffOdds {
+			t.Fatalf("Unexpected playoff odds for team %s\n\t"+
+				"Expected: %f\n\tActual: %f",
+				teamKey,
+				teamOdds.PlayoffOdds,
+				odds.ByTeam[teamKey].PlayoffOdds)
+		}
+	}
 
-	allPlayData := data[0]
-	rankings := allPlayData.OverallRankings
-	if rankings[0].Rank != 1 ||
-		rankings[1].Rank != 1 ||
-		rankings[2].Rank != 3 ||
-		rankings[3].Rank != 4 {
-		t.Fatalf("GetPowerData did not correctly rank teams after a tie\n"+
-			"\trankings: %+v\n",
-			rankings)
+	// Nothing is left to simulate once the regular season is over
+	data, err = GetPowerDataWithOptions(
+		context.Background(),
+		m,
+		league,
+		4,
+		[]Scheme{allPlayRecord{}},
+		PowerDataOptions{PlayoffSimulator: NewPlayoffSimulator(42, 500, 2, 1)})
+	if err != nil || data[0].PlayoffOdds != nil {
+		t.Fatalf("Unexpected playoff odds after the regular season: %+v, %v",
+			data[0].PlayoffOdds,
+			err)
+	}
+}
+
+func TestPlayoffByeTeams(t *testing.T) {
+	for playoffTeams, byeTeams := range map[int]int{0: 0, 2: 0, 4: 0, 6: 2, 7: 1, 8: 0, 10: 6} {
+		if actual := PlayoffByeTeams(playoffTeams); actual != byeTeams {
+			t.Fatalf("Unexpected bye teams for %d playoff teams: %d",
+				playoffTeams,
+				actual)
+		}
 	}
 }
 
@@ -709,3 +3363,201 @@ func (m mockClient) GetMatchupsForWeekRange(leagueKey string, startWeek, endWeek
 func (m mockClient) GetLeagueStandings(leagueKey string) (*goff.League, error) {
 	return m.StandingsLeague, m.StandingsError
 }
+
+func mockPlayoffMatchups() map[int][]goff.Matchup {
+	matchup := func(first, second string, firstPoints, secondPoints, firstProjected, secondProjected float64) goff.Matchup {
+		return goff.Matchup{
+			Teams: []goff.Team{
+				goff.Team{
+					TeamKey:             first,
+					TeamPoints:          goff.Points{Total: firstPoints},
+					TeamProjectedPoints: goff.Points{Total: firstProjected},
+				},
+				goff.Team{
+					TeamKey:             second,
+					TeamPoints:          goff.Points{Total: secondPoints},
+					TeamProjectedPoints: goff.Points{Total: secondProjected},
+				},
+			},
+		}
+	}
+	return map[int][]goff.Matchup{
+		1: []goff.Matchup{
+			matchup("a", "b", 10.0, 5.0, 0.0, 0.0),
+			matchup("c", "d", 8.0, 1.0, 0.0, 0.0),
+		},
+		2: []goff.Matchup{
+			matchup("a", "c", 6.0, 3.0, 0.0, 0.0),
+			matchup("b", "d", 5.0, 2.0, 0.0, 0.0),
+		},
+		3: []goff.Matchup{
+			matchup("a", "d", 0.0, 0.0, 8.0, 100.0),
+			matchup("b", "c", 0.0, 0.0, 7.0, 7.0),
+		},
+		4: []goff.Matchup{
+			matchup("b", "d", 0.0, 0.0, 7.0, 100.0),
+			matchup("a", "c", 0.0, 0.0, 8.0, 6.0),
+		},
+	}
+}
+
+type mockRosterClient struct {
+	mockClient
+	Rosters     map[string][]goff.Player
+	RosterError error
+}
+
+func (m mockRosterClient) GetTeamRosterStats(leagueKey string, teamKey string, week int) ([]goff.Player, error) {
+	return m.Rosters[teamKey], m.RosterError
+}
+
+type mockDivisionClient struct {
+	mockClient
+	Divisions      []Division
+	DivisionsError error
+}
+
+func (m mockDivisionClient) GetDivisions(leagueKey string) ([]Division, error) {
+	return m.Divisions, m.DivisionsError
+}
+
+type mockCategoryClient struct {
+	mockClient
+	Categories []StatCategory
+	StatLines  map[int][]TeamStatLine
+}
+
+func (m mockCategoryClient) GetStatCategories(leagueKey string) ([]StatCategory, error) {
+	return m.Categories, nil
+}
+
+func (m mockCategoryClient) GetTeamStatLines(leagueKey string, week int) ([]TeamStatLine, error) {
+	return m.StatLines[week], nil
+}
+
+func mockCategoryStats() ([]StatCategory, []TeamStatLine) {
+	categories := []StatCategory{
+		StatCategory{ID: "7", Name: "R"},
+		StatCategory{ID: "26", Name: "ERA", LowerIsBetter: true},
+	}
+	statLines := []TeamStatLine{
+		TeamStatLine{
+			Team:  goff.Team{TeamKey: "a"},
+			Stats: map[string]float64{"7": 10.0, "26": 3.0},
+		},
+		TeamStatLine{
+			Team:  goff.Team{TeamKey: "b"},
+			Stats: map[string]float64{"7": 8.0, "26": 2.0},
+		},
+		TeamStatLine{
+			Team:  goff.Team{TeamKey: "c"},
+			Stats: map[string]float64{"7": 10.0, "26": 4.0},
+		},
+		// Teams without stats aren't compared
+		TeamStatLine{
+			Team: goff.Team{TeamKey: "d"},
+		},
+	}
+	return categories, statLines
+}
+
+type mockCountingClient struct {
+	mockClient
+	Calls   *int32
+	Barrier *sync.WaitGroup
+}
+
+func (m mockCountingClient) GetAllTeamStats(leagueKey string, week int, projected bool) ([]goff.Team, error) {
+	atomic.AddInt32(m.Calls, 1)
+	if m.Barrier != nil {
+		m.Barrier.Done()
+		m.Barrier.Wait()
+	}
+	return m.mockClient.GetAllTeamStats(leagueKey, week, projected)
+}
+
+// mockCancellingClient cancels a context when the league's standings are
+// requested, and counts any other request
+type mockCancellingClient struct {
+	mockCountingClient
+	Cancel context.CancelFunc
+}
+
+func (m mockCancellingClient) GetLeagueStandings(leagueKey string) (*goff.League, error) {
+	m.Cancel()
+	return m.mockClient.GetLeagueStandings(leagueKey)
+}
+
+func (m mockCancellingClient) GetMatchupsForWeekRange(leagueKey string, startWeek, endWeek int) (map[int][]goff.Matchup, error) {
+	atomic.AddInt32(m.Calls, 1)
+	return m.mockClient.GetMatchupsForWeekRange(leagueKey, startWeek, endWeek)
+}
+
+// assertNoGoroutinesRemain waits for the number of goroutines to return to
+// what it was before a test started
+func assertNoGoroutinesRemain(t *testing.T, goroutines int) {
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > goroutines {
+		if time.Now().After(deadline) {
+			t.Fatalf("Goroutines remain after getting power data\n\t"+
+				"Expected: %d\n\tActual: %d",
+				goroutines,
+				runtime.NumGoroutine())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// mockSeasonScheme gives every team a point each week and ranks teams in
+// reverse order of their names
+type mockSeasonScheme struct {
+}
+
+func (m mockSeasonScheme) ID() string {
+	return "mock-season"
+}
+
+func (m mockSeasonScheme) DisplayName() string {
+	return "Mock Season"
+}
+
+func (m mockSeasonScheme) Type() string {
+	return Types.SCORE
+}
+
+func (m mockSeasonScheme) CalculateWeeklyRankings(
+	week int,
+	teams []goff.Team,
+	projected bool,
+	results chan *WeeklyRanking) {
+}
+
+func (m mockSeasonScheme) CalculateSeasonRankings(
+	week int,
+	season *Season,
+	state SchemeState) (*WeeklyRanking, SchemeState) {
+
+	teams := season.WeekTeams(week)
+	sort.Slice(teams, func(i, j int) bool { return teams[i].Name > teams[j].Name })
+	ranking := &WeeklyRanking{
+		Scheme:    m,
+		Week:      week,
+		Projected: season.Projected(week),
+		Standings: make(map[string]*TeamRankingData),
+	}
+	for i := range teams {
+		ranking.Rankings = append(ranking.Rankings, &TeamScoreData{
+			Team:       &teams[i],
+			Rank:       i + 1,
+			PowerScore: 1.0,
+			Record:     &goff.Record{},
+		})
+		ranking.Standings[teams[i].TeamKey] = &TeamRankingData{
+			Week:   week,
+			Rank:   i + 1,
+			Score:  float64(week),
+			Record: &goff.Record{},
+		}
+	}
+	return ranking, nil
+}
@@ -0,0 +1,194 @@
+package rankings
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/Forestmb/goff"
+)
+
+//
+// Configuration variables
+//
+
+// MaxConcurrentRequests is the most requests for weekly data that are made to
+// the fantasy sports provider at the same time when calculating power rankings
+var MaxConcurrentRequests = 8
+
+//
+// Data structures
+//
+
+// PowerDataErrors contains every error that occurred while getting the data
+// for a league's power rankings
+type PowerDataErrors []error
+
+func (e PowerDataErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Is returns whether any of the errors is the target, for use with errors.Is
+func (e PowerDataErrors) Is(target error) bool {
+	for _, err := range e {
+		if err == target {
+			return true
+		}
+	}
+	return false
+}
+
+// requestGroup runs functions that make requests to the fantasy sports
+// provider, limiting how many run at the same time. The first error cancels
+// the group's context so functions that haven't started yet are skipped.
+type requestGroup struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	limit  chan struct{}
+	wg     sync.WaitGroup
+
+	mutex  sync.Mutex
+	errors PowerDataErrors
+}
+
+//
+// Functions
+//
+
+// newRequestGroup creates a group that is cancelled along with the given
+// context
+func newRequestGroup(ctx context.Context, maxConcurrent int) *requestGroup {
+	if maxConcurrent < 1 {
+		maxConcurrent = 1
+	}
+	groupCtx, cancel := context.WithCancel(ctx)
+	return &requestGroup{
+		ctx:    groupCtx,
+		cancel: cancel,
+		limit:  make(chan struct{}, maxConcurrent),
+	}
+}
+
+// Go runs the function in a new goroutine once there are fewer than the
+// maximum number of functions running. The function may send a single error
+// on the given channel before it returns.
+func (g *requestGroup) Go(f func(errors chan error)) {
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		select {
+		case g.limit <- struct{}{}:
+		case <-g.ctx.Done():
+			return
+		}
+		defer func() { <-g.limit }()
+		if g.ctx.Err() != nil {
+			return
+		}
+
+		errors := make(chan error, 1)
+		f(errors)
+		select {
+		case err := <-errors:
+			g.mutex.Lock()
+			g.errors = append(g.errors, err)
+			g.mutex.Unlock()
+			g.cancel()
+		default:
+		}
+	}()
+}
+
+// Wait for every function in the group to return, then return the errors
+// that occurred. A single error is returned as is.
+func (g *requestGroup) Wait() error {
+	g.wg.Wait()
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	switch len(g.errors) {
+	case 0:
+		return nil
+	case 1:
+		return g.errors[0]
+	}
+	errors := make(PowerDataErrors, len(g.errors))
+	copy(errors, g.errors)
+	return errors
+}
+
+// contextClient stops making requests to the fantasy sports provider once its
+// context is done, returning the context's error instead. It implements every
+// optional client interface, which must only be used when the wrapped client
+// does.
+type contextClient struct {
+	client PowerRankingsClient
+	ctx    context.Context
+}
+
+func (c *contextClient) GetAllTeamStats(
+	leagueKey string,
+	week int,
+	projected bool) ([]goff.Team, error) {
+
+	if err := c.ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.client.GetAllTeamStats(leagueKey, week, projected)
+}
+
+func (c *contextClient) GetMatchupsForWeekRange(
+	leagueKey string,
+	startWeek int,
+	endWeek int) (map[int][]goff.Matchup, error) {
+
+	if err := c.ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.client.GetMatchupsForWeekRange(leagueKey, startWeek, endWeek)
+}
+
+func (c *contextClient) GetLeagueStandings(leagueKey string) (*goff.League, error) {
+	if err := c.ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.client.GetLeagueStandings(leagueKey)
+}
+
+func (c *contextClient) GetTeamRosterStats(
+	leagueKey string,
+	teamKey string,
+	week int) ([]goff.Player, error) {
+
+	if err := c.ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.client.(RosterClient).GetTeamRosterStats(leagueKey, teamKey, week)
+}
+
+func (c *contextClient) GetStatCategories(leagueKey string) ([]StatCategory, error) {
+	if err := c.ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.client.(CategoryClient).GetStatCategories(leagueKey)
+}
+
+func (c *contextClient) GetTeamStatLines(
+	leagueKey string,
+	week int) ([]TeamStatLine, error) {
+
+	if err := c.ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.client.(CategoryClient).GetTeamStatLines(leagueKey, week)
+}
+
+func (c *contextClient) GetDivisions(leagueKey string) ([]Division, error) {
+	if err := c.ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.client.(DivisionClient).GetDivisions(leagueKey)
+}
@@ -0,0 +1,148 @@
+package rankings
+
+import (
+	"github.com/Forestmb/goff"
+	"github.com/golang/glog"
+)
+
+//
+// Data structures
+//
+
+// ScheduleSwap describes the record every team in a league would have if
+// they had played the schedule of every other team in the league.
+type ScheduleSwap struct {
+	// Teams in the order of both the rows and the columns of Records
+	Teams []*goff.Team
+
+	// Records[i][j] is the record of Teams[i] using the fantasy scores of
+	// Teams[i] against the opponents of Teams[j]. Records[i][i] is the actual
+	// record of Teams[i].
+	Records [][]*goff.Record
+
+	// Weeks is the number of weeks used to calculate the records
+	Weeks int
+}
+
+// weeklySchedule contains the fantasy score of each team and who they played
+// for a single week
+type weeklySchedule struct {
+	Scores    map[string]float64
+	Opponents map[string]string
+}
+
+//
+// Functions
+//
+
+// GetScheduleSwap returns the records each team in a league would have had
+// with every other team's schedule, up to the given week. Only the regular
+// season is used, since not every team plays a matchup during the playoffs.
+func GetScheduleSwap(client PowerRankingsClient, l *goff.League, currentWeek int) (*ScheduleSwap, error) {
+	calendar, err := FindSeasonCalendar(client, l)
+	if err != nil {
+		return nil, err
+	}
+	client = calendar.wrapClient(client)
+	leagueKey := l.LeagueKey
+	league, err := client.GetLeagueStandings(leagueKey)
+	if err != nil {
+		return nil, err
+	}
+
+	var allMatchups map[int][]goff.Matchup
+	lastWeek := lastWeekMatchupsAreAvailable(currentWeek, league)
+	if lastWeek > 0 {
+		glog.V(2).Infof("getting weekly matchups -- weekStart=%d, weekEnd=%d",
+			1,
+			lastWeek)
+		allMatchups, err = client.GetMatchupsForWeekRange(leagueKey, 1, lastWeek)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return CalculateScheduleSwap(league.Standings, allMatchups, lastWeek), nil
+}
+
+// CalculateScheduleSwap replays the fantasy scores of each team against the
+// opponents of every other team for each week up to the last week. When a
+// team would play itself under another team's schedule, it plays the owner of
+// that schedule instead.
+func CalculateScheduleSwap(
+	standings []goff.Team,
+	allMatchups map[int][]goff.Matchup,
+	lastWeek int) *ScheduleSwap {
+
+	teams := getMatchupTeams(
+		&goff.League{Standings: standings},
+		allMatchups,
+		lastWeek)
+	indexByTeamKey := make(map[string]int)
+	swap := &ScheduleSwap{
+		Teams:   make([]*goff.Team, len(teams)),
+		Records: make([][]*goff.Record, len(teams)),
+		Weeks:   lastWeek,
+	}
+	for i := range teams {
+		swap.Teams[i] = &teams[i]
+		swap.Records[i] = make([]*goff.Record, len(teams))
+		for j := range teams {
+			swap.Records[i][j] = &goff.Record{}
+		}
+		indexByTeamKey[teams[i].TeamKey] = i
+	}
+
+	for week := 1; week <= lastWeek; week++ {
+		schedule := getWeeklySchedule(allMatchups[week])
+		for _, team := range swap.Teams {
+			score, ok := schedule.Scores[team.TeamKey]
+			if !ok {
+				continue
+			}
+
+			for _, scheduleOwner := range swap.Teams {
+				opponent, ok := schedule.Opponents[scheduleOwner.TeamKey]
+				if !ok {
+					continue
+				}
+				if opponent == team.TeamKey {
+					opponent = scheduleOwner.TeamKey
+				}
+
+				record := swap.Records[indexByTeamKey[team.TeamKey]][indexByTeamKey[scheduleOwner.TeamKey]]
+				opponentScore := schedule.Scores[opponent]
+				if score > opponentScore {
+					record.Wins++
+				} else if score == opponentScore {
+					record.Ties++
+				} else {
+					record.Losses++
+				}
+			}
+		}
+	}
+
+	return swap
+}
+
+// getWeeklySchedule returns the scores and opponents of all teams playing in
+// the given matchups
+func getWeeklySchedule(matchups []goff.Matchup) *weeklySchedule {
+	schedule := &weeklySchedule{
+		Scores:    make(map[string]float64),
+		Opponents: make(map[string]string),
+	}
+	for _, matchup := range matchups {
+		if len(matchup.Teams) != 2 {
+			continue
+		}
+		first := matchup.Teams[0]
+		second := matchup.Teams[1]
+		schedule.Scores[first.TeamKey] = first.TeamPoints.Total
+		schedule.Scores[second.TeamKey] = second.TeamPoints.Total
+		schedule.Opponents[first.TeamKey] = second.TeamKey
+		schedule.Opponents[second.TeamKey] = first.TeamKey
+	}
+	return schedule
+}
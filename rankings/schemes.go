@@ -1,7 +1,12 @@
 package rankings
 
 import (
+	"errors"
+	"fmt"
+	"math"
 	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/Forestmb/goff"
 )
@@ -35,10 +40,52 @@ type Scheme interface {
 		results chan *WeeklyRanking)
 }
 
+// A MatchupScheme is a Scheme that ranks teams using the head-to-head matchups
+// played each week. Unlike CalculateWeeklyRankings, which only sees a single
+// week in isolation, the ratings from the previous week are passed in and the
+// updated ratings are returned so they can carry over to the following week.
+//
+// Every team in the league should be included in the returned rankings, even
+// if they did not play a matchup that week.
+type MatchupScheme interface {
+	Scheme
+	CalculateMatchupRankings(
+		week int,
+		teams []goff.Team,
+		matchups []goff.Matchup,
+		projected bool,
+		ratings TeamRatings) (*WeeklyRanking, TeamRatings)
+}
+
+// A RosterScheme is a Scheme that ranks teams using the players on their
+// rosters. Rosters are only available for weeks that have already been played,
+// so they are nil for projections.
+type RosterScheme interface {
+	Scheme
+	CalculateRosterRankings(
+		week int,
+		teams []goff.Team,
+		rosters map[string][]goff.Player,
+		projected bool) *WeeklyRanking
+}
+
+// A WeightedScheme is a Scheme whose weekly power scores are not all worth the
+// same. A team's overall score through a week is the sum of the power scores
+// from each week up to and including that week, each multiplied by the weight
+// returned for it.
+type WeightedScheme interface {
+	Scheme
+	WeekWeight(week int, throughWeek int) float64
+}
+
 //
 // Data structures
 //
 
+// TeamRatings maps the key of each team to the rating it has been given by a
+// MatchupScheme or SeasonScheme
+type TeamRatings map[string]float64
+
 // TeamRanking ranks teams based on their performance for a single week
 type TeamRanking []goff.Team
 
@@ -82,11 +129,32 @@ func (t TeamProjectedRanking) Swap(i int, j int) {
 
 // GetSchemes returns the supported rankings formats
 func GetSchemes() []Scheme {
-	return []Scheme{
+	return GetSchemesWithCompositeWeights(DefaultCompositeWeights)
+}
+
+// GetSchemesWithCompositeWeights returns the supported rankings formats, with
+// the 'Composite' scheme blending the other schemes using the given weights
+func GetSchemesWithCompositeWeights(weights CompositeWeights) []Scheme {
+	schemes := []Scheme{
 		allPlayRecord{},
 		victoryPoints{},
 		totalPoints{},
+		medianRecord{},
+		eloRating{
+			kFactor:         EloKFactor,
+			marginOfVictory: EloMarginOfVictory,
+		},
+		recencyWeighted{
+			decay:  RecencyDecay,
+			window: RecencyWindow,
+		},
+		pythagorean{exponent: PythagoreanExponent},
+		NewCompositeScheme(weights),
+	}
+	if OptimalLineups {
+		schemes = append(schemes, optimalLineup{})
 	}
+	return schemes
 }
 
 type victoryPoints struct {
@@ -269,6 +337,17 @@ func (a totalPoints) CalculateWeeklyRankings(
 	projected bool,
 	results chan *WeeklyRanking) {
 
+	results <- &WeeklyRanking{
+		Scheme:    a,
+		Week:      week,
+		Rankings:  rankByFantasyScore(teams, projected),
+		Projected: projected,
+	}
+}
+
+// rankByFantasyScore ranks teams by the fantasy points they scored, giving
+// each team a power score equal to its fantasy score
+func rankByFantasyScore(teams []goff.Team, projected bool) []*TeamScoreData {
 	// Sort teams and convert them into TeamScoreData
 	if !projected {
 		sort.Sort(TeamRanking(teams))
@@ -304,11 +383,788 @@ func (a totalPoints) CalculateWeeklyRankings(
 			rankings[i].Rank = i + 1
 		}
 	}
+	return rankings
+}
+
+type medianRecord struct {
+}
+
+func (m medianRecord) ID() string {
+	return "median"
+}
+
+func (m medianRecord) DisplayName() string {
+	return "Median"
+}
+
+func (m medianRecord) Type() string {
+	return Types.RECORD
+}
+
+// CalculateWeeklyRankings for a 'Median' ranking scheme gives each team a win
+// if they score more points than the league median for the week, a loss if
+// they score less, and a tie if they score exactly the median.
+func (m medianRecord) CalculateWeeklyRankings(
+	week int,
+	teams []goff.Team,
+	projected bool,
+	results chan *WeeklyRanking) {
+
+	// Sort teams and convert them into TeamScoreData
+	if !projected {
+		sort.Sort(TeamRanking(teams))
+	} else {
+		sort.Sort(TeamProjectedRanking(teams))
+	}
+
+	rankings := make([]*TeamScoreData, len(teams))
+	for index := range teams {
+		team := &teams[index]
+
+		var score float64
+		if !projected {
+			score = team.TeamPoints.Total
+		} else {
+			score = team.TeamProjectedPoints.Total
+		}
+
+		rankings[index] = &TeamScoreData{
+			Team:         team,
+			FantasyScore: score,
+			Record:       &goff.Record{},
+			Projected:    projected,
+		}
+	}
+
+	median := medianFantasyScore(rankings)
+	for _, team := range rankings {
+		if team.FantasyScore > median {
+			team.Record.Wins++
+		} else if team.FantasyScore == median {
+			team.Record.Ties++
+		} else {
+			team.Record.Losses++
+		}
+	}
+
+	// Update ranks
+	for i := range rankings {
+		if i > 0 && rankings[i].FantasyScore == rankings[i-1].FantasyScore {
+			rankings[i].Rank = rankings[i-1].Rank
+		} else {
+			rankings[i].Rank = i + 1
+		}
+	}
 
 	results <- &WeeklyRanking{
-		Scheme:    a,
+		Scheme:    m,
+		Week:      week,
+		Rankings:  rankings,
+		Projected: projected,
+	}
+}
+
+// medianFantasyScore returns the median fantasy score of teams that are
+// already sorted by their fantasy score in descending order
+func medianFantasyScore(rankings []*TeamScoreData) float64 {
+	count := len(rankings)
+	if count == 0 {
+		return 0.0
+	}
+	middle := count / 2
+	if count%2 == 1 {
+		return rankings[middle].FantasyScore
+	}
+	return (rankings[middle-1].FantasyScore + rankings[middle].FantasyScore) / 2.0
+}
+
+// EloInitialRating is the rating every team starts the season with in the
+// 'Elo Rating' scheme
+const EloInitialRating = 1500.0
+
+type eloRating struct {
+	kFactor         float64
+	marginOfVictory bool
+}
+
+func (e eloRating) ID() string {
+	return "elo"
+}
+
+func (e eloRating) DisplayName() string {
+	return "Elo Rating"
+}
+
+func (e eloRating) Type() string {
+	return Types.SCORE
+}
+
+// CalculateWeeklyRankings for an 'Elo Rating' scheme can't rate teams since
+// a single week does not contain who played who or the ratings from earlier
+// weeks. Every team has the initial rating.
+//
+// See CalculateSeasonRankings
+func (e eloRating) CalculateWeeklyRankings(
+	week int,
+	teams []goff.Team,
+	projected bool,
+	results chan *WeeklyRanking) {
+
+	ranking, _ := e.CalculateSeasonRankings(
+		week,
+		singleWeekSeason(week, teams, projected),
+		nil)
+	results <- ranking
+}
+
+// CalculateSeasonRankings for an 'Elo Rating' scheme updates the rating of
+// each team based on the result of its matchup and the rating of its opponent.
+// The winner of each matchup takes rating points from the loser, with more
+// points exchanged when the result was unexpected. Every team starts with
+// EloInitialRating.
+//
+// The power score for each week is the change in a team's rating, and its
+// standing through the week is its current rating.
+func (e eloRating) CalculateSeasonRankings(
+	week int,
+	season *Season,
+	state SchemeState) (*WeeklyRanking, SchemeState) {
+
+	ratings, _ := state.(TeamRatings)
+	teams := season.WeekTeams(week)
+	projected := season.Projected(week)
+
+	updated := make(TeamRatings)
+	for teamKey, rating := range ratings {
+		updated[teamKey] = rating
+	}
+
+	rankings := make([]*TeamScoreData, len(teams))
+	rankingsByTeamKey := make(map[string]*TeamScoreData)
+	for index := range teams {
+		team := &teams[index]
+		if _, ok := updated[team.TeamKey]; !ok {
+			updated[team.TeamKey] = EloInitialRating
+		}
+		rankings[index] = &TeamScoreData{
+			Team:      team,
+			Record:    &goff.Record{},
+			Projected: projected,
+		}
+		rankingsByTeamKey[team.TeamKey] = rankings[index]
+	}
+
+	for _, matchup := range season.Matchups[week] {
+		if len(matchup.Teams) != 2 {
+			continue
+		}
+		first, firstOK := rankingsByTeamKey[matchup.Teams[0].TeamKey]
+		second, secondOK := rankingsByTeamKey[matchup.Teams[1].TeamKey]
+		if !firstOK || !secondOK {
+			continue
+		}
+		first.FantasyScore = matchupScore(&matchup.Teams[0], projected)
+		second.FantasyScore = matchupScore(&matchup.Teams[1], projected)
+
+		firstRating := ratings[first.Team.TeamKey]
+		if _, ok := ratings[first.Team.TeamKey]; !ok {
+			firstRating = EloInitialRating
+		}
+		secondRating := ratings[second.Team.TeamKey]
+		if _, ok := ratings[second.Team.TeamKey]; !ok {
+			secondRating = EloInitialRating
+		}
+
+		change := e.ratingChange(
+			firstRating,
+			secondRating,
+			first.FantasyScore,
+			second.FantasyScore)
+		first.PowerScore += change
+		second.PowerScore -= change
+		updated[first.Team.TeamKey] += change
+		updated[second.Team.TeamKey] -= change
+	}
+
+	return rankByRating(e, week, rankings, updated, projected), updated
+}
+
+// ratingChange returns how many rating points the first team gains (or loses,
+// if negative) from the result of a matchup against the second team
+func (e eloRating) ratingChange(
+	rating float64,
+	opponentRating float64,
+	score float64,
+	opponentScore float64) float64 {
+
+	expected := 1.0 / (1.0 + math.Pow(10.0, (opponentRating-rating)/400.0))
+	actual := 0.5
+	if score > opponentScore {
+		actual = 1.0
+	} else if score < opponentScore {
+		actual = 0.0
+	}
+
+	multiplier := 1.0
+	if e.marginOfVictory && score != opponentScore {
+		// Scale by the margin of victory, discounted when the favorite wins
+		// so that ratings don't run away from each other
+		winnerRatingDifference := rating - opponentRating
+		if score < opponentScore {
+			winnerRatingDifference = -winnerRatingDifference
+		}
+		multiplier = math.Log(math.Abs(score-opponentScore)+1.0) *
+			(2.2 / (winnerRatingDifference*0.001 + 2.2))
+	}
+
+	return e.kFactor * multiplier * (actual - expected)
+}
+
+// matchupScore returns the points a team scored, or is projected to score, in
+// a matchup
+func matchupScore(team *goff.Team, projected bool) float64 {
+	if projected {
+		return team.TeamProjectedPoints.Total
+	}
+	return team.TeamPoints.Total
+}
+
+// 'Recency Weighted' scheme
+type recencyWeighted struct {
+	decay  float64
+	window int
+}
+
+func (r recencyWeighted) ID() string {
+	return "recency"
+}
+
+func (r recencyWeighted) DisplayName() string {
+	return "Recency Weighted"
+}
+
+func (r recencyWeighted) Type() string {
+	return Types.SCORE
+}
+
+// CalculateWeeklyRankings for a 'Recency Weighted' scheme gives each team
+// points based on how many fantasy points they scored, the same as 'Total
+// Points'. The difference is in how those points add up over the season.
+//
+// See WeekWeight
+func (r recencyWeighted) CalculateWeeklyRankings(
+	week int,
+	teams []goff.Team,
+	projected bool,
+	results chan *WeeklyRanking) {
+
+	results <- &WeeklyRanking{
+		Scheme:    r,
+		Week:      week,
+		Rankings:  rankByFantasyScore(teams, projected),
+		Projected: projected,
+	}
+}
+
+// WeekWeight for a 'Recency Weighted' scheme only counts the most recent weeks
+// when a window is set. Otherwise, each week is worth the decay factor times
+// the week after it.
+func (r recencyWeighted) WeekWeight(week int, throughWeek int) float64 {
+	weeksAgo := throughWeek - week
+	if r.window > 0 {
+		if weeksAgo < r.window {
+			return 1.0
+		}
+		return 0.0
+	}
+	return math.Pow(r.decay, float64(weeksAgo))
+}
+
+// 'Composite' scheme
+
+// CompositeRecordComponent is the ID used to weight a team's actual
+// head-to-head record in the 'Composite' scheme
+const CompositeRecordComponent = "record"
+
+// CompositeWeights maps the ID of each scheme blended into the 'Composite'
+// scheme to how much it is worth
+type CompositeWeights map[string]float64
+
+type compositeScheme struct {
+	weights CompositeWeights
+}
+
+// NewCompositeScheme creates a 'Composite' scheme that blends the weekly
+// results of other schemes using the given weights
+func NewCompositeScheme(weights CompositeWeights) MatchupScheme {
+	return compositeScheme{weights: weights}
+}
+
+// ParseCompositeWeights reads weights in the format returned by
+// CompositeWeights.String, e.g. "all-play:50,total-points:30,record:20"
+func ParseCompositeWeights(value string) (CompositeWeights, error) {
+	components := make(map[string]bool)
+	for _, scheme := range compositeComponents() {
+		components[scheme.ID()] = true
+	}
+	components[CompositeRecordComponent] = true
+
+	weights := make(CompositeWeights)
+	total := 0.0
+	for _, part := range strings.Split(value, ",") {
+		pieces := strings.Split(strings.TrimSpace(part), ":")
+		if len(pieces) != 2 {
+			return nil, fmt.Errorf("invalid composite weight '%s'", part)
+		}
+		id := strings.TrimSpace(pieces[0])
+		if !components[id] {
+			return nil, fmt.Errorf("unknown composite component '%s'", id)
+		}
+		weight, err := strconv.ParseFloat(strings.TrimSpace(pieces[1]), 64)
+		if err != nil || weight < 0 || math.IsInf(weight, 0) || math.IsNaN(weight) {
+			return nil, fmt.Errorf("invalid weight for composite component '%s'", id)
+		}
+		weights[id] += weight
+		total += weight
+	}
+	if total == 0 {
+		return nil, errors.New("composite weights must not all be zero")
+	}
+	return weights, nil
+}
+
+// String returns the weights in the format read by ParseCompositeWeights,
+// ordered by component ID
+func (w CompositeWeights) String() string {
+	var ids []string
+	for id := range w {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		parts[i] = id + ":" + strconv.FormatFloat(w[id], 'f', -1, 64)
+	}
+	return strings.Join(parts, ",")
+}
+
+// compositeComponents returns the schemes that can be blended into the
+// 'Composite' scheme. Schemes that depend on more than a single week are
+// excluded.
+func compositeComponents() []Scheme {
+	return []Scheme{
+		allPlayRecord{},
+		victoryPoints{},
+		totalPoints{},
+		medianRecord{},
+	}
+}
+
+func (c compositeScheme) ID() string {
+	return "composite"
+}
+
+func (c compositeScheme) DisplayName() string {
+	return "Composite"
+}
+
+func (c compositeScheme) Type() string {
+	return Types.SCORE
+}
+
+// CalculateWeeklyRankings for a 'Composite' scheme can't use the actual
+// records of teams, since a single week does not contain who played who. Only
+// the other components are used.
+//
+// See CalculateMatchupRankings
+func (c compositeScheme) CalculateWeeklyRankings(
+	week int,
+	teams []goff.Team,
+	projected bool,
+	results chan *WeeklyRanking) {
+
+	var matchups []goff.Matchup
+	if len(teams) > 0 {
+		matchups = []goff.Matchup{goff.Matchup{Week: week, Teams: teams}}
+	}
+	ranking, _ := c.CalculateMatchupRankings(week, teams, matchups, projected, nil)
+	results <- ranking
+}
+
+// CalculateMatchupRankings for a 'Composite' scheme normalizes the results of
+// each component scheme for the week to a value between 0 and 1 and gives
+// each team a power score of up to 100 points from the weighted average of
+// those values. Record schemes use win percentage and score schemes use the
+// percentage of the highest power score that week. The 'record' component is
+// 1 for a win, 0.5 for a tie, and 0 for a loss in the team's actual matchup.
+//
+// Teams that did not play in a matchup that week get no points.
+func (c compositeScheme) CalculateMatchupRankings(
+	week int,
+	teams []goff.Team,
+	matchups []goff.Matchup,
+	projected bool,
+	ratings TeamRatings) (*WeeklyRanking, TeamRatings) {
+
+	totalWeight := 0.0
+	for _, weight := range c.weights {
+		totalWeight += weight
+	}
+
+	var weekTeams []goff.Team
+	for _, matchup := range matchups {
+		weekTeams = append(weekTeams, matchup.Teams...)
+	}
+
+	fantasyScores := make(map[string]float64)
+	for index := range weekTeams {
+		team := &weekTeams[index]
+		fantasyScores[team.TeamKey] = matchupScore(team, projected)
+	}
+
+	scores := make(map[string]float64)
+	if totalWeight > 0 && len(weekTeams) > 0 {
+		for _, scheme := range compositeComponents() {
+			weight := c.weights[scheme.ID()] / totalWeight
+			if weight == 0 {
+				continue
+			}
+
+			teamsForScheme := make([]goff.Team, len(weekTeams))
+			copy(teamsForScheme, weekTeams)
+			results := make(chan *WeeklyRanking, 1)
+			scheme.CalculateWeeklyRankings(week, teamsForScheme, projected, results)
+			for teamKey, value := range normalizeWeeklyRanking(scheme, <-results) {
+				scores[teamKey] += 100.0 * weight * value
+			}
+		}
+
+		weight := c.weights[CompositeRecordComponent] / totalWeight
+		for _, matchup := range matchups {
+			if weight == 0 || len(matchup.Teams) != 2 {
+				continue
+			}
+			first := matchup.Teams[0].TeamKey
+			second := matchup.Teams[1].TeamKey
+			if fantasyScores[first] > fantasyScores[second] {
+				scores[first] += 100.0 * weight
+			} else if fantasyScores[first] < fantasyScores[second] {
+				scores[second] += 100.0 * weight
+			} else {
+				scores[first] += 50.0 * weight
+				scores[second] += 50.0 * weight
+			}
+		}
+	}
+
+	rankings := make([]*TeamScoreData, len(teams))
+	for index := range teams {
+		team := &teams[index]
+		rankings[index] = &TeamScoreData{
+			Team:         team,
+			FantasyScore: fantasyScores[team.TeamKey],
+			PowerScore:   scores[team.TeamKey],
+			Record:       &goff.Record{},
+			Projected:    projected,
+		}
+	}
+
+	// Sort teams by their composite score and assign ranks
+	sort.SliceStable(rankings, func(i, j int) bool {
+		return rankings[i].PowerScore > rankings[j].PowerScore
+	})
+	for i := range rankings {
+		if i > 0 && rankings[i].PowerScore == rankings[i-1].PowerScore {
+			rankings[i].Rank = rankings[i-1].Rank
+		} else {
+			rankings[i].Rank = i + 1
+		}
+	}
+
+	return &WeeklyRanking{
+		Scheme:    c,
+		Week:      week,
+		Rankings:  rankings,
+		Projected: projected,
+	}, ratings
+}
+
+// normalizeWeeklyRanking converts the results of a scheme for a single week
+// to a value between 0 and 1 for each team
+func normalizeWeeklyRanking(scheme Scheme, ranking *WeeklyRanking) map[string]float64 {
+	values := make(map[string]float64)
+	if scheme.Type() == Types.RECORD {
+		for _, teamScore := range ranking.Rankings {
+			values[teamScore.Team.TeamKey] = winPercentage(teamScore.Record)
+		}
+		return values
+	}
+
+	maxScore := 0.0
+	for _, teamScore := range ranking.Rankings {
+		maxScore = math.Max(maxScore, teamScore.PowerScore)
+	}
+	for _, teamScore := range ranking.Rankings {
+		if maxScore > 0 {
+			values[teamScore.Team.TeamKey] = math.Max(teamScore.PowerScore, 0) / maxScore
+		}
+	}
+	return values
+}
+
+// 'Optimal Lineup' scheme
+type optimalLineup struct {
+}
+
+func (o optimalLineup) ID() string {
+	return "optimal-lineup"
+}
+
+func (o optimalLineup) DisplayName() string {
+	return "Optimal Lineup"
+}
+
+func (o optimalLineup) Type() string {
+	return Types.SCORE
+}
+
+// CalculateWeeklyRankings for an 'Optimal Lineup' scheme doesn't have the
+// rosters of each team, so it assumes each team started its best lineup.
+//
+// See CalculateRosterRankings
+func (o optimalLineup) CalculateWeeklyRankings(
+	week int,
+	teams []goff.Team,
+	projected bool,
+	results chan *WeeklyRanking) {
+
+	results <- o.CalculateRosterRankings(week, teams, nil, projected)
+}
+
+// CalculateRosterRankings for an 'Optimal Lineup' scheme gives each team
+// points based on the most fantasy points it could have scored if it had
+// started the best possible lineup from the players on its roster. The
+// fantasy score of each team is the points scored by the lineup it actually
+// started.
+//
+// Teams without a roster use their team score for both.
+func (o optimalLineup) CalculateRosterRankings(
+	week int,
+	teams []goff.Team,
+	rosters map[string][]goff.Player,
+	projected bool) *WeeklyRanking {
+
+	lineups := GetLineups(rosters)
+	rankings := make([]*TeamScoreData, len(teams))
+	for index := range teams {
+		team := &teams[index]
+		actual := matchupScore(team, projected)
+		optimal := actual
+		if lineup, ok := lineups[team.TeamKey]; ok {
+			actual = lineup.ActualPoints
+			optimal = lineup.OptimalPoints
+		}
+		rankings[index] = &TeamScoreData{
+			Team:         team,
+			FantasyScore: actual,
+			PowerScore:   optimal,
+			Record:       &goff.Record{},
+			Projected:    projected,
+		}
+	}
+
+	sort.SliceStable(rankings, func(i, j int) bool {
+		return rankings[i].PowerScore > rankings[j].PowerScore
+	})
+	for i := range rankings {
+		if i > 0 && rankings[i].PowerScore == rankings[i-1].PowerScore {
+			rankings[i].Rank = rankings[i-1].Rank
+		} else {
+			rankings[i].Rank = i + 1
+		}
+	}
+
+	return &WeeklyRanking{
+		Scheme:    o,
+		Week:      week,
+		Rankings:  rankings,
+		Projected: projected,
+	}
+}
+
+// 'Pythagorean Expectation' scheme
+type pythagorean struct {
+	exponent float64
+}
+
+func (p pythagorean) ID() string {
+	return "pythagorean"
+}
+
+func (p pythagorean) DisplayName() string {
+	return "Pythagorean Expectation"
+}
+
+func (p pythagorean) Type() string {
+	return Types.SCORE
+}
+
+// CalculateWeeklyRankings for a 'Pythagorean Expectation' scheme can't find
+// the points scored against each team since a single week does not contain
+// who played who. Every team is expected to win half of its games.
+//
+// See CalculateSeasonRankings
+func (p pythagorean) CalculateWeeklyRankings(
+	week int,
+	teams []goff.Team,
+	projected bool,
+	results chan *WeeklyRanking) {
+
+	ranking, _ := p.CalculateSeasonRankings(
+		week,
+		singleWeekSeason(week, teams, projected),
+		nil)
+	results <- ranking
+}
+
+// CalculateSeasonRankings for a 'Pythagorean Expectation' scheme keeps track
+// of the total points scored for and against each team in its matchups. The
+// expected win percentage of a team is:
+//
+//	pointsFor^exponent / (pointsFor^exponent + pointsAgainst^exponent)
+//
+// The power score for each week is the change in a team's expected win
+// percentage, and its standing through the week is its current expectation.
+func (p pythagorean) CalculateSeasonRankings(
+	week int,
+	season *Season,
+	state SchemeState) (*WeeklyRanking, SchemeState) {
+
+	previous, _ := state.(*pointTotals)
+	if previous == nil {
+		previous = &pointTotals{}
+	}
+	totals := &pointTotals{
+		For:     make(map[string]float64),
+		Against: make(map[string]float64),
+	}
+	for teamKey, points := range previous.For {
+		totals.For[teamKey] = points
+	}
+	for teamKey, points := range previous.Against {
+		totals.Against[teamKey] = points
+	}
+
+	projected := season.Projected(week)
+	fantasyScores := make(map[string]float64)
+	for _, matchup := range season.Matchups[week] {
+		if len(matchup.Teams) != 2 {
+			continue
+		}
+		first := matchup.Teams[0].TeamKey
+		second := matchup.Teams[1].TeamKey
+		firstScore := matchupScore(&matchup.Teams[0], projected)
+		secondScore := matchupScore(&matchup.Teams[1], projected)
+		fantasyScores[first] = firstScore
+		fantasyScores[second] = secondScore
+
+		totals.For[first] += firstScore
+		totals.Against[first] += secondScore
+		totals.For[second] += secondScore
+		totals.Against[second] += firstScore
+	}
+
+	teams := season.WeekTeams(week)
+	rankings := make([]*TeamScoreData, len(teams))
+	expectations := make(TeamRatings)
+	for index := range teams {
+		team := &teams[index]
+		current := p.expectation(totals.For[team.TeamKey], totals.Against[team.TeamKey])
+		expectations[team.TeamKey] = current
+		rankings[index] = &TeamScoreData{
+			Team:         team,
+			FantasyScore: fantasyScores[team.TeamKey],
+			PowerScore: current - p.expectation(
+				previous.For[team.TeamKey],
+				previous.Against[team.TeamKey]),
+			Record:    &goff.Record{},
+			Projected: projected,
+		}
+	}
+
+	return rankByRating(p, week, rankings, expectations, projected), totals
+}
+
+// expectation returns the expected win percentage of a team with the given
+// total points for and against. Teams that haven't scored or allowed any
+// points are expected to win half of their games.
+func (p pythagorean) expectation(pointsFor float64, pointsAgainst float64) float64 {
+	if pointsFor <= 0 && pointsAgainst <= 0 {
+		return 0.5
+	}
+	pointsFor = math.Pow(math.Max(pointsFor, 0), p.exponent)
+	pointsAgainst = math.Pow(math.Max(pointsAgainst, 0), p.exponent)
+	return pointsFor / (pointsFor + pointsAgainst)
+}
+
+// pointTotals are the total points scored for and against each team
+type pointTotals struct {
+	For     map[string]float64
+	Against map[string]float64
+}
+
+// singleWeekSeason returns a season containing only the given week, for
+// schemes that use the whole season to rank a single week of teams
+func singleWeekSeason(week int, teams []goff.Team, projected bool) *Season {
+	currentWeek := week
+	if projected {
+		currentWeek = week - 1
+	}
+	return &Season{Teams: teams, CurrentWeek: currentWeek, EndWeek: week}
+}
+
+// rankByRating orders the rankings of a week by the rating of each team and
+// assigns ranks, sharing a rank between teams with the same rating. The
+// standing of each team through the week is its rating.
+func rankByRating(
+	scheme Scheme,
+	week int,
+	rankings []*TeamScoreData,
+	ratings TeamRatings,
+	projected bool) *WeeklyRanking {
+
+	sort.SliceStable(rankings, func(i, j int) bool {
+		iRating := ratings[rankings[i].Team.TeamKey]
+		jRating := ratings[rankings[j].Team.TeamKey]
+		if iRating == jRating {
+			return rankings[i].Team.Name < rankings[j].Team.Name
+		}
+		return iRating > jRating
+	})
+
+	standings := make(map[string]*TeamRankingData)
+	for i, teamScore := range rankings {
+		teamKey := teamScore.Team.TeamKey
+		if i > 0 && ratings[teamKey] == ratings[rankings[i-1].Team.TeamKey] {
+			teamScore.Rank = rankings[i-1].Rank
+		} else {
+			teamScore.Rank = i + 1
+		}
+		standings[teamKey] = &TeamRankingData{
+			Week:      week,
+			Rank:      teamScore.Rank,
+			Score:     ratings[teamKey],
+			Record:    &goff.Record{},
+			Projected: projected,
+		}
+	}
+
+	return &WeeklyRanking{
+		Scheme:    scheme,
 		Week:      week,
 		Rankings:  rankings,
 		Projected: projected,
+		Standings: standings,
 	}
 }
@@ -0,0 +1,284 @@
+package rankings
+
+import (
+	"sort"
+
+	"github.com/Forestmb/goff"
+)
+
+//
+// Interface
+//
+
+// A SeasonScheme is a Scheme that ranks teams using the entire season. Each
+// week it is given the matchups of every week in the season along with the
+// state it returned for the previous week, which is nil for the first week.
+//
+// Unlike other schemes, a SeasonScheme decides how a team's results combine
+// into its standing through each week and how teams with the same standing
+// are ordered. The returned ranking must include the standing of every team
+// in the league through the week, with ranks assigned.
+type SeasonScheme interface {
+	Scheme
+	CalculateSeasonRankings(
+		week int,
+		season *Season,
+		state SchemeState) (*WeeklyRanking, SchemeState)
+}
+
+//
+// Data structures
+//
+
+// SchemeState is carried over by a SeasonScheme from one week to the next.
+// Its contents are only known to the scheme that created it.
+type SchemeState interface{}
+
+// Season contains every team in a league and the matchups they played, or are
+// projected to play, each week
+type Season struct {
+	Teams       []goff.Team
+	Matchups    map[int][]goff.Matchup
+	CurrentWeek int
+	EndWeek     int
+}
+
+// Projected returns whether the results of a week are projections
+func (s *Season) Projected(week int) bool {
+	return week > s.CurrentWeek
+}
+
+// WeekTeams returns a copy of every team in the league, with the points each
+// team scored, or is projected to score, in its matchup for the given week.
+// Teams that did not play a matchup that week have no points.
+func (s *Season) WeekTeams(week int) []goff.Team {
+	matchupTeams := make(map[string]goff.Team)
+	for _, matchup := range s.Matchups[week] {
+		for _, team := range matchup.Teams {
+			matchupTeams[team.TeamKey] = team
+		}
+	}
+
+	teams := make([]goff.Team, len(s.Teams))
+	copy(teams, s.Teams)
+	for index := range teams {
+		team := &teams[index]
+		matchupTeam := matchupTeams[team.TeamKey]
+		team.TeamPoints = matchupTeam.TeamPoints
+		team.TeamProjectedPoints = matchupTeam.TeamProjectedPoints
+	}
+	return teams
+}
+
+// weeklySeasonScheme adapts a Scheme that ranks a single week at a time
+type weeklySeasonScheme struct {
+	Scheme
+}
+
+// matchupSeasonScheme adapts a MatchupScheme, carrying its ratings over from
+// one week to the next
+type matchupSeasonScheme struct {
+	MatchupScheme
+}
+
+// adaptedSeasonState is the state of a scheme adapted to be a SeasonScheme:
+// the ratings of a MatchupScheme, and every weekly result so far
+type adaptedSeasonState struct {
+	Ratings   TeamRatings
+	AllScores map[string][]*TeamScoreData
+}
+
+//
+// Functions
+//
+
+// GetSeasonScheme returns a scheme that ranks teams using the entire season.
+// Schemes that only rank a single week at a time are adapted so that each
+// team's standing is the sum of its weekly power scores, weighted for a
+// WeightedScheme, or the sum of its weekly records.
+func GetSeasonScheme(scheme Scheme) SeasonScheme {
+	if seasonScheme, ok := scheme.(SeasonScheme); ok {
+		return seasonScheme
+	}
+	if matchupScheme, ok := scheme.(MatchupScheme); ok {
+		return matchupSeasonScheme{MatchupScheme: matchupScheme}
+	}
+	return weeklySeasonScheme{Scheme: scheme}
+}
+
+// GetSeasonRankings ranks teams for every week of a season using a scheme
+// that carries its state over from one week to the next
+func GetSeasonRankings(
+	scheme SeasonScheme,
+	season *Season,
+	results chan *WeeklyRanking) {
+
+	var state SchemeState
+	for week := 1; week <= season.EndWeek; week++ {
+		var weeklyRanking *WeeklyRanking
+		weeklyRanking, state = scheme.CalculateSeasonRankings(week, season, state)
+		results <- weeklyRanking
+	}
+}
+
+func (w weeklySeasonScheme) CalculateSeasonRankings(
+	week int,
+	season *Season,
+	state SchemeState) (*WeeklyRanking, SchemeState) {
+
+	results := make(chan *WeeklyRanking, 1)
+	w.CalculateWeeklyRankings(
+		week,
+		season.WeekTeams(week),
+		season.Projected(week),
+		results)
+	previous, _ := state.(*adaptedSeasonState)
+	return addAdaptedStandings(w.Scheme, <-results, previous, nil)
+}
+
+func (m matchupSeasonScheme) CalculateSeasonRankings(
+	week int,
+	season *Season,
+	state SchemeState) (*WeeklyRanking, SchemeState) {
+
+	previous, _ := state.(*adaptedSeasonState)
+	var ratings TeamRatings
+	if previous != nil {
+		ratings = previous.Ratings
+	}
+	weeklyRanking, ratings := m.CalculateMatchupRankings(
+		week,
+		season.WeekTeams(week),
+		season.Matchups[week],
+		season.Projected(week),
+		ratings)
+	return addAdaptedStandings(m.MatchupScheme, weeklyRanking, previous, ratings)
+}
+
+// addAdaptedStandings adds the standing of each team through the week to the
+// weekly ranking of an adapted scheme, and returns the state for the next week
+func addAdaptedStandings(
+	scheme Scheme,
+	weeklyRanking *WeeklyRanking,
+	previous *adaptedSeasonState,
+	ratings TeamRatings) (*WeeklyRanking, SchemeState) {
+
+	state := &adaptedSeasonState{
+		Ratings:   ratings,
+		AllScores: make(map[string][]*TeamScoreData),
+	}
+	if previous != nil {
+		for teamKey, scores := range previous.AllScores {
+			state.AllScores[teamKey] = scores
+		}
+	}
+
+	weightedScheme, isWeighted := scheme.(WeightedScheme)
+	standings := make([]*TeamRankingData, len(weeklyRanking.Rankings))
+	weeklyRanking.Standings = make(map[string]*TeamRankingData)
+	for i, teamScore := range weeklyRanking.Rankings {
+		teamKey := teamScore.Team.TeamKey
+		allScores := append(
+			append([]*TeamScoreData{}, state.AllScores[teamKey]...),
+			teamScore)
+		state.AllScores[teamKey] = allScores
+
+		standing := &TeamRankingData{
+			Week:      weeklyRanking.Week,
+			Record:    &goff.Record{},
+			Projected: teamScore.Projected,
+		}
+		for _, score := range allScores {
+			addRecord(standing.Record, score.Record)
+			if !isWeighted {
+				standing.Score += score.PowerScore
+			}
+		}
+		if isWeighted {
+			standing.Score = weightedScore(weightedScheme, allScores, len(allScores))
+		}
+		standings[i] = standing
+		weeklyRanking.Standings[teamKey] = standing
+	}
+	rankStandings(scheme, standings)
+	return weeklyRanking, state
+}
+
+// addWeeklyStandings adds the standing of each team through every week to the
+// weekly rankings of a scheme that ranks a single week at a time, like the
+// scheme returned by GetSeasonScheme
+func addWeeklyStandings(scheme Scheme, weeklyRankings []*WeeklyRanking) {
+	var state SchemeState
+	for _, weeklyRanking := range weeklyRankings {
+		previous, _ := state.(*adaptedSeasonState)
+		_, state = addAdaptedStandings(scheme, weeklyRanking, previous, nil)
+	}
+}
+
+// rankStandings assigns ranks to the standings of every team through a week.
+// Record schemes are ordered by wins, then ties, and score schemes by score.
+// Teams with the same record or score share a rank.
+func rankStandings(scheme Scheme, standings []*TeamRankingData) {
+	if scheme.Type() == Types.RECORD {
+		sort.Sort(RecordSortedTeamRankingsData(standings))
+		for j, rankingsData := range standings {
+			if j > 0 &&
+				rankingsData.Record.Wins == standings[j-1].Record.Wins &&
+				rankingsData.Record.Ties == standings[j-1].Record.Ties {
+				rankingsData.Rank = standings[j-1].Rank
+			} else {
+				rankingsData.Rank = j + 1
+			}
+		}
+		return
+	}
+
+	sort.Sort(PowerSortedTeamRankingsData(standings))
+	for j, rankingsData := range standings {
+		if j > 0 && rankingsData.Score == standings[j-1].Score {
+			rankingsData.Rank = standings[j-1].Rank
+		} else {
+			rankingsData.Rank = j + 1
+		}
+	}
+}
+
+// addSeasonStandings updates each team with the standings of a SeasonScheme.
+// The overall and projected results of a team are its standings through the
+// current week and the last week.
+func addSeasonStandings(
+	powerDataByTeamKey map[string]*TeamPowerData,
+	weeklyRankings []*WeeklyRanking,
+	currentWeek int,
+	endWeek int) {
+
+	for teamKey, powerData := range powerDataByTeamKey {
+		for i, weeklyRanking := range weeklyRankings {
+			standing, ok := weeklyRanking.Standings[teamKey]
+			if !ok {
+				standing = &TeamRankingData{
+					Week:      i + 1,
+					Record:    &goff.Record{},
+					Projected: weeklyRanking.Projected,
+				}
+			}
+			powerData.AllRankings[i] = standing
+		}
+
+		powerData.TotalScore = 0.0
+		powerData.OverallRecord = &goff.Record{}
+		powerData.Rank = 1
+		if currentWeek > 0 {
+			current := powerData.AllRankings[currentWeek-1]
+			powerData.TotalScore = current.Score
+			addRecord(powerData.OverallRecord, current.Record)
+			powerData.Rank = current.Rank
+		}
+
+		projected := powerData.AllRankings[endWeek-1]
+		powerData.ProjectedTotalScore = projected.Score
+		powerData.ProjectedOverallRecord = &goff.Record{}
+		addRecord(powerData.ProjectedOverallRecord, projected.Record)
+		powerData.ProjectedRank = projected.Rank
+	}
+}
@@ -0,0 +1,456 @@
+package rankings
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Forestmb/goff"
+)
+
+//
+// Configuration variables
+//
+
+// DefaultTieBreakers are used to order teams that are tied in the overall
+// rankings when no other tie-breakers are given
+var DefaultTieBreakers = TieBreakers{}
+
+//
+// Interface
+//
+
+// A TieBreaker orders teams that are tied in the overall rankings of a scheme
+type TieBreaker interface {
+	ID() string
+	DisplayName() string
+
+	// Values returns a value for each of the tied teams using the season
+	// through the current week, or through the last week if projected. Teams
+	// with higher values are ranked ahead of teams with lower values.
+	Values(teams []*TeamPowerData, season *Season, projected bool) []float64
+}
+
+//
+// Data structures
+//
+
+// TieBreakerChain is an ordered list of tie-breakers. Each tie-breaker is
+// only used for teams that are still tied after the ones before it.
+type TieBreakerChain []TieBreaker
+
+// TieBreakers maps the ID of a scheme to the tie-breakers used for its overall
+// rankings. The chain for the empty ID is used by every other scheme.
+type TieBreakers map[string]TieBreakerChain
+
+//
+// Functions
+//
+
+// GetTieBreakers returns the supported tie-breakers
+func GetTieBreakers() []TieBreaker {
+	return []TieBreaker{
+		pointsFor{},
+		headToHead{},
+		allPlayPercentage{},
+		coinFlip{},
+	}
+}
+
+// Chain returns the tie-breakers used for the overall rankings of a scheme
+func (t TieBreakers) Chain(schemeID string) TieBreakerChain {
+	if chain, ok := t[schemeID]; ok {
+		return chain
+	}
+	return t[""]
+}
+
+// NeedsMatchups returns whether any of the tie-breakers use the matchups of
+// the season
+func (t TieBreakers) NeedsMatchups() bool {
+	for _, chain := range t {
+		if len(chain) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseTieBreakers reads tie-breakers in the format returned by
+// TieBreakers.String. Chains are separated by semicolons, and a chain for a
+// single scheme starts with the ID of the scheme, e.g.
+// "points-for,head-to-head,coin-flip:7;all-play=head-to-head,coin-flip"
+func ParseTieBreakers(value string) (TieBreakers, error) {
+	schemes := make(map[string]bool)
+	for _, scheme := range append(GetSchemes(), GetCategorySchemes()...) {
+		schemes[scheme.ID()] = true
+	}
+
+	tieBreakers := make(TieBreakers)
+	for _, part := range strings.Split(value, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		schemeID := ""
+		if pieces := strings.SplitN(part, "=", 2); len(pieces) == 2 {
+			schemeID = strings.TrimSpace(pieces[0])
+			if !schemes[schemeID] {
+				return nil, fmt.Errorf("unknown scheme '%s' for tie-breakers", schemeID)
+			}
+			part = pieces[1]
+		}
+		if _, ok := tieBreakers[schemeID]; ok {
+			return nil, fmt.Errorf("multiple tie-breakers for scheme '%s'", schemeID)
+		}
+		chain, err := parseTieBreakerChain(part)
+		if err != nil {
+			return nil, err
+		}
+		tieBreakers[schemeID] = chain
+	}
+	return tieBreakers, nil
+}
+
+// parseTieBreakerChain reads a comma-separated list of tie-breaker IDs
+func parseTieBreakerChain(value string) (TieBreakerChain, error) {
+	var chain TieBreakerChain
+	for _, part := range strings.Split(value, ",") {
+		pieces := strings.SplitN(strings.TrimSpace(part), ":", 2)
+		id := strings.TrimSpace(pieces[0])
+		if id == (coinFlip{}).ID() {
+			flip := coinFlip{}
+			if len(pieces) == 2 {
+				seed, err := strconv.ParseInt(strings.TrimSpace(pieces[1]), 10, 64)
+				if err != nil {
+					return nil, fmt.Errorf("invalid seed for tie-breaker '%s'", part)
+				}
+				flip.seed = seed
+			}
+			chain = append(chain, flip)
+			continue
+		}
+
+		var found TieBreaker
+		for _, tieBreaker := range GetTieBreakers() {
+			if tieBreaker.ID() == id {
+				found = tieBreaker
+			}
+		}
+		if found == nil || len(pieces) != 1 {
+			return nil, fmt.Errorf("unknown tie-breaker '%s'", strings.TrimSpace(part))
+		}
+		chain = append(chain, found)
+	}
+	return chain, nil
+}
+
+// String returns the tie-breakers in the format read by ParseTieBreakers,
+// with the chain used by every scheme first and the rest ordered by scheme ID
+func (t TieBreakers) String() string {
+	var ids []string
+	for id := range t {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	var parts []string
+	for _, id := range ids {
+		if id == "" {
+			parts = append(parts, t[id].String())
+		} else {
+			parts = append(parts, id+"="+t[id].String())
+		}
+	}
+	return strings.Join(parts, ";")
+}
+
+// String returns the comma-separated IDs of the tie-breakers
+func (c TieBreakerChain) String() string {
+	parts := make([]string, len(c))
+	for i, tieBreaker := range c {
+		parts[i] = tieBreaker.ID()
+		if flip, ok := tieBreaker.(coinFlip); ok && flip.seed != 0 {
+			parts[i] += ":" + strconv.FormatInt(flip.seed, 10)
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// breakTies reorders teams that share a rank using the tie-breakers. Teams
+// must already be sorted by rank, or projected rank if projected. Teams that
+// are still tied after every tie-breaker keep sharing a rank.
+func breakTies(
+	teams []*TeamPowerData,
+	chain TieBreakerChain,
+	season *Season,
+	projected bool) {
+
+	if len(chain) == 0 {
+		return
+	}
+	rank := func(powerData *TeamPowerData) *int {
+		if projected {
+			return &powerData.ProjectedRank
+		}
+		return &powerData.Rank
+	}
+
+	for start := 0; start < len(teams); {
+		end := start + 1
+		for end < len(teams) && *rank(teams[end]) == *rank(teams[start]) {
+			end++
+		}
+		if end-start > 1 {
+			tied := make([]*TeamPowerData, end-start)
+			copy(tied, teams[start:end])
+
+			nextRank := *rank(tied[0])
+			index := start
+			for _, group := range chain.order(tied, season, projected) {
+				for _, powerData := range group {
+					teams[index] = powerData
+					*rank(powerData) = nextRank
+					index++
+				}
+				nextRank += len(group)
+			}
+		}
+		start = end
+	}
+}
+
+// order splits tied teams into groups ordered by the first tie-breaker. Teams
+// with the same value are ordered by the rest of the chain, so each tie-breaker
+// only compares the teams that are still tied.
+func (c TieBreakerChain) order(
+	teams []*TeamPowerData,
+	season *Season,
+	projected bool) [][]*TeamPowerData {
+
+	if len(c) == 0 || len(teams) < 2 {
+		return [][]*TeamPowerData{teams}
+	}
+
+	values := c[0].Values(teams, season, projected)
+	indexes := make([]int, len(teams))
+	for i := range indexes {
+		indexes[i] = i
+	}
+	sort.SliceStable(indexes, func(i, j int) bool {
+		return values[indexes[i]] > values[indexes[j]]
+	})
+
+	var groups [][]*TeamPowerData
+	for start := 0; start < len(indexes); {
+		end := start + 1
+		for end < len(indexes) && values[indexes[end]] == values[indexes[start]] {
+			end++
+		}
+		group := make([]*TeamPowerData, 0, end-start)
+		for _, index := range indexes[start:end] {
+			group = append(group, teams[index])
+		}
+		groups = append(groups, c[1:].order(group, season, projected)...)
+		start = end
+	}
+	return groups
+}
+
+// throughWeek returns the last week used by a tie-breaker
+func throughWeek(season *Season, projected bool) int {
+	if projected {
+		return season.EndWeek
+	}
+	return season.CurrentWeek
+}
+
+// Points For tie-breaker
+type pointsFor struct {
+}
+
+func (p pointsFor) ID() string {
+	return "points-for"
+}
+
+func (p pointsFor) DisplayName() string {
+	return "Points For"
+}
+
+// Values for a 'Points For' tie-breaker are the total points each team scored
+// in its matchups
+func (p pointsFor) Values(
+	teams []*TeamPowerData,
+	season *Season,
+	projected bool) []float64 {
+
+	totals := make(map[string]float64)
+	for week := 1; week <= throughWeek(season, projected); week++ {
+		for _, matchup := range season.Matchups[week] {
+			for i := range matchup.Teams {
+				team := &matchup.Teams[i]
+				totals[team.TeamKey] += matchupScore(team, season.Projected(week))
+			}
+		}
+	}
+
+	values := make([]float64, len(teams))
+	for i, powerData := range teams {
+		values[i] = totals[powerData.Team.TeamKey]
+	}
+	return values
+}
+
+// Head-to-Head tie-breaker
+type headToHead struct {
+}
+
+func (h headToHead) ID() string {
+	return "head-to-head"
+}
+
+func (h headToHead) DisplayName() string {
+	return "Head-to-Head"
+}
+
+// Values for a 'Head-to-Head' tie-breaker are the win percentage of each team
+// in its matchups against the other tied teams. Ties count as half a win.
+func (h headToHead) Values(
+	teams []*TeamPowerData,
+	season *Season,
+	projected bool) []float64 {
+
+	tied := make(map[string]bool)
+	for _, powerData := range teams {
+		tied[powerData.Team.TeamKey] = true
+	}
+
+	records := make(map[string]*goff.Record)
+	for week := 1; week <= throughWeek(season, projected); week++ {
+		for _, matchup := range season.Matchups[week] {
+			if len(matchup.Teams) != 2 ||
+				!tied[matchup.Teams[0].TeamKey] ||
+				!tied[matchup.Teams[1].TeamKey] {
+				continue
+			}
+			firstScore := matchupScore(&matchup.Teams[0], season.Projected(week))
+			secondScore := matchupScore(&matchup.Teams[1], season.Projected(week))
+			for i, team := range matchup.Teams {
+				record, ok := records[team.TeamKey]
+				if !ok {
+					record = &goff.Record{}
+					records[team.TeamKey] = record
+				}
+				score, otherScore := firstScore, secondScore
+				if i == 1 {
+					score, otherScore = secondScore, firstScore
+				}
+				if score > otherScore {
+					record.Wins++
+				} else if score == otherScore {
+					record.Ties++
+				} else {
+					record.Losses++
+				}
+			}
+		}
+	}
+
+	values := make([]float64, len(teams))
+	for i, powerData := range teams {
+		if record, ok := records[powerData.Team.TeamKey]; ok {
+			values[i] = winPercentage(record)
+		}
+	}
+	return values
+}
+
+// All-Play Percentage tie-breaker
+type allPlayPercentage struct {
+}
+
+func (a allPlayPercentage) ID() string {
+	return "all-play"
+}
+
+func (a allPlayPercentage) DisplayName() string {
+	return "All-Play Percentage"
+}
+
+// Values for an 'All-Play Percentage' tie-breaker are the win percentage of
+// each team if it had played every other team in the league each week
+func (a allPlayPercentage) Values(
+	teams []*TeamPowerData,
+	season *Season,
+	projected bool) []float64 {
+
+	records := make(map[string]*goff.Record)
+	for week := 1; week <= throughWeek(season, projected); week++ {
+		var weekTeams []*goff.Team
+		for _, matchup := range season.Matchups[week] {
+			for i := range matchup.Teams {
+				weekTeams = append(weekTeams, &matchup.Teams[i])
+			}
+		}
+		for _, team := range weekTeams {
+			record, ok := records[team.TeamKey]
+			if !ok {
+				record = &goff.Record{}
+				records[team.TeamKey] = record
+			}
+			score := matchupScore(team, season.Projected(week))
+			for _, other := range weekTeams {
+				if other == team {
+					continue
+				}
+				otherScore := matchupScore(other, season.Projected(week))
+				if score > otherScore {
+					record.Wins++
+				} else if score == otherScore {
+					record.Ties++
+				} else {
+					record.Losses++
+				}
+			}
+		}
+	}
+
+	values := make([]float64, len(teams))
+	for i, powerData := range teams {
+		if record, ok := records[powerData.Team.TeamKey]; ok {
+			values[i] = winPercentage(record)
+		}
+	}
+	return values
+}
+
+// Coin Flip tie-breaker
+type coinFlip struct {
+	seed int64
+}
+
+func (c coinFlip) ID() string {
+	return "coin-flip"
+}
+
+func (c coinFlip) DisplayName() string {
+	return "Coin Flip"
+}
+
+// Values for a 'Coin Flip' tie-breaker are random, but always the same for a
+// team using the same seed so the rankings don't change between page loads
+func (c coinFlip) Values(
+	teams []*TeamPowerData,
+	season *Season,
+	projected bool) []float64 {
+
+	values := make([]float64, len(teams))
+	for i, powerData := range teams {
+		hash := fnv.New64a()
+		fmt.Fprintf(hash, "%d:%s", c.seed, powerData.Team.TeamKey)
+		values[i] = float64(hash.Sum64() >> 11)
+	}
+	return values
+}
@@ -45,6 +45,14 @@ type Manager interface {
 	GetClient(w http.ResponseWriter, r *http.Request) (*goff.Client, error)
 }
 
+// HTTPClientManager is implemented by a Manager that can also return an HTTP
+// client for a user, used to request fantasy API content that goff.Client
+// doesn't parse. The HTTP client is authenticated as the same user as the one
+// returned by `Manager.GetClient`, but doesn't cache its responses.
+type HTTPClientManager interface {
+	GetHTTPClient(w http.ResponseWriter, r *http.Request) (goff.HTTPClient, error)
+}
+
 // defaultManager is the default implementation of Manager
 type defaultManager struct {
 	consumerProvider         ConsumerProvider
@@ -186,6 +194,36 @@ func (d *defaultManager) Authenticate(w http.ResponseWriter, req *http.Request)
 // GetClient returns the goff.Client for the user represented by the given
 // request. The return value can be used to make fantasy API requests
 func (d *defaultManager) GetClient(w http.ResponseWriter, req *http.Request) (*goff.Client, error) {
+	id, oauthClient, err := d.getOAuthClient(w, req)
+	if err != nil {
+		return nil, err
+	}
+
+	client := goff.NewCachedClient(
+		goff.NewLRUCache(
+			id,
+			time.Duration(d.userCacheDurationSeconds)*time.Second,
+			d.cache),
+		oauthClient)
+	glog.V(3).Infoln("client created successfully")
+	return client, nil
+}
+
+// GetHTTPClient returns the HTTP client for the user represented by the given
+// request, which can be used to make fantasy API requests that goff.Client
+// doesn't parse. Its responses aren't cached.
+func (d *defaultManager) GetHTTPClient(w http.ResponseWriter, req *http.Request) (goff.HTTPClient, error) {
+	_, oauthClient, err := d.getOAuthClient(w, req)
+	if err != nil {
+		return nil, err
+	}
+	glog.V(3).Infoln("http client created successfully")
+	return oauthClient, nil
+}
+
+// getOAuthClient returns the ID of the session represented by the given
+// request along with an HTTP client authenticated using its access token
+func (d *defaultManager) getOAuthClient(w http.ResponseWriter, req *http.Request) (string, *http.Client, error) {
 	session, err := d.store.Get(req, SessionName)
 	if err != nil {
 		glog.Warningf("error getting session: %s", err)
@@ -196,7 +234,7 @@ func (d *defaultManager) GetClient(w http.ResponseWriter, req *http.Request) (*g
 	// No access token, try creating one if being verified by request
 	if !ok {
 		glog.V(2).Infoln("client not authenticated")
-		return nil, errors.New("no access token in client session")
+		return "", nil, errors.New("no access token in client session")
 	}
 
 	id, ok := session.Values[SessionIDKey].(string)
@@ -214,18 +252,9 @@ func (d *defaultManager) GetClient(w http.ResponseWriter, req *http.Request) (*g
 	err = session.Save(req, w)
 	if err != nil {
 		glog.Warningf("error saving client session: %s", err)
-		return nil, err
+		return "", nil, err
 	}
 
 	consumer := d.consumerProvider.Get(req)
-	oauthClient := consumer.Client(req.Context(), accessToken)
-
-	client := goff.NewCachedClient(
-		goff.NewLRUCache(
-			id,
-			time.Duration(d.userCacheDurationSeconds)*time.Second,
-			d.cache),
-		oauthClient)
-	glog.V(3).Infoln("client created successfully")
-	return client, nil
+	return id, consumer.Client(req.Context(), accessToken), nil
 }
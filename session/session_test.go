@@ -243,6 +243,43 @@ func TestGetClientAccessTokenExists(t *testing.T) {
 	}
 }
 
+func TestGetHTTPClientAccessTokenExists(t *testing.T) {
+	consumer := &MockConsumer{
+		Token: &oauth2.Token{},
+	}
+	store := mockStore()
+	store.Values[AccessTokenKey] = &oauth2.Token{}
+	store.Values[SessionIDKey] = "123"
+
+	manager, ok := NewManager(mockProvider(consumer), store).(HTTPClientManager)
+	if !ok {
+		t.Fatalf("default manager can't create HTTP clients")
+	}
+	client, err := manager.GetHTTPClient(mockResponseWriter(), &http.Request{})
+
+	if err != nil {
+		t.Fatalf("error creating HTTP client with existing access token: %s", err)
+	}
+
+	if client == nil {
+		t.Fatalf("no HTTP client created when access token already exists")
+	}
+}
+
+func TestGetHTTPClientNoAuthenticatedSession(t *testing.T) {
+	consumer := &MockConsumer{
+		Token: &oauth2.Token{},
+	}
+	store := mockStore()
+
+	manager := NewManager(mockProvider(consumer), store).(HTTPClientManager)
+	_, err := manager.GetHTTPClient(mockResponseWriter(), defaultRequest())
+
+	if err == nil {
+		t.Fatalf("no error when creating HTTP client with no authenticated session")
+	}
+}
+
 func TestGetClientAccessTokenExistsTokenSaved(t *testing.T) {
 	consumer := &MockConsumer{
 		Token: &oauth2.Token{},
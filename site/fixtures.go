@@ -0,0 +1,274 @@
+package site
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/Forestmb/goff"
+	"github.com/Forestmb/power-league/rankings"
+	"github.com/golang/glog"
+)
+
+// fantasyClient is the part of goff.Client used to calculate the power
+// rankings of a league
+type fantasyClient interface {
+	yahooGoffClient
+	GetLeagueMetadata(leagueKey string) (*goff.League, error)
+	GetLeagueSettings(leagueKey string) (*LeagueSettings, error)
+	GetTeamStatLines(leagueKey string, week int) ([]rankings.TeamStatLine, error)
+	RequestCount() int
+}
+
+// RecordingClient saves every response from the Yahoo fantasy sports API to a
+// fixture file in Dir, so the same responses can be served by a ReplayClient
+// without authenticating with Yahoo
+type RecordingClient struct {
+	Client fantasyClient
+	Dir    string
+}
+
+// ReplayClient serves the responses saved by a RecordingClient from the
+// fixture files in Dir. Requests without a fixture return an error.
+type ReplayClient struct {
+	Dir string
+
+	mutex        sync.Mutex
+	requestCount int
+}
+
+//
+// RecordingClient
+//
+
+// GetLeagueMetadata returns the metadata associated with the given league.
+func (r *RecordingClient) GetLeagueMetadata(leagueKey string) (*goff.League, error) {
+	league, err := r.Client.GetLeagueMetadata(leagueKey)
+	return league, r.record(err, league, "GetLeagueMetadata", leagueKey)
+}
+
+// GetLeagueSettings returns the settings of a league that goff doesn't parse.
+func (r *RecordingClient) GetLeagueSettings(leagueKey string) (*LeagueSettings, error) {
+	settings, err := r.Client.GetLeagueSettings(leagueKey)
+	return settings, r.record(err, settings, "GetLeagueSettings", leagueKey)
+}
+
+// GetLeagueStandings gets a league containing the current standings.
+func (r *RecordingClient) GetLeagueStandings(leagueKey string) (*goff.League, error) {
+	league, err := r.Client.GetLeagueStandings(leagueKey)
+	return league, r.record(err, league, "GetLeagueStandings", leagueKey)
+}
+
+// GetAllTeamStats gets teams stats for a given week.
+func (r *RecordingClient) GetAllTeamStats(leagueKey string, week int) ([]goff.Team, error) {
+	teams, err := r.Client.GetAllTeamStats(leagueKey, week)
+	return teams, r.record(err, teams, "GetAllTeamStats", leagueKey, week)
+}
+
+// GetTeamStatLines returns the value of each stat for every team in a league
+// for the given week.
+func (r *RecordingClient) GetTeamStatLines(leagueKey string, week int) ([]rankings.TeamStatLine, error) {
+	statLines, err := r.Client.GetTeamStatLines(leagueKey, week)
+	return statLines, r.record(err, statLines, "GetTeamStatLines", leagueKey, week)
+}
+
+// GetTeamRoster returns a team's roster for the given week.
+func (r *RecordingClient) GetTeamRoster(teamKey string, week int) ([]goff.Player, error) {
+	players, err := r.Client.GetTeamRoster(teamKey, week)
+	return players, r.record(err, players, "GetTeamRoster", teamKey, week)
+}
+
+// GetPlayersStats returns a list of Players containing their stats for the
+// given week.
+func (r *RecordingClient) GetPlayersStats(leagueKey string, week int, players []goff.Player) ([]goff.Player, error) {
+	stats, err := r.Client.GetPlayersStats(leagueKey, week, players)
+	return stats, r.record(err, stats, "GetPlayersStats", leagueKey, week, playerKeysID(players))
+}
+
+// GetMatchupsForWeekRange returns a list of matchups for each week in the
+// requested range.
+func (r *RecordingClient) GetMatchupsForWeekRange(leagueKey string, startWeek, endWeek int) (map[int][]goff.Matchup, error) {
+	matchups, err := r.Client.GetMatchupsForWeekRange(leagueKey, startWeek, endWeek)
+	return matchups, r.record(err, matchups, "GetMatchupsForWeekRange", leagueKey, startWeek, endWeek)
+}
+
+// RequestCount returns the amount of requests made to the Yahoo API.
+func (r *RecordingClient) RequestCount() int {
+	return r.Client.RequestCount()
+}
+
+// record saves the response to a request in its fixture file, unless the
+// request failed. Failing to save the fixture is logged but does not fail the
+// request.
+func (r *RecordingClient) record(err error, response interface{}, method string, args ...interface{}) error {
+	if err != nil {
+		return err
+	}
+	filename := fixtureFilename(r.Dir, method, args...)
+	if writeErr := writeFixture(filename, response); writeErr != nil {
+		glog.Warningf("unable to record fixture -- file=%s, error=%s",
+			filename,
+			writeErr)
+	} else {
+		glog.V(3).Infof("recorded fixture -- file=%s", filename)
+	}
+	return nil
+}
+
+//
+// ReplayClient
+//
+
+// GetLeagueMetadata returns the metadata associated with the given league.
+func (r *ReplayClient) GetLeagueMetadata(leagueKey string) (*goff.League, error) {
+	var league *goff.League
+	err := r.replay(&league, "GetLeagueMetadata", leagueKey)
+	return league, err
+}
+
+// GetLeagueSettings returns the settings of a league that goff doesn't parse.
+func (r *ReplayClient) GetLeagueSettings(leagueKey string) (*LeagueSettings, error) {
+	var settings *LeagueSettings
+	err := r.replay(&settings, "GetLeagueSettings", leagueKey)
+	return settings, err
+}
+
+// GetLeagueStandings gets a league containing the current standings.
+func (r *ReplayClient) GetLeagueStandings(leagueKey string) (*goff.League, error) {
+	var league *goff.League
+	err := r.replay(&league, "GetLeagueStandings", leagueKey)
+	return league, err
+}
+
+// GetAllTeamStats gets teams stats for a given week.
+func (r *ReplayClient) GetAllTeamStats(leagueKey string, week int) ([]goff.Team, error) {
+	var teams []goff.Team
+	err := r.replay(&teams, "GetAllTeamStats", leagueKey, week)
+	return teams, err
+}
+
+// GetTeamStatLines returns the value of each stat for every team in a league
+// for the given week.
+func (r *ReplayClient) GetTeamStatLines(leagueKey string, week int) ([]rankings.TeamStatLine, error) {
+	var statLines []rankings.TeamStatLine
+	err := r.replay(&statLines, "GetTeamStatLines", leagueKey, week)
+	return statLines, err
+}
+
+// GetTeamRoster returns a team's roster for the given week.
+func (r *ReplayClient) GetTeamRoster(teamKey string, week int) ([]goff.Player, error) {
+	var players []goff.Player
+	err := r.replay(&players, "GetTeamRoster", teamKey, week)
+	return players, err
+}
+
+// GetPlayersStats returns a list of Players containing their stats for the
+// given week.
+func (r *ReplayClient) GetPlayersStats(leagueKey string, week int, players []goff.Player) ([]goff.Player, error) {
+	var stats []goff.Player
+	err := r.replay(&stats, "GetPlayersStats", leagueKey, week, playerKeysID(players))
+	return stats, err
+}
+
+// GetMatchupsForWeekRange returns a list of matchups for each week in the
+// requested range.
+func (r *ReplayClient) GetMatchupsForWeekRange(leagueKey string, startWeek, endWeek int) (map[int][]goff.Matchup, error) {
+	var matchups map[int][]goff.Matchup
+	err := r.replay(&matchups, "GetMatchupsForWeekRange", leagueKey, startWeek, endWeek)
+	return matchups, err
+}
+
+// RequestCount returns the amount of fixtures served by this client.
+func (r *ReplayClient) RequestCount() int {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.requestCount
+}
+
+// replay reads the response to a request from its fixture file
+func (r *ReplayClient) replay(response interface{}, method string, args ...interface{}) error {
+	r.mutex.Lock()
+	r.requestCount++
+	r.mutex.Unlock()
+
+	filename := fixtureFilename(r.Dir, method, args...)
+	content, err := ioutil.ReadFile(filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("no fixture recorded for %s(%s)",
+				method,
+				joinArgs(args))
+		}
+		return err
+	}
+	glog.V(3).Infof("replaying fixture -- file=%s", filename)
+	return json.Unmarshal(content, response)
+}
+
+//
+// Fixture files
+//
+
+// fixtureFilename returns the file in dir holding the response to a request,
+// named after the method called and its arguments
+func fixtureFilename(dir string, method string, args ...interface{}) string {
+	name := method
+	if len(args) > 0 {
+		name += "-" + joinArgs(args)
+	}
+	return filepath.Join(dir, url.PathEscape(name)+".json")
+}
+
+func joinArgs(args []interface{}) string {
+	values := make([]string, len(args))
+	for index, arg := range args {
+		values[index] = fmt.Sprint(arg)
+	}
+	return strings.Join(values, "-")
+}
+
+// playerKeysID shortens the keys of the requested players to a value that can
+// be used in a file name
+func playerKeysID(players []goff.Player) string {
+	playerKeys := make([]string, len(players))
+	for index, player := range players {
+		playerKeys[index] = player.PlayerKey
+	}
+	sum := sha1.Sum([]byte(strings.Join(playerKeys, ",")))
+	return hex.EncodeToString(sum[:])[:12]
+}
+
+// writeFixture saves a response as JSON. The response is written to a
+// temporary file before being renamed, so the same request made twice at the
+// same time can't leave a partial fixture behind.
+func writeFixture(filename string, response interface{}) error {
+	content, err := json.MarshalIndent(response, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(filename), ".fixture-")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(content)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), filename)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
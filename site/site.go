@@ -3,12 +3,19 @@
 package site
 
 import (
+	"errors"
 	"fmt"
+	"hash/fnv"
 	"net/http"
+	"net/url"
 	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/Forestmb/goff"
+	"github.com/Forestmb/power-league/offline"
+	"github.com/Forestmb/power-league/providers"
 	"github.com/Forestmb/power-league/rankings"
 	"github.com/Forestmb/power-league/session"
 	"github.com/Forestmb/power-league/templates"
@@ -21,6 +28,10 @@ const (
 
 	// LatestSupportedYear that fantasy leagues will be displayed for
 	LatestSupportedYear = 2021
+
+	// maxUploadSize is the largest league file, in bytes, that can be
+	// uploaded
+	maxUploadSize = 10 << 20
 )
 
 // Site consists of the information needed to run a power rankings site
@@ -32,6 +43,11 @@ type Site struct {
 	sessionManager session.Manager
 	config         *templates.SiteConfig
 	templates      templates.Templates
+
+	// Directories where fixtures of Yahoo API responses are recorded to or
+	// replayed from, if any
+	recordFixturesDir string
+	replayFixturesDir string
 }
 
 // HandlerFunc is a handler for a given site
@@ -80,6 +96,19 @@ func (s *Site) GenerateURL(r *http.Request, context string) string {
 	return fmt.Sprintf("%s://%s%s", protocol, r.Host, context)
 }
 
+// RecordFixtures saves the Yahoo API responses used to calculate the power
+// rankings of each league to fixture files in dir
+func (s *Site) RecordFixtures(dir string) {
+	s.recordFixturesDir = dir
+}
+
+// ReplayFixtures calculates the power rankings of each league from the
+// fixture files in dir instead of the Yahoo API. Users don't need to log in to
+// see the rankings of a league with recorded fixtures.
+func (s *Site) ReplayFixtures(dir string) {
+	s.replayFixturesDir = dir
+}
+
 // NewSite creates a new site
 func NewSite(
 	tls bool,
@@ -115,11 +144,44 @@ func NewSite(
 	site.ContextHandler("logout", "/logout", handleLogout)
 	site.ContextHandler("auth", "/auth", handleAuthentication)
 	site.ContextHandler("league", "/league", handlePowerRankings)
+	site.ContextHandler("scheduleSwap", "/league/schedule-swap", handleScheduleSwap)
+	site.ContextHandler("upload", "/league/upload", handleUpload)
+	site.ContextHandler("import", "/league/import", handleImport)
 	site.ContextHandler("about", "/about", handleAbout)
 
 	return site
 }
 
+// isLoggedIn returns whether the user can see the rankings of a league
+func (s *Site) isLoggedIn(req *http.Request) bool {
+	return s.replayFixturesDir != "" || s.sessionManager.IsLoggedIn(req)
+}
+
+// getClient returns the client used to get the data needed to rank a league,
+// replaying or recording fixtures if enabled
+func (s *Site) getClient(w http.ResponseWriter, req *http.Request) (fantasyClient, error) {
+	if s.replayFixturesDir != "" {
+		return &ReplayClient{Dir: s.replayFixturesDir}, nil
+	}
+
+	goffClient, err := s.sessionManager.GetClient(w, req)
+	if err != nil {
+		return nil, err
+	}
+	var httpClient goff.HTTPClient
+	if httpClientManager, ok := s.sessionManager.(session.HTTPClientManager); ok {
+		httpClient, err = httpClientManager.GetHTTPClient(w, req)
+		if err != nil {
+			return nil, err
+		}
+	}
+	client := newYahooAPIClient(goffClient, httpClient)
+	if s.recordFixturesDir != "" {
+		return &RecordingClient{Client: client, Dir: s.recordFixturesDir}, nil
+	}
+	return client, nil
+}
+
 //
 // Handlers
 //
@@ -235,7 +297,7 @@ func handleShowLeagues(s *Site, w http.ResponseWriter, req *http.Request) {
 func handlePowerRankings(s *Site, w http.ResponseWriter, req *http.Request) {
 	glog.V(5).Infoln("in handlePowerRankings")
 
-	loggedIn := s.sessionManager.IsLoggedIn(req)
+	loggedIn := s.isLoggedIn(req)
 	if !loggedIn {
 		homePage := s.GenerateURL(req, s.config.BaseContext)
 		http.Redirect(w, req, homePage, http.StatusTemporaryRedirect)
@@ -255,19 +317,14 @@ func handlePowerRankings(s *Site, w http.ResponseWriter, req *http.Request) {
 	}
 
 	var league *goff.League
-	client, err := s.sessionManager.GetClient(w, req)
+	client, err := s.getClient(w, req)
 	if err == nil {
 		glog.V(3).Infof("getting metadata -- league=%s", leagueKey)
 		league, err = client.GetLeagueMetadata(leagueKey)
 		if err == nil {
-			if league.IsFinished {
-				glog.V(3).Infoln("league is finished")
-				currentWeek = league.CurrentWeek
-			} else {
-				currentWeek = league.CurrentWeek - 1
-			}
-
-			leagueStarted = league.DraftStatus == "postdraft"
+			currentWeek, leagueStarted = getCurrentWeek(
+				&YahooClient{Client: client},
+				league)
 		} else {
 			glog.Warningf("unable to get current week from league metadata: %s", err)
 		}
@@ -281,16 +338,59 @@ func handlePowerRankings(s *Site, w http.ResponseWriter, req *http.Request) {
 		var leaguePowerData []*rankings.LeaguePowerData
 		var schemes []rankings.Scheme
 		var chosenScheme rankings.Scheme
+		compositeWeights := chooseCompositeWeightsFromRequest(req)
+		tieBreakers := chooseTieBreakersFromRequest(req, leagueKey)
+		seasonMode := chooseSeasonModeFromRequest(req)
+		live := req.URL.Query().Get("live") == "true"
+		compare := req.URL.Query().Get("compare") == "projectors"
+		projector := chooseProjectorFromRequest(req)
+		completedWeeks := currentWeek
 		if leagueStarted {
-			leaguePowerData, err = rankings.GetPowerData(
+			var playoffSimulator *rankings.PlayoffSimulator
+			if !league.IsFinished {
+				playoffSimulator = newPlayoffSimulator(client, leagueKey)
+			}
+			leaguePowerData, err = rankings.GetPowerDataWithOptions(
+				req.Context(),
 				&YahooClient{Client: client},
 				league,
-				currentWeek)
+				currentWeek,
+				rankings.GetSchemesWithCompositeWeights(compositeWeights),
+				rankings.PowerDataOptions{
+					SeasonMode:  seasonMode,
+					TieBreakers: tieBreakers,
+					Live:        live,
+					Projector:   projector,
+
+					LiveRemaining:    rankings.GetSeasonCalendar(league).WeekRemaining(time.Now()),
+					PlayoffSimulator: playoffSimulator,
+				})
 			if err == nil {
 				for _, powerData := range leaguePowerData {
 					schemes = append(schemes, powerData.RankingScheme)
 				}
 				chosenScheme = chooseSchemeFromRequest(req, schemes)
+
+				// Rankings of the regular season stop before the playoffs,
+				// and live rankings include the week being played
+				if len(leaguePowerData) > 0 {
+					currentWeek = leaguePowerData[0].CurrentWeek
+				}
+			}
+		}
+
+		// Projectors are only compared when asked to, using the points scored
+		// in completed weeks without the week being played
+		var projectorComparisons []*rankings.ProjectorComparison
+		if err == nil && leagueStarted && compare {
+			var compareErr error
+			projectorComparisons, compareErr = rankings.CompareProjectors(
+				&YahooClient{Client: client},
+				league,
+				completedWeeks,
+				rankings.GetProjectors())
+			if compareErr != nil {
+				glog.Warningf("unable to compare projectors: %s", compareErr)
 			}
 		}
 
@@ -304,6 +404,15 @@ func handlePowerRankings(s *Site, w http.ResponseWriter, req *http.Request) {
 				LeaguePowerData: leaguePowerData,
 				LoggedIn:        loggedIn,
 				SiteConfig:      s.config,
+
+				CompositeWeights: compositeWeights,
+				TieBreakers:      tieBreakers,
+				SeasonMode:       seasonMode,
+				Live:             live,
+
+				Projector:            projector,
+				Projectors:           rankings.GetProjectors(),
+				ProjectorComparisons: projectorComparisons,
 			}
 
 			err = s.templates.WriteRankingsTemplate(w, rankingsContent)
@@ -312,27 +421,340 @@ func handlePowerRankings(s *Site, w http.ResponseWriter, req *http.Request) {
 
 	if err != nil {
 		glog.Warningf("error generating power rankings page: %s", err)
-		if err == goff.ErrAccessDenied {
-			writeErrorPage(
-				s,
-				w,
-				"You do not have permission to access this league.",
-				loggedIn)
-		} else {
-			writeErrorPage(
-				s,
+		writeLeagueErrorPage(
+			s,
+			w,
+			err,
+			"There was a problem delivering you your power rankings. "+
+				"Please try again later.",
+			loggedIn)
+	}
+
+	if client != nil {
+		glog.V(2).Infof("API Request Count: %d", client.RequestCount())
+	}
+}
+
+func handleScheduleSwap(s *Site, w http.ResponseWriter, req *http.Request) {
+	glog.V(5).Infoln("in handleScheduleSwap")
+
+	loggedIn := s.isLoggedIn(req)
+	if !loggedIn {
+		homePage := s.GenerateURL(req, s.config.BaseContext)
+		http.Redirect(w, req, homePage, http.StatusTemporaryRedirect)
+		return
+	}
+
+	values := req.URL.Query()
+	leagueKey := values.Get("key")
+	if leagueKey == "" {
+		leaguesContext := s.handlers["showLeagues"].Context
+		leaguesURL := s.GenerateURL(req, leaguesContext)
+		http.Redirect(w, req, leaguesURL, http.StatusTemporaryRedirect)
+		return
+	}
+
+	var league *goff.League
+	client, err := s.getClient(w, req)
+	if err == nil {
+		glog.V(3).Infof("getting metadata -- league=%s", leagueKey)
+		league, err = client.GetLeagueMetadata(leagueKey)
+	} else {
+		glog.Warningf("unable to create client: %s", err)
+	}
+
+	if err == nil {
+		currentWeek, leagueStarted := getCurrentWeek(
+			&YahooClient{Client: client},
+			league)
+		var scheduleSwap *rankings.ScheduleSwap
+		if leagueStarted {
+			glog.V(3).Infof("calculating schedule swap -- week=%d", currentWeek)
+			scheduleSwap, err = rankings.GetScheduleSwap(
+				&YahooClient{Client: client},
+				league,
+				currentWeek)
+		}
+
+		if err == nil {
+			err = s.templates.WriteScheduleSwapTemplate(
 				w,
-				"There was a problem delivering you your power rankings. "+
-					"Please try again later.",
-				loggedIn)
+				&templates.ScheduleSwapPageContent{
+					Weeks:         currentWeek,
+					League:        league,
+					LeagueStarted: leagueStarted,
+					ScheduleSwap:  scheduleSwap,
+					LoggedIn:      loggedIn,
+					SiteConfig:    s.config,
+				})
 		}
 	}
 
+	if err != nil {
+		glog.Warningf("error generating schedule swap page: %s", err)
+		writeLeagueErrorPage(
+			s,
+			w,
+			err,
+			"There was a problem delivering you your schedule swap. "+
+				"Please try again later.",
+			loggedIn)
+	}
+
 	if client != nil {
 		glog.V(2).Infof("API Request Count: %d", client.RequestCount())
 	}
 }
 
+func handleUpload(s *Site, w http.ResponseWriter, req *http.Request) {
+	glog.V(5).Infoln("in handleUpload")
+
+	loggedIn := s.sessionManager.IsLoggedIn(req)
+	if req.Method != http.MethodPost {
+		writeUploadPage(s, w, "", loggedIn)
+		return
+	}
+
+	req.Body = http.MaxBytesReader(w, req.Body, maxUploadSize)
+	file, header, err := req.FormFile("league")
+	if err != nil {
+		glog.V(2).Infof("no league file uploaded: %s", err)
+		writeUploadPage(s, w, "Choose a JSON or CSV file to upload.", loggedIn)
+		return
+	}
+	defer file.Close()
+
+	var client *offline.Client
+	l, err := offline.Read(file, header.Filename)
+	if err == nil {
+		client, err = offline.NewClient(l)
+	}
+	if err != nil {
+		glog.V(2).Infof("unable to read uploaded league -- file=%s, error=%s",
+			header.Filename,
+			err)
+		writeUploadPage(
+			s,
+			w,
+			fmt.Sprintf("Unable to read %s: %s", header.Filename, err),
+			loggedIn)
+		return
+	}
+	seasonMode := parseSeasonMode(req.PostFormValue("season"))
+	writeOfflineRankings(s, w, req, client, seasonMode, loggedIn)
+}
+
+func handleImport(s *Site, w http.ResponseWriter, req *http.Request) {
+	glog.V(5).Infoln("in handleImport")
+
+	loggedIn := s.sessionManager.IsLoggedIn(req)
+	if req.Method != http.MethodPost {
+		writeUploadPage(s, w, "", loggedIn)
+		return
+	}
+
+	provider, ok := providers.GetProvider(req.FormValue("provider"))
+	if !ok {
+		writeUploadPage(s, w, "Choose where the league is hosted.", loggedIn)
+		return
+	}
+	leagueID := strings.TrimSpace(req.FormValue("id"))
+	season := strings.TrimSpace(req.FormValue("year"))
+
+	// Yahoo leagues are requested as the logged in user
+	var err error
+	if provider.ID() == providers.YahooID && loggedIn {
+		var yahooClient *goff.Client
+		yahooClient, err = s.sessionManager.GetClient(w, req)
+		if err == nil {
+			provider = providers.NewYahoo(yahooClient)
+		}
+	}
+
+	var client *offline.Client
+	var l *providers.League
+	if err == nil {
+		l, err = provider.GetLeague(req.Context(), leagueID, season)
+	}
+	if err == nil {
+		client, err = providers.NewClient(l)
+	}
+	if err != nil {
+		glog.V(2).Infof("unable to import league -- provider=%s, id=%s, "+
+			"season=%s, error=%s",
+			provider.ID(),
+			leagueID,
+			season,
+			err)
+		writeUploadPage(
+			s,
+			w,
+			fmt.Sprintf("Unable to get league %s from %s: %s",
+				leagueID,
+				provider.DisplayName(),
+				err),
+			loggedIn)
+		return
+	}
+	seasonMode := parseSeasonMode(req.PostFormValue("season"))
+	writeOfflineRankings(s, w, req, client, seasonMode, loggedIn)
+}
+
+// Respond to an HTTP request with the power rankings of a league that isn't
+// on Yahoo. The weeks to rank are chosen in the form the league was uploaded
+// or imported with.
+func writeOfflineRankings(
+	s *Site,
+	w http.ResponseWriter,
+	req *http.Request,
+	client *offline.Client,
+	seasonMode string,
+	loggedIn bool) {
+
+	league := client.League()
+	currentWeek, leagueStarted := getCurrentWeek(client, league)
+	compositeWeights := chooseCompositeWeightsFromRequest(req)
+	tieBreakers := chooseTieBreakersFromRequest(req, league.LeagueKey)
+	glog.V(3).Infof("calculating offline rankings -- league=%s, week=%d",
+		league.Name,
+		currentWeek)
+	leaguePowerData, err := rankings.GetPowerDataWithOptions(
+		req.Context(),
+		client,
+		league,
+		currentWeek,
+		rankings.GetSchemesWithCompositeWeights(compositeWeights),
+		rankings.PowerDataOptions{
+			SeasonMode:  seasonMode,
+			TieBreakers: tieBreakers,
+		})
+
+	if err == nil {
+		var schemes []rankings.Scheme
+		for _, powerData := range leaguePowerData {
+			schemes = append(schemes, powerData.RankingScheme)
+		}
+		if len(leaguePowerData) > 0 {
+			currentWeek = leaguePowerData[0].CurrentWeek
+		}
+		err = s.templates.WriteRankingsTemplate(
+			w,
+			&templates.RankingsPageContent{
+				Weeks:           currentWeek,
+				League:          league,
+				LeagueStarted:   leagueStarted,
+				SchemeToShow:    chooseSchemeFromRequest(req, schemes),
+				Schemes:         schemes,
+				LeaguePowerData: leaguePowerData,
+				LoggedIn:        loggedIn,
+				SiteConfig:      s.config,
+
+				CompositeWeights: compositeWeights,
+				TieBreakers:      tieBreakers,
+				SeasonMode:       seasonMode,
+				Offline:          true,
+			})
+	}
+
+	if err != nil {
+		glog.Warningf("error generating offline power rankings page: %s", err)
+		writeErrorPage(
+			s,
+			w,
+			"There was a problem delivering you your power rankings. "+
+				"Please try again later.",
+			loggedIn)
+	}
+}
+
+// newPlayoffSimulator returns a simulator for the playoff odds of a league,
+// using the number of playoff teams in its settings. The simulations are
+// seeded by the league so the odds only change when its results do. Returns
+// nil if the league's settings don't have any playoff teams.
+func newPlayoffSimulator(client fantasyClient, leagueKey string) *rankings.PlayoffSimulator {
+	settings, err := client.GetLeagueSettings(leagueKey)
+	if err != nil {
+		glog.Warningf("unable to get league settings, not simulating "+
+			"playoffs -- league=%s, error=%s",
+			leagueKey,
+			err)
+		return nil
+	}
+	if settings.PlayoffTeams <= 0 {
+		return nil
+	}
+
+	seed := fnv.New64a()
+	seed.Write([]byte(leagueKey))
+	return rankings.NewPlayoffSimulator(
+		int64(seed.Sum64()),
+		rankings.PlayoffSimulations,
+		settings.PlayoffTeams,
+		rankings.PlayoffByeTeams(settings.PlayoffTeams))
+}
+
+// Respond to an HTTP request with the upload page, showing a message if the
+// last file uploaded couldn't be read
+func writeUploadPage(
+	s *Site,
+	w http.ResponseWriter,
+	message string,
+	loggedIn bool) {
+
+	err := s.templates.WriteUploadTemplate(
+		w,
+		&templates.UploadPageContent{
+			Message:    message,
+			Providers:  providers.GetProviders(),
+			Year:       strconv.Itoa(LatestSupportedYear),
+			LoggedIn:   loggedIn,
+			SiteConfig: s.config,
+		})
+	if err != nil {
+		glog.Warningf("error generating upload page: %s", err)
+		writeErrorPage(
+			s,
+			w,
+			"There was a problem delivering you this page. "+
+				"Please try again later.",
+			loggedIn)
+	}
+}
+
+// getCurrentWeek returns the last scoring period of a league that has been
+// completed and whether or not the league has started
+func getCurrentWeek(client rankings.PowerRankingsClient, league *goff.League) (int, bool) {
+	if league.IsFinished {
+		glog.V(3).Infoln("league is finished")
+	}
+	calendar, err := rankings.FindSeasonCalendar(client, league)
+	if err != nil {
+		glog.Warningf("unable to find weeks without matchups: %s", err)
+		calendar = rankings.GetSeasonCalendar(league)
+	}
+	currentWeek := calendar.CompletedPeriods(league)
+	return currentWeek, league.DraftStatus == "postdraft"
+}
+
+// Respond to an HTTP request for a league with an error page, using a more
+// specific message when the user can't access the league
+func writeLeagueErrorPage(
+	s *Site,
+	w http.ResponseWriter,
+	err error,
+	message string,
+	loggedIn bool) {
+
+	if errors.Is(err, goff.ErrAccessDenied) {
+		message = "You do not have permission to access this league."
+	} else if errors.Is(err, rankings.ErrCategoryLeague) {
+		message = "The stat categories of this league aren't available, so " +
+			"it can't be ranked. Power rankings of category leagues need " +
+			"each team's stats by category."
+	}
+	writeErrorPage(s, w, message, loggedIn)
+}
+
 // Respond to an HTTP request with an error page
 func writeErrorPage(
 	s *Site,
@@ -418,6 +840,57 @@ type yahooGoffClient interface {
 	GetMatchupsForWeekRange(leagueKey string, startWeek, endWeek int) (map[int][]goff.Matchup, error)
 }
 
+// yahooSettingsClient gets the data of a league goff doesn't parse. It is
+// implemented by the clients returned by Site.getClient, but not by a
+// goff.Client.
+type yahooSettingsClient interface {
+	GetLeagueSettings(leagueKey string) (*LeagueSettings, error)
+	GetTeamStatLines(leagueKey string, week int) ([]rankings.TeamStatLine, error)
+}
+
+// GetStatCategories returns the stat categories used to compare teams in a
+// category league. Returns rankings.ErrCategoryLeague if the client can't get
+// them.
+func (y *YahooClient) GetStatCategories(leagueKey string) ([]rankings.StatCategory, error) {
+	settingsClient, ok := y.Client.(yahooSettingsClient)
+	if !ok {
+		return nil, rankings.ErrCategoryLeague
+	}
+	settings, err := settingsClient.GetLeagueSettings(leagueKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(settings.StatCategories) == 0 {
+		return nil, rankings.ErrCategoryLeague
+	}
+	return settings.StatCategories, nil
+}
+
+// GetTeamStatLines returns the value of each stat category for every team in
+// a league for the given week. Returns rankings.ErrCategoryLeague if the
+// client can't get them.
+func (y *YahooClient) GetTeamStatLines(leagueKey string, week int) ([]rankings.TeamStatLine, error) {
+	settingsClient, ok := y.Client.(yahooSettingsClient)
+	if !ok {
+		return nil, rankings.ErrCategoryLeague
+	}
+	return settingsClient.GetTeamStatLines(leagueKey, week)
+}
+
+// GetDivisions returns the divisions of a league along with the teams in each.
+// No divisions are returned if the client can't get them.
+func (y *YahooClient) GetDivisions(leagueKey string) ([]rankings.Division, error) {
+	settingsClient, ok := y.Client.(yahooSettingsClient)
+	if !ok {
+		return nil, nil
+	}
+	settings, err := settingsClient.GetLeagueSettings(leagueKey)
+	if err != nil {
+		return nil, err
+	}
+	return settings.Divisions, nil
+}
+
 // GetLeagueStandings gets a league containing the current standings.
 func (y *YahooClient) GetLeagueStandings(leagueKey string) (*goff.League, error) {
 	return y.Client.GetLeagueStandings(leagueKey)
@@ -465,6 +938,31 @@ func (y *YahooClient) GetAllTeamStats(leagueKey string, week int, projection boo
 	return teams, nil
 }
 
+// GetTeamRosterStats gets the players on a team's roster for a given week,
+// along with the points each player scored
+func (y *YahooClient) GetTeamRosterStats(leagueKey string, teamKey string, week int) ([]goff.Player, error) {
+	roster, err := y.Client.GetTeamRoster(teamKey, week)
+	if err != nil || len(roster) == 0 {
+		return roster, err
+	}
+
+	players, err := y.Client.GetPlayersStats(leagueKey, week, roster)
+	if err != nil {
+		return nil, err
+	}
+
+	// The roster has the position each player was started at, but not the
+	// points they scored
+	pointsByPlayerKey := make(map[string]goff.Points)
+	for _, player := range players {
+		pointsByPlayerKey[player.PlayerKey] = player.PlayerPoints
+	}
+	for index := range roster {
+		roster[index].PlayerPoints = pointsByPlayerKey[roster[index].PlayerKey]
+	}
+	return roster, nil
+}
+
 func calculateTeamScore(y *YahooClient, leagueKey string, week int, team *goff.Team, errors chan error) {
 	allPlayers, err := y.Client.GetTeamRoster(team.TeamKey, week)
 	if err == nil {
@@ -534,3 +1032,112 @@ func chooseSchemeFromRequest(
 	// Default to the first scheme
 	return schemes[0]
 }
+
+// chooseCompositeWeightsFromRequest returns the weights of the 'Composite'
+// scheme given in the request. Like the scheme to show, the URL parameter is
+// used before the user preference saved in a cookie.
+func chooseCompositeWeightsFromRequest(req *http.Request) rankings.CompositeWeights {
+	values := req.URL.Query()
+	weightsParam := values.Get("weights")
+	if weightsParam != "" {
+		weights, err := rankings.ParseCompositeWeights(weightsParam)
+		if err == nil {
+			return weights
+		}
+		glog.Warningf("invalid composite weights in URL -- weights=%s, "+
+			"error=%s",
+			weightsParam,
+			err)
+	}
+
+	weightsCookie, err := req.Cookie("CompositeWeights")
+	if err == nil {
+		value, err := url.QueryUnescape(weightsCookie.Value)
+		if err == nil {
+			weights, err := rankings.ParseCompositeWeights(value)
+			if err == nil {
+				return weights
+			}
+		}
+		glog.Warningf("invalid composite weights in cookie -- weights=%s",
+			weightsCookie.Value)
+	}
+
+	return rankings.DefaultCompositeWeights
+}
+
+// chooseSeasonModeFromRequest returns the weeks of the season to rank given in
+// the URL of the request, defaulting to every week including the playoffs
+func chooseSeasonModeFromRequest(req *http.Request) string {
+	return parseSeasonMode(req.URL.Query().Get("season"))
+}
+
+// parseSeasonMode returns the season mode with the given ID, defaulting to
+// every week including the playoffs
+func parseSeasonMode(seasonParam string) string {
+	if seasonParam == rankings.SeasonModes.REGULAR {
+		return rankings.SeasonModes.REGULAR
+	}
+	return rankings.SeasonModes.PLAYOFFS
+}
+
+// chooseProjectorFromRequest returns the projector given in the request, or
+// nil to use the projections of the fantasy sports provider. Like the scheme
+// to show, the URL parameter is used before the user preference saved in a
+// cookie.
+func chooseProjectorFromRequest(req *http.Request) rankings.Projector {
+	projectorID := req.URL.Query().Get("projector")
+	if projectorID == "" {
+		projectorCookie, err := req.Cookie("Projector")
+		if err == nil {
+			projectorID = projectorCookie.Value
+		}
+	}
+
+	for _, projector := range rankings.GetProjectors() {
+		if projector.ID() == projectorID {
+			return projector
+		}
+	}
+	return nil
+}
+
+// chooseTieBreakersFromRequest returns the tie-breakers given in the request
+// for a league. The URL parameter is used before the preference saved in a
+// cookie for the league, so each league can use the tie-breakers in its own
+// rules.
+func chooseTieBreakersFromRequest(req *http.Request, leagueKey string) rankings.TieBreakers {
+	values := req.URL.Query()
+	if _, ok := values["tiebreakers"]; ok {
+		tieBreakersParam := values.Get("tiebreakers")
+		tieBreakers, err := rankings.ParseTieBreakers(tieBreakersParam)
+		if err == nil {
+			return tieBreakers
+		}
+		glog.Warningf("invalid tie-breakers in URL -- tiebreakers=%s, "+
+			"error=%s",
+			tieBreakersParam,
+			err)
+	}
+
+	tieBreakersCookie, err := req.Cookie(tieBreakersCookieName(leagueKey))
+	if err == nil {
+		value, err := url.QueryUnescape(tieBreakersCookie.Value)
+		if err == nil {
+			tieBreakers, err := rankings.ParseTieBreakers(value)
+			if err == nil {
+				return tieBreakers
+			}
+		}
+		glog.Warningf("invalid tie-breakers in cookie -- tiebreakers=%s",
+			tieBreakersCookie.Value)
+	}
+
+	return rankings.DefaultTieBreakers
+}
+
+// tieBreakersCookieName returns the name of the cookie that saves the
+// tie-breakers for a league
+func tieBreakersCookieName(leagueKey string) string {
+	return "TieBreakers-" + leagueKey
+}
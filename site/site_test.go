@@ -1,13 +1,23 @@
 package site
 
 import (
+	"bytes"
+	"context"
 	"errors"
 	"io"
+	"io/ioutil"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/Forestmb/goff"
+	"github.com/Forestmb/power-league/offline"
+	"github.com/Forestmb/power-league/providers"
 	"github.com/Forestmb/power-league/rankings"
 	"github.com/Forestmb/power-league/templates"
 )
@@ -588,6 +598,28 @@ func TestHandlePowerRankingsGetLeagueErrorAccessDenied(t *testing.T) {
 	}
 }
 
+func TestWriteLeagueErrorPageCategoryLeague(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	mockTemplates := &MockTemplates{}
+	site := &Site{
+		config:    &templates.SiteConfig{},
+		templates: mockTemplates,
+	}
+
+	writeLeagueErrorPage(
+		site,
+		recorder,
+		rankings.ErrCategoryLeague,
+		"default message",
+		true)
+
+	if mockTemplates.LastErrorContent == nil ||
+		!strings.Contains(mockTemplates.LastErrorContent.Message, "stat categories") {
+		t.Fatalf("Unexpected error message for category league: %+v",
+			mockTemplates.LastErrorContent)
+	}
+}
+
 func TestHandlePowerRankingsGetLeagueError(t *testing.T) {
 	recorder := httptest.NewRecorder()
 	request, _ := http.NewRequest("GET", "http://example.com:8080/league?key=3.2.1", nil)
@@ -641,6 +673,427 @@ func TestHandlePowerRankingsWriteErrorTemplateError(t *testing.T) {
 	assertErrorHandledCorrectly(t, site, mockTemplates, true)
 }
 
+func TestHandleScheduleSwapNotLoggedIn(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "http://example.com:8080/league/schedule-swap?key=3.2.1", nil)
+	baseContext := "/base"
+	mockSessionManager := &MockSessionManager{
+		IsLoggedInRet: false,
+	}
+	site := &Site{
+		config: &templates.SiteConfig{
+			BaseContext: baseContext,
+		},
+		handlers:       map[string]*ContextHandler{},
+		sessionManager: mockSessionManager,
+		templates:      &MockTemplates{},
+	}
+
+	handleScheduleSwap(site, recorder, request)
+
+	if recorder.Code != http.StatusTemporaryRedirect {
+		t.Fatalf("Unexpected response code given when attempting to access "+
+			"schedule swap when not logged in\n\tExpected: %d\n\tActual: %d",
+			http.StatusTemporaryRedirect,
+			recorder.Code)
+	}
+
+	redirectURL := recorder.HeaderMap.Get("Location")
+	expected := "http://example.com:8080" + baseContext
+	if redirectURL != expected {
+		t.Fatalf("Redirected to unexpected URL when attempting to access "+
+			"schedule swap when not logged in\n\tExpected: %s\n\tActual: %s",
+			expected,
+			redirectURL)
+	}
+}
+
+func TestHandleScheduleSwapGetClientError(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "http://example.com:8080/league/schedule-swap?key=3.2.1", nil)
+	mockSessionManager := &MockSessionManager{
+		IsLoggedInRet: true,
+		ClientError:   errors.New("error"),
+	}
+	mockTemplates := &MockTemplates{}
+	site := &Site{
+		config: &templates.SiteConfig{
+			BaseContext: "/base",
+		},
+		handlers:       map[string]*ContextHandler{},
+		sessionManager: mockSessionManager,
+		templates:      mockTemplates,
+	}
+
+	handleScheduleSwap(site, recorder, request)
+
+	assertErrorHandledCorrectly(t, site, mockTemplates, true)
+	if mockTemplates.LastScheduleSwapContent != nil {
+		t.Fatalf("Schedule swap template written when client could not be " +
+			"created")
+	}
+}
+
+func TestHandleScheduleSwapGetLeagueErrorAccessDenied(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "http://example.com:8080/league/schedule-swap?key=3.2.1", nil)
+	mockSessionManager := &MockSessionManager{
+		IsLoggedInRet: true,
+		Client: &goff.Client{
+			Provider: &MockedContentProvider{
+				content: nil,
+				err:     goff.ErrAccessDenied,
+			},
+		},
+	}
+	mockTemplates := &MockTemplates{}
+	site := &Site{
+		config: &templates.SiteConfig{
+			BaseContext: "/base",
+		},
+		handlers:       map[string]*ContextHandler{},
+		sessionManager: mockSessionManager,
+		templates:      mockTemplates,
+	}
+
+	handleScheduleSwap(site, recorder, request)
+
+	assertErrorHandledCorrectly(t, site, mockTemplates, true)
+	expected := "You do not have permission to access this league."
+	if mockTemplates.LastErrorContent.Message != expected {
+		t.Fatalf("Unexpected error message when user does not have access "+
+			"to get league information:\n\tExpected: %s\n\tActual: %s",
+			expected,
+			mockTemplates.LastErrorContent.Message)
+	}
+}
+
+func TestHandleUploadGet(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/league/upload", nil)
+	mockTemplates := &MockTemplates{}
+	site := &Site{
+		config:         &templates.SiteConfig{},
+		sessionManager: &MockSessionManager{IsLoggedInRet: false},
+		templates:      mockTemplates,
+	}
+
+	handleUpload(site, recorder, request)
+
+	if mockTemplates.LastUploadContent == nil ||
+		mockTemplates.LastUploadContent.Message != "" ||
+		mockTemplates.LastUploadContent.SiteConfig != site.config {
+		t.Fatalf("Unexpected upload content: %+v", mockTemplates.LastUploadContent)
+	}
+}
+
+func TestHandleUpload(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	request := mockUploadRequest(t, "Offline League.csv",
+		"week,team,score,opponent,division\n"+
+			"1,Team A,100.0,Team B,East\n"+
+			"1,Team B,90.0,Team A,East\n"+
+			"1,Team C,80.0,Team D,West\n"+
+			"1,Team D,70.0,Team C,West\n",
+		"regular")
+	mockTemplates := &MockTemplates{}
+	site := &Site{
+		config:         &templates.SiteConfig{},
+		sessionManager: &MockSessionManager{IsLoggedInRet: false},
+		templates:      mockTemplates,
+	}
+
+	handleUpload(site, recorder, request)
+
+	content := mockTemplates.LastRankingsContent
+	if content == nil {
+		t.Fatalf("Rankings not written for uploaded league, error content: %+v",
+			mockTemplates.LastErrorContent)
+	}
+	if !content.Offline ||
+		content.League.Name != "Offline League" ||
+		!content.LeagueStarted ||
+		content.Weeks != 1 ||
+		content.SeasonMode != rankings.SeasonModes.REGULAR ||
+		len(content.LeaguePowerData) == 0 ||
+		content.SchemeToShow == nil {
+		t.Fatalf("Unexpected rankings content for uploaded league: %+v", content)
+	}
+	for _, powerData := range content.LeaguePowerData {
+		if len(powerData.OverallRankings) != 4 || len(powerData.Divisions) != 2 {
+			t.Fatalf("Unexpected power data for uploaded league: %+v", powerData)
+		}
+	}
+}
+
+func TestHandleUploadInvalidFile(t *testing.T) {
+	for _, filename := range []string{"league.csv", "league.txt"} {
+		recorder := httptest.NewRecorder()
+		request := mockUploadRequest(t, filename, "week,team\n1,Team A\n", "")
+		mockTemplates := &MockTemplates{}
+		site := &Site{
+			config:         &templates.SiteConfig{},
+			sessionManager: &MockSessionManager{IsLoggedInRet: false},
+			templates:      mockTemplates,
+		}
+
+		handleUpload(site, recorder, request)
+
+		if mockTemplates.LastRankingsContent != nil ||
+			mockTemplates.LastUploadContent == nil ||
+			!strings.Contains(mockTemplates.LastUploadContent.Message, filename) {
+			t.Fatalf("Unexpected upload content for invalid file %s: %+v",
+				filename,
+				mockTemplates.LastUploadContent)
+		}
+	}
+}
+
+func TestHandleUploadInvalidMatchups(t *testing.T) {
+	for _, matchups := range []string{
+		`[["a", "b"], ["c"]]`,
+		`[["a", "b"], ["c", "c"]]`,
+		`[["a", "b", "c"]]`,
+	} {
+		recorder := httptest.NewRecorder()
+		request := mockUploadRequest(t, "league.json",
+			`{"teams": [{"key": "a"}, {"key": "b"}, {"key": "c"}], `+
+				`"weeks": [{"scores": {"a": 1, "b": 2, "c": 3}, `+
+				`"matchups": `+matchups+`}]}`,
+			"")
+		mockTemplates := &MockTemplates{}
+		site := &Site{
+			config:         &templates.SiteConfig{},
+			sessionManager: &MockSessionManager{IsLoggedInRet: false},
+			templates:      mockTemplates,
+		}
+
+		handleUpload(site, recorder, request)
+
+		if mockTemplates.LastRankingsContent != nil ||
+			mockTemplates.LastUploadContent == nil ||
+			!strings.Contains(mockTemplates.LastUploadContent.Message, "league.json") {
+			t.Fatalf("Unexpected upload content for matchups %s: %+v",
+				matchups,
+				mockTemplates.LastUploadContent)
+		}
+	}
+}
+
+func TestHandleUploadWeeksWithoutMatchups(t *testing.T) {
+	for _, test := range []struct {
+		Filename string
+		Content  string
+	}{
+		{
+			Filename: "league.csv",
+			Content:  "week,team,score\n1,A,10\n1,B,12\n2,A,9\n2,B,3\n",
+		},
+		{
+			Filename: "league.json",
+			Content: `{"teams": [{"key": "A"}, {"key": "B"}], "weeks": [` +
+				`{"scores": {"A": 10, "B": 12}, "matchups": [["A", "B"]]}, ` +
+				`{"scores": {"A": 9, "B": 3}}, ` +
+				`{"scores": {"A": 8, "B": 7}, "matchups": [["A", "B"]]}]}`,
+		},
+	} {
+		recorder := httptest.NewRecorder()
+		request := mockUploadRequest(t, test.Filename, test.Content, "")
+		ctx, cancel := context.WithTimeout(request.Context(), 5*time.Second)
+		request = request.WithContext(ctx)
+		mockTemplates := &MockTemplates{}
+		site := &Site{
+			config:         &templates.SiteConfig{},
+			sessionManager: &MockSessionManager{IsLoggedInRet: false},
+			templates:      mockTemplates,
+		}
+
+		handleUpload(site, recorder, request)
+		cancel()
+
+		content := mockTemplates.LastRankingsContent
+		if content == nil {
+			t.Fatalf("Rankings not written for %s, error content: %+v",
+				test.Filename,
+				mockTemplates.LastErrorContent)
+		}
+		for _, powerData := range content.LeaguePowerData {
+			if len(powerData.OverallRankings) != 2 {
+				t.Fatalf("Unexpected power data for %s: %+v",
+					test.Filename,
+					powerData)
+			}
+		}
+	}
+}
+
+func TestHandleUploadNoFile(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("POST", "/league/upload", strings.NewReader(""))
+	mockTemplates := &MockTemplates{}
+	site := &Site{
+		config:         &templates.SiteConfig{},
+		sessionManager: &MockSessionManager{IsLoggedInRet: false},
+		templates:      mockTemplates,
+	}
+
+	handleUpload(site, recorder, request)
+
+	if mockTemplates.LastUploadContent == nil ||
+		mockTemplates.LastUploadContent.Message == "" {
+		t.Fatalf("No message shown when no file was uploaded: %+v",
+			mockTemplates.LastUploadContent)
+	}
+}
+
+func TestHandleImport(t *testing.T) {
+	s := mockSleeperServer()
+	defer s.Close()
+	defer func(baseURL string) { providers.SleeperBaseURL = baseURL }(providers.SleeperBaseURL)
+	providers.SleeperBaseURL = s.URL
+
+	recorder := httptest.NewRecorder()
+	request := mockImportRequest("sleeper", "123", "2021")
+	mockTemplates := &MockTemplates{}
+	site := &Site{
+		config:         &templates.SiteConfig{},
+		sessionManager: &MockSessionManager{IsLoggedInRet: false},
+		templates:      mockTemplates,
+	}
+
+	handleImport(site, recorder, request)
+
+	content := mockTemplates.LastRankingsContent
+	if content == nil {
+		t.Fatalf("Rankings not written for imported league, upload content: %+v",
+			mockTemplates.LastUploadContent)
+	}
+	if !content.Offline ||
+		content.League.Name != "Imported League" ||
+		content.League.LeagueKey != "sleeper.2021.123" ||
+		content.Weeks != 1 ||
+		len(content.LeaguePowerData) == 0 {
+		t.Fatalf("Unexpected rankings content for imported league: %+v", content)
+	}
+	for _, powerData := range content.LeaguePowerData {
+		if len(powerData.OverallRankings) != 2 {
+			t.Fatalf("Unexpected power data for imported league: %+v", powerData)
+		}
+	}
+}
+
+func TestHandleImportYahoo(t *testing.T) {
+	teams := []goff.Team{
+		goff.Team{TeamKey: "406.l.7.t.1", Name: "Team A"},
+		goff.Team{TeamKey: "406.l.7.t.2", Name: "Team B"},
+	}
+	league := goff.League{
+		LeagueKey:   "406.l.7",
+		LeagueID:    7,
+		Name:        "Yahoo League",
+		CurrentWeek: 2,
+		StartWeek:   1,
+		EndWeek:     2,
+		Settings:    goff.Settings{ScoringType: "headpoint"},
+		Standings:   teams,
+		Scoreboard: goff.Scoreboard{
+			Matchups: []goff.Matchup{
+				goff.Matchup{
+					Week: 1,
+					Teams: []goff.Team{
+						goff.Team{TeamKey: "406.l.7.t.1", TeamPoints: goff.Points{Total: 100.0}},
+						goff.Team{TeamKey: "406.l.7.t.2", TeamPoints: goff.Points{Total: 90.0}},
+					},
+				},
+			},
+		},
+	}
+	recorder := httptest.NewRecorder()
+	request := mockImportRequest("yahoo", "7", "2021")
+	mockTemplates := &MockTemplates{}
+	site := &Site{
+		config: &templates.SiteConfig{},
+		sessionManager: &MockSessionManager{
+			IsLoggedInRet: true,
+			Client: &goff.Client{
+				Provider: &MockedContentProvider{
+					content: &goff.FantasyContent{
+						Users: []goff.User{
+							goff.User{
+								Games: []goff.Game{
+									goff.Game{Leagues: []goff.League{league}},
+								},
+							},
+						},
+						League: league,
+					},
+				},
+			},
+		},
+		templates: mockTemplates,
+	}
+
+	handleImport(site, recorder, request)
+
+	content := mockTemplates.LastRankingsContent
+	if content == nil {
+		t.Fatalf("Rankings not written for imported Yahoo league, upload "+
+			"content: %+v",
+			mockTemplates.LastUploadContent)
+	}
+	if !content.Offline ||
+		content.League.Name != "Yahoo League" ||
+		content.League.LeagueKey != "yahoo.2021.7" ||
+		content.Weeks != 1 ||
+		len(content.LeaguePowerData) == 0 {
+		t.Fatalf("Unexpected rankings content for imported Yahoo league: %+v", content)
+	}
+	for _, powerData := range content.LeaguePowerData {
+		if len(powerData.OverallRankings) != 2 {
+			t.Fatalf("Unexpected power data for imported Yahoo league: %+v", powerData)
+		}
+	}
+}
+
+func TestHandleImportErrors(t *testing.T) {
+	s := mockSleeperServer()
+	defer s.Close()
+	defer func(baseURL string) { providers.SleeperBaseURL = baseURL }(providers.SleeperBaseURL)
+	providers.SleeperBaseURL = s.URL
+
+	for _, test := range []struct {
+		Provider string
+		LeagueID string
+		Message  string
+	}{
+		{Provider: "", LeagueID: "123", Message: "Choose where the league is hosted."},
+		{Provider: "sleeper", LeagueID: "abc", Message: "Unable to get league abc from Sleeper"},
+		{Provider: "sleeper", LeagueID: "456", Message: "Unable to get league 456 from Sleeper"},
+		{Provider: "yahoo", LeagueID: "123", Message: "Unable to get league 123 from Yahoo: log in"},
+	} {
+		recorder := httptest.NewRecorder()
+		request := mockImportRequest(test.Provider, test.LeagueID, "2021")
+		mockTemplates := &MockTemplates{}
+		site := &Site{
+			config:         &templates.SiteConfig{},
+			sessionManager: &MockSessionManager{IsLoggedInRet: false},
+			templates:      mockTemplates,
+		}
+
+		handleImport(site, recorder, request)
+
+		if mockTemplates.LastRankingsContent != nil ||
+			mockTemplates.LastUploadContent == nil ||
+			!strings.HasPrefix(mockTemplates.LastUploadContent.Message, test.Message) ||
+			len(mockTemplates.LastUploadContent.Providers) == 0 {
+			t.Fatalf("Unexpected upload content importing league %s from '%s': %+v",
+				test.LeagueID,
+				test.Provider,
+				mockTemplates.LastUploadContent)
+		}
+	}
+}
+
 func TestChooseSchemeFromRequestURLParameter(t *testing.T) {
 	unexpected := mockRecordScheme{}
 	expected := mockScoreScheme{}
@@ -724,6 +1177,176 @@ func TestChooseSchemeFromRequestCookie(t *testing.T) {
 	}
 }
 
+func TestChooseCompositeWeightsFromRequest(t *testing.T) {
+	request, _ := http.NewRequest(
+		"GET",
+		"http://example.com:8080/context?weights=all-play:1,record:3",
+		nil)
+	request.AddCookie(&http.Cookie{
+		Name:  "CompositeWeights",
+		Value: "total-points%3A1",
+	})
+	actual := chooseCompositeWeightsFromRequest(request).String()
+	expected := "all-play:1,record:3"
+	if actual != expected {
+		t.Fatalf("Unexpected composite weights chosen from request using "+
+			"URL parameter:\n\tExpected: %s\n\tActual: %s",
+			expected,
+			actual)
+	}
+
+	request, _ = http.NewRequest(
+		"GET",
+		"http://example.com:8080/context?weights=invalid",
+		nil)
+	request.AddCookie(&http.Cookie{
+		Name:  "CompositeWeights",
+		Value: "total-points%3A1",
+	})
+	actual = chooseCompositeWeightsFromRequest(request).String()
+	expected = "total-points:1"
+	if actual != expected {
+		t.Fatalf("Unexpected composite weights chosen from request using "+
+			"cookie:\n\tExpected: %s\n\tActual: %s",
+			expected,
+			actual)
+	}
+
+	request, _ = http.NewRequest("GET", "http://example.com:8080/context", nil)
+	request.AddCookie(&http.Cookie{
+		Name:  "CompositeWeights",
+		Value: "invalid",
+	})
+	actual = chooseCompositeWeightsFromRequest(request).String()
+	expected = rankings.DefaultCompositeWeights.String()
+	if actual != expected {
+		t.Fatalf("Unexpected composite weights chosen from request with no "+
+			"valid weights:\n\tExpected: %s\n\tActual: %s",
+			expected,
+			actual)
+	}
+}
+
+func TestChooseTieBreakersFromRequest(t *testing.T) {
+	request, _ := http.NewRequest(
+		"GET",
+		"http://example.com:8080/context?tiebreakers=head-to-head,coin-flip",
+		nil)
+	request.AddCookie(&http.Cookie{
+		Name:  "TieBreakers-nfl.l.1",
+		Value: "points-for",
+	})
+	actual := chooseTieBreakersFromRequest(request, "nfl.l.1").String()
+	expected := "head-to-head,coin-flip"
+	if actual != expected {
+		t.Fatalf("Unexpected tie-breakers chosen from request using "+
+			"URL parameter:\n\tExpected: %s\n\tActual: %s",
+			expected,
+			actual)
+	}
+
+	request, _ = http.NewRequest(
+		"GET",
+		"http://example.com:8080/context?tiebreakers=invalid",
+		nil)
+	request.AddCookie(&http.Cookie{
+		Name:  "TieBreakers-nfl.l.2",
+		Value: "points-for",
+	})
+	request.AddCookie(&http.Cookie{
+		Name:  "TieBreakers-nfl.l.1",
+		Value: "all-play%3Dcoin-flip%3A7",
+	})
+	actual = chooseTieBreakersFromRequest(request, "nfl.l.1").String()
+	expected = "all-play=coin-flip:7"
+	if actual != expected {
+		t.Fatalf("Unexpected tie-breakers chosen from request using "+
+			"league cookie:\n\tExpected: %s\n\tActual: %s",
+			expected,
+			actual)
+	}
+
+	request, _ = http.NewRequest("GET", "http://example.com:8080/context", nil)
+	request.AddCookie(&http.Cookie{
+		Name:  "TieBreakers-nfl.l.2",
+		Value: "points-for",
+	})
+	actual = chooseTieBreakersFromRequest(request, "nfl.l.1").String()
+	expected = rankings.DefaultTieBreakers.String()
+	if actual != expected {
+		t.Fatalf("Unexpected tie-breakers chosen from request for a league "+
+			"without any:\n\tExpected: %s\n\tActual: %s",
+			expected,
+			actual)
+	}
+}
+
+func TestChooseSeasonModeFromRequest(t *testing.T) {
+	for url, expected := range map[string]string{
+		"http://example.com:8080/context?season=regular":  rankings.SeasonModes.REGULAR,
+		"http://example.com:8080/context?season=playoffs": rankings.SeasonModes.PLAYOFFS,
+		"http://example.com:8080/context?season=invalid":  rankings.SeasonModes.PLAYOFFS,
+		"http://example.com:8080/context":                 rankings.SeasonModes.PLAYOFFS,
+	} {
+		request, _ := http.NewRequest("GET", url, nil)
+		actual := chooseSeasonModeFromRequest(request)
+		if actual != expected {
+			t.Fatalf("Unexpected season mode chosen from request '%s':\n"+
+				"\tExpected: %s\n\tActual: %s",
+				url,
+				expected,
+				actual)
+		}
+	}
+
+	// Like the other options, only the URL parameter is used
+	request, _ := http.NewRequest(
+		"POST",
+		"http://example.com:8080/context",
+		strings.NewReader("season=regular"))
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	actual := chooseSeasonModeFromRequest(request)
+	if actual != rankings.SeasonModes.PLAYOFFS {
+		t.Fatalf("Unexpected season mode chosen from form:\n"+
+			"\tExpected: %s\n\tActual: %s",
+			rankings.SeasonModes.PLAYOFFS,
+			actual)
+	}
+}
+
+func TestChooseProjectorFromRequest(t *testing.T) {
+	request, _ := http.NewRequest(
+		"GET",
+		"http://example.com:8080/context?projector=trailing-mean",
+		nil)
+	request.AddCookie(&http.Cookie{Name: "Projector", Value: "season-mean"})
+	projector := chooseProjectorFromRequest(request)
+	if projector == nil || projector.ID() != "trailing-mean" {
+		t.Fatalf("Unexpected projector chosen from request using URL "+
+			"parameter: %+v",
+			projector)
+	}
+
+	request, _ = http.NewRequest("GET", "http://example.com:8080/context", nil)
+	request.AddCookie(&http.Cookie{Name: "Projector", Value: "season-mean"})
+	projector = chooseProjectorFromRequest(request)
+	if projector == nil || projector.ID() != "season-mean" {
+		t.Fatalf("Unexpected projector chosen from request using cookie: %+v",
+			projector)
+	}
+
+	request, _ = http.NewRequest(
+		"GET",
+		"http://example.com:8080/context?projector=provider",
+		nil)
+	request.AddCookie(&http.Cookie{Name: "Projector", Value: "season-mean"})
+	projector = chooseProjectorFromRequest(request)
+	if projector != nil {
+		t.Fatalf("Projector chosen from request for provider projections: %+v",
+			projector)
+	}
+}
+
 func TestGetUserLeagues(t *testing.T) {
 	year := "2012"
 	client := &MockUserLeaguesClient{
@@ -873,6 +1496,44 @@ func TestYahooClientAllTeamStatsError(t *testing.T) {
 	}
 }
 
+func TestYahooClientGetTeamRosterStats(t *testing.T) {
+	goffClient := &MockGoffClient{
+		TeamRoster: []goff.Player{
+			goff.Player{
+				PlayerKey:        "p1",
+				SelectedPosition: goff.SelectedPosition{Position: "QB"},
+			},
+			goff.Player{
+				PlayerKey:        "p2",
+				SelectedPosition: goff.SelectedPosition{Position: "BN"},
+			},
+		},
+		PlayersStats: []goff.Player{
+			goff.Player{PlayerKey: "p2", PlayerPoints: goff.Points{Total: 7.0}},
+			goff.Player{PlayerKey: "p1", PlayerPoints: goff.Points{Total: 21.5}},
+		},
+	}
+	client := &YahooClient{Client: goffClient}
+
+	roster, err := client.GetTeamRosterStats("123", "123.t.1", 3)
+	if err != nil {
+		t.Fatalf("unexpected error getting team roster stats: %s", err)
+	}
+	if len(roster) != 2 ||
+		roster[0].PlayerPoints.Total != 21.5 ||
+		roster[0].SelectedPosition.Position != "QB" ||
+		roster[1].PlayerPoints.Total != 7.0 {
+		t.Fatalf("unexpected roster stats returned: %+v", roster)
+	}
+
+	goffClient.PlayersStatsError = errors.New("error")
+	_, err = client.GetTeamRosterStats("123", "123.t.1", 3)
+	if err == nil {
+		t.Fatalf("no error getting team roster stats when getting the " +
+			"player stats failed")
+	}
+}
+
 func TestYahooClientZeroPointsRosterError(t *testing.T) {
 	goffClient := &MockGoffClient{
 		AllTeamStats: []goff.Team{
@@ -1009,6 +1670,403 @@ func TestYahooClientAllTeamStatsZeroPoints(t *testing.T) {
 	}
 }
 
+func TestYahooAPIClientGetLeagueSettings(t *testing.T) {
+	httpClient := &MockHTTPClient{
+		Status: http.StatusOK,
+		Body: `<?xml version="1.0" encoding="UTF-8"?>
+<fantasy_content xmlns="http://fantasysports.yahooapis.com/fantasy/v2/base.rng">
+  <league>
+    <league_key>223.l.431</league_key>
+    <settings>
+      <uses_playoff>1</uses_playoff>
+      <playoff_start_week>15</playoff_start_week>
+      <num_playoff_teams>6</num_playoff_teams>
+      <divisions>
+        <division><division_id>1</division_id><name>East</name></division>
+        <division><division_id>2</division_id><name>West</name></division>
+      </divisions>
+    </settings>
+    <standings>
+      <teams>
+        <team><team_key>223.l.431.t.1</team_key><division_id>1</division_id></team>
+        <team><team_key>223.l.431.t.2</team_key><division_id>2</division_id></team>
+        <team><team_key>223.l.431.t.3</team_key><division_id>1</division_id></team>
+      </teams>
+    </standings>
+  </league>
+</fantasy_content>`,
+	}
+	client := newYahooAPIClient(&goff.Client{Provider: &MockedContentProvider{}}, httpClient)
+
+	settings, err := client.GetLeagueSettings("223.l.431")
+	if err != nil || settings.PlayoffTeams != 6 {
+		t.Fatalf("Unexpected league settings: %+v, %v", settings, err)
+	}
+	if httpClient.LastURL != goff.YahooBaseURL+"/league/223.l.431;out=standings,settings" {
+		t.Fatalf("Unexpected league settings URL: %s", httpClient.LastURL)
+	}
+
+	yahooClient := &YahooClient{Client: client}
+	divisions, err := yahooClient.GetDivisions("223.l.431")
+	if err != nil ||
+		len(divisions) != 2 ||
+		divisions[0].ID != "1" ||
+		divisions[0].Name != "East" ||
+		strings.Join(divisions[0].TeamKeys, ",") != "223.l.431.t.1,223.l.431.t.3" ||
+		divisions[1].ID != "2" ||
+		divisions[1].Name != "West" ||
+		strings.Join(divisions[1].TeamKeys, ",") != "223.l.431.t.2" {
+		t.Fatalf("Unexpected divisions: %+v, %v", divisions, err)
+	}
+
+	// Settings are only requested once
+	client.GetLeagueSettings("223.l.431")
+	if httpClient.Count != 1 || client.RequestCount() != 1 {
+		t.Fatalf("Unexpected requests for league settings: %d, %d counted",
+			httpClient.Count,
+			client.RequestCount())
+	}
+}
+
+func TestYahooAPIClientWithoutHTTPClient(t *testing.T) {
+	client := newYahooAPIClient(&goff.Client{Provider: &MockedContentProvider{}}, nil)
+
+	settings, err := client.GetLeagueSettings("223.l.431")
+	if err != nil ||
+		settings.PlayoffTeams != 0 ||
+		settings.StatCategories != nil ||
+		settings.Divisions != nil {
+		t.Fatalf("Unexpected league settings without an HTTP client: %+v, %v",
+			settings,
+			err)
+	}
+	if _, err := client.GetTeamStatLines("223.l.431", 1); err == nil {
+		t.Fatal("No error returned getting stat lines without an HTTP client")
+	}
+	if client.RequestCount() != 0 {
+		t.Fatalf("Unexpected requests without an HTTP client: %d",
+			client.RequestCount())
+	}
+}
+
+func TestSiteGetClientHTTPClient(t *testing.T) {
+	httpClient := &MockHTTPClient{
+		Status: http.StatusOK,
+		Body: `<fantasy_content><league><settings>
+  <num_playoff_teams>4</num_playoff_teams>
+</settings></league></fantasy_content>`,
+	}
+	sessionManager := &MockSessionManager{
+		Client:     &goff.Client{Provider: &MockedContentProvider{}},
+		HTTPClient: httpClient,
+	}
+	site := &Site{sessionManager: sessionManager}
+
+	client, err := site.getClient(httptest.NewRecorder(), nil)
+	if err != nil {
+		t.Fatalf("Unexpected error getting client: %s", err)
+	}
+	settings, err := client.GetLeagueSettings("223.l.431")
+	if err != nil || settings.PlayoffTeams != 4 || httpClient.Count != 1 {
+		t.Fatalf("League settings not requested with the session's HTTP "+
+			"client: %+v, %v",
+			settings,
+			err)
+	}
+
+	sessionManager.HTTPClientError = errors.New("failure")
+	if _, err := site.getClient(httptest.NewRecorder(), nil); err == nil {
+		t.Fatal("No error returned when the HTTP client can't be created")
+	}
+}
+
+func TestYahooAPIClientErrors(t *testing.T) {
+	for _, test := range []struct {
+		Status int
+		Err    error
+	}{
+		{Status: http.StatusUnauthorized, Err: goff.ErrAccessDenied},
+		{Status: http.StatusForbidden, Err: goff.ErrAccessDenied},
+		{Status: http.StatusInternalServerError},
+	} {
+		client := newYahooAPIClient(
+			&goff.Client{Provider: &MockedContentProvider{}},
+			&MockHTTPClient{Status: test.Status})
+		_, err := client.GetLeagueSettings("223.l.431")
+		if err == nil || (test.Err != nil && err != test.Err) {
+			t.Fatalf("Unexpected error for status %d:\n\tExpected: %v\n\tActual: %v",
+				test.Status,
+				test.Err,
+				err)
+		}
+	}
+
+	client := newYahooAPIClient(
+		&goff.Client{Provider: &MockedContentProvider{}},
+		&MockHTTPClient{Err: errors.New("failure")})
+	if _, err := client.GetLeagueSettings("223.l.431"); err == nil {
+		t.Fatal("No error returned when the request fails")
+	}
+}
+
+func TestYahooClientCategoryLeague(t *testing.T) {
+	leagueKey := "398.l.1234"
+	teams := []goff.Team{
+		goff.Team{TeamKey: "398.l.1234.t.1", Name: "Team A"},
+		goff.Team{TeamKey: "398.l.1234.t.2", Name: "Team B"},
+	}
+	league := &goff.League{
+		LeagueKey:   leagueKey,
+		CurrentWeek: 2,
+		EndWeek:     2,
+		Settings:    goff.Settings{ScoringType: "head"},
+		Standings:   teams,
+	}
+	statsXML := func(teamKey string, runs string, era string) string {
+		return `<team><team_key>` + teamKey + `</team_key><team_stats><stats>
+          <stat><stat_id>7</stat_id><value>` + runs + `</value></stat>
+          <stat><stat_id>26</stat_id><value>` + era + `</value></stat>
+          <stat><stat_id>60</stat_id><value>12/40</value></stat>
+        </stats></team_stats></team>`
+	}
+	httpClient := &MockHTTPClient{
+		Status: http.StatusOK,
+		Bodies: map[string]string{
+			goff.YahooBaseURL + "/league/" + leagueKey + ";out=standings,settings": `
+<fantasy_content><league><settings><stat_categories><stats>
+  <stat><stat_id>7</stat_id><name>Runs</name><display_name>R</display_name><sort_order>1</sort_order></stat>
+  <stat><stat_id>26</stat_id><name>Earned Run Average</name><display_name>ERA</display_name><sort_order>0</sort_order></stat>
+  <stat><stat_id>60</stat_id><name>H/AB</name><sort_order>1</sort_order><is_only_display_stat>1</is_only_display_stat></stat>
+</stats></stat_categories></settings></league></fantasy_content>`,
+			goff.YahooBaseURL + "/league/" + leagueKey + "/teams/stats;type=week;week=1": `
+<fantasy_content><league><teams>` +
+				statsXML("398.l.1234.t.1", "10", "3.00") +
+				statsXML("398.l.1234.t.2", "5", "4.50") + `
+</teams></league></fantasy_content>`,
+		},
+	}
+	client := &YahooClient{
+		Client: newYahooAPIClient(&goff.Client{
+			Provider: &MockedContentProvider{
+				content: &goff.FantasyContent{League: *league},
+			},
+		}, httpClient),
+	}
+
+	categories, err := client.GetStatCategories(leagueKey)
+	if err != nil || len(categories) != 2 ||
+		categories[0] != (rankings.StatCategory{ID: "7", Name: "R"}) ||
+		categories[1] != (rankings.StatCategory{ID: "26", Name: "ERA", LowerIsBetter: true}) {
+		t.Fatalf("Unexpected stat categories: %+v, %v", categories, err)
+	}
+
+	data, err := rankings.GetPowerData(client, league, 1)
+	if err != nil {
+		t.Fatalf("Unexpected error ranking category league: %s", err)
+	}
+	if len(data) != len(rankings.GetCategorySchemes()) {
+		t.Fatalf("Category league not ranked by the category schemes: %+v", data)
+	}
+	for _, powerData := range data {
+		first := powerData.OverallRankings[0]
+		if first.Team.TeamKey != "398.l.1234.t.1" || first.Rank != 1 {
+			t.Fatalf("Team with more runs and a lower ERA not ranked first "+
+				"by scheme %s: %+v",
+				powerData.RankingScheme.ID(),
+				first)
+		}
+	}
+
+	// A goff.Client can't get the stats of each category
+	_, err = (&YahooClient{Client: &goff.Client{}}).GetStatCategories(leagueKey)
+	if err != rankings.ErrCategoryLeague {
+		t.Fatalf("Unexpected error getting stat categories without settings: %v", err)
+	}
+}
+
+func TestNewPlayoffSimulator(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fixtures")
+	if err != nil {
+		t.Fatalf("Unable to create fixtures directory: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	client := &ReplayClient{Dir: dir}
+	if simulator := newPlayoffSimulator(client, "missing"); simulator != nil {
+		t.Fatalf("Unexpected simulator without league settings: %+v", simulator)
+	}
+
+	for leagueKey, playoffTeams := range map[string]int{"none": 0, "six": 6} {
+		err := writeFixture(
+			fixtureFilename(dir, "GetLeagueSettings", leagueKey),
+			&LeagueSettings{PlayoffTeams: playoffTeams})
+		if err != nil {
+			t.Fatalf("Unable to write fixture: %s", err)
+		}
+	}
+	if simulator := newPlayoffSimulator(client, "none"); simulator != nil {
+		t.Fatalf("Unexpected simulator without playoff teams: %+v", simulator)
+	}
+	simulator := newPlayoffSimulator(client, "six")
+	if simulator == nil ||
+		simulator.PlayoffTeams != 6 ||
+		simulator.ByeTeams != 2 ||
+		simulator.Simulations != rankings.PlayoffSimulations {
+		t.Fatalf("Unexpected simulator for six playoff teams: %+v", simulator)
+	}
+}
+
+func TestRecordAndReplayFixtures(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fixtures")
+	if err != nil {
+		t.Fatalf("Unable to create fixtures directory: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// Record the responses used to rank the league
+	recordingClient := &RecordingClient{
+		Client: mockOfflineFantasyClient(t),
+		Dir:    dir,
+	}
+	league, err := recordingClient.GetLeagueMetadata(fixturesLeagueKey)
+	if err != nil {
+		t.Fatalf("Unexpected error getting league metadata: %s", err)
+	}
+	currentWeek, _ := getCurrentWeek(
+		&YahooClient{Client: recordingClient},
+		league)
+	expected, err := rankings.GetPowerData(
+		&YahooClient{Client: recordingClient},
+		league,
+		currentWeek)
+	if err != nil {
+		t.Fatalf("Unexpected error recording fixtures: %s", err)
+	}
+
+	// Replay them without a logged in user
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET",
+		"http://example.com:8080/league?key="+fixturesLeagueKey,
+		nil)
+	mockTemplates := &MockTemplates{}
+	site := &Site{
+		config:            &templates.SiteConfig{},
+		handlers:          map[string]*ContextHandler{},
+		sessionManager:    &MockSessionManager{IsLoggedInRet: false},
+		templates:         mockTemplates,
+		replayFixturesDir: dir,
+	}
+
+	handlePowerRankings(site, recorder, request)
+
+	content := mockTemplates.LastRankingsContent
+	if content == nil {
+		t.Fatalf("Rankings not written from fixtures, error content: %+v",
+			mockTemplates.LastErrorContent)
+	}
+	if content.League.Name != "Fixtures League" ||
+		content.Weeks != currentWeek ||
+		len(content.LeaguePowerData) != len(expected) ||
+		content.ProjectorComparisons != nil {
+		t.Fatalf("Unexpected rankings content replayed from fixtures: %+v",
+			content)
+	}
+	for index, powerData := range content.LeaguePowerData {
+		for rank, teamData := range powerData.OverallRankings {
+			expectedTeam := expected[index].OverallRankings[rank]
+			if teamData.Team.TeamKey != expectedTeam.Team.TeamKey ||
+				teamData.TotalScore != expectedTeam.TotalScore {
+				t.Fatalf("Unexpected team replayed from fixtures for scheme "+
+					"%s at rank %d:\n\tExpected: %+v\n\tActual: %+v",
+					powerData.RankingScheme.ID(),
+					rank+1,
+					expectedTeam,
+					teamData)
+			}
+		}
+	}
+
+	// Projectors are compared when asked to, even once the league is over
+	request, _ = http.NewRequest("GET",
+		"http://example.com:8080/league?compare=projectors&key="+fixturesLeagueKey,
+		nil)
+	handlePowerRankings(site, recorder, request)
+	content = mockTemplates.LastRankingsContent
+	if len(content.ProjectorComparisons) != len(rankings.GetProjectors()) {
+		t.Fatalf("Unexpected projector comparisons replayed from fixtures: %+v",
+			content.ProjectorComparisons)
+	}
+}
+
+func TestReplayFixturesMissing(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fixtures")
+	if err != nil {
+		t.Fatalf("Unable to create fixtures directory: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	client := &ReplayClient{Dir: dir}
+	_, err = client.GetLeagueStandings(fixturesLeagueKey)
+	if err == nil || client.RequestCount() != 1 {
+		t.Fatalf("Unexpected result replaying missing fixture: %v, %d requests",
+			err,
+			client.RequestCount())
+	}
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET",
+		"http://example.com:8080/league?key="+fixturesLeagueKey,
+		nil)
+	mockTemplates := &MockTemplates{}
+	site := &Site{
+		config:            &templates.SiteConfig{},
+		handlers:          map[string]*ContextHandler{},
+		sessionManager:    &MockSessionManager{IsLoggedInRet: false},
+		templates:         mockTemplates,
+		replayFixturesDir: dir,
+	}
+
+	handlePowerRankings(site, recorder, request)
+
+	assertErrorHandledCorrectly(t, site, mockTemplates, true)
+}
+
+func TestGetClientRecordFixtures(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fixtures")
+	if err != nil {
+		t.Fatalf("Unable to create fixtures directory: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	site := &Site{
+		sessionManager: &MockSessionManager{
+			Client: &goff.Client{
+				Provider: &MockedContentProvider{
+					content: &goff.FantasyContent{
+						League: goff.League{Name: "Recorded League"},
+					},
+				},
+			},
+		},
+		recordFixturesDir: dir,
+	}
+	client, err := site.getClient(httptest.NewRecorder(), nil)
+	if err != nil {
+		t.Fatalf("Unexpected error getting client: %s", err)
+	}
+	if _, ok := client.(*RecordingClient); !ok {
+		t.Fatalf("Client does not record fixtures: %+v", client)
+	}
+	client.GetLeagueMetadata(fixturesLeagueKey)
+
+	league, err := (&ReplayClient{Dir: dir}).GetLeagueMetadata(fixturesLeagueKey)
+	if err != nil || league.Name != "Recorded League" {
+		t.Fatalf("Unexpected league replayed from recorded fixture: %+v, %v",
+			league,
+			err)
+	}
+}
+
 func assertErrorHandledCorrectly(
 	t *testing.T,
 	s *Site,
@@ -1084,6 +2142,9 @@ type MockSessionManager struct {
 	IsLoggedInRet bool
 	Client        *goff.Client
 	ClientError   error
+
+	HTTPClient      goff.HTTPClient
+	HTTPClientError error
 }
 
 func (m *MockSessionManager) Login(w http.ResponseWriter, r *http.Request) (loginURL string) {
@@ -1111,6 +2172,10 @@ func (m *MockSessionManager) GetClient(w http.ResponseWriter, r *http.Request) (
 	return m.Client, m.ClientError
 }
 
+func (m *MockSessionManager) GetHTTPClient(w http.ResponseWriter, r *http.Request) (goff.HTTPClient, error) {
+	return m.HTTPClient, m.HTTPClientError
+}
+
 type MockUserLeaguesClient struct {
 	Leagues map[string][]goff.League
 	Error   error
@@ -1120,16 +2185,74 @@ func (m *MockUserLeaguesClient) GetUserLeagues(year string) ([]goff.League, erro
 	return m.Leagues[year], m.Error
 }
 
-type MockTemplates struct {
-	WriteAboutError    error
-	WriteErrorError    error
-	WriteLeaguesError  error
-	WriteRankingsError error
+// mockUploadRequest creates a request uploading a league file to the upload
+// page
+func mockUploadRequest(t *testing.T, filename string, content string, season string) *http.Request {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("league", filename)
+	if err != nil {
+		t.Fatalf("Unable to create upload request: %s", err)
+	}
+	part.Write([]byte(content))
+	writer.WriteField("season", season)
+	writer.Close()
+
+	request, _ := http.NewRequest("POST", "/league/upload", &body)
+	request.Header.Set("Content-Type", writer.FormDataContentType())
+	return request
+}
+
+// mockImportRequest returns a request to import a league from a provider
+func mockImportRequest(provider string, leagueID string, year string) *http.Request {
+	form := url.Values{}
+	form.Set("provider", provider)
+	form.Set("id", leagueID)
+	form.Set("year", year)
+	request, _ := http.NewRequest("POST", "/league/import", strings.NewReader(form.Encode()))
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return request
+}
+
+// mockSleeperServer responds like Sleeper's API for a league with the ID 123
+// and two teams that have played one week
+func mockSleeperServer() *httptest.Server {
+	responses := map[string]string{
+		"/league/123": `{"league_id": "123", "name": "Imported League", ` +
+			`"season": "2021", "status": "in_season", ` +
+			`"settings": {"last_scored_leg": 1, "playoff_week_start": 0}}`,
+		"/league/123/users": `[{"user_id": "1", "display_name": "one"}, ` +
+			`{"user_id": "2", "display_name": "two"}]`,
+		"/league/123/rosters": `[{"roster_id": 1, "owner_id": "1"}, ` +
+			`{"roster_id": 2, "owner_id": "2"}]`,
+		"/league/123/matchups/1": `[{"roster_id": 1, "matchup_id": 1, "points": 100.5}, ` +
+			`{"roster_id": 2, "matchup_id": 1, "points": 90.25}]`,
+	}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if response, ok := responses[r.URL.Path]; ok {
+			w.Write([]byte(response))
+		} else if strings.HasPrefix(r.URL.Path, "/league/123/matchups/") {
+			w.Write([]byte("[]"))
+		} else {
+			w.Write([]byte("null"))
+		}
+	}))
+}
 
-	LastAboutContent    *templates.AboutPageContent
-	LastErrorContent    *templates.ErrorPageContent
-	LastLeaguesContent  *templates.LeaguesPageContent
-	LastRankingsContent *templates.RankingsPageContent
+type MockTemplates struct {
+	WriteAboutError        error
+	WriteErrorError        error
+	WriteLeaguesError      error
+	WriteRankingsError     error
+	WriteScheduleSwapError error
+	WriteUploadError       error
+
+	LastAboutContent        *templates.AboutPageContent
+	LastErrorContent        *templates.ErrorPageContent
+	LastLeaguesContent      *templates.LeaguesPageContent
+	LastRankingsContent     *templates.RankingsPageContent
+	LastScheduleSwapContent *templates.ScheduleSwapPageContent
+	LastUploadContent       *templates.UploadPageContent
 }
 
 func (m *MockTemplates) WriteRankingsTemplate(w io.Writer, content *templates.RankingsPageContent) error {
@@ -1137,6 +2260,16 @@ func (m *MockTemplates) WriteRankingsTemplate(w io.Writer, content *templates.Ra
 	return m.WriteRankingsError
 }
 
+func (m *MockTemplates) WriteScheduleSwapTemplate(w io.Writer, content *templates.ScheduleSwapPageContent) error {
+	m.LastScheduleSwapContent = content
+	return m.WriteScheduleSwapError
+}
+
+func (m *MockTemplates) WriteUploadTemplate(w io.Writer, content *templates.UploadPageContent) error {
+	m.LastUploadContent = content
+	return m.WriteUploadError
+}
+
 func (m *MockTemplates) WriteAboutTemplate(w io.Writer, content *templates.AboutPageContent) error {
 	m.LastAboutContent = content
 	return m.WriteAboutError
@@ -1152,6 +2285,66 @@ func (m *MockTemplates) WriteErrorTemplate(w io.Writer, content *templates.Error
 	return m.WriteErrorError
 }
 
+const fixturesLeagueKey = "offline.fixtures-league"
+
+// offlineFantasyClient serves a league read by the offline package in place of
+// the Yahoo API, to record fixtures from
+type offlineFantasyClient struct {
+	*offline.Client
+}
+
+func mockOfflineFantasyClient(t *testing.T) *offlineFantasyClient {
+	league, err := offline.ReadJSON(strings.NewReader(`{
+	  "name": "Fixtures League",
+	  "teams": [
+	    {"key": "a", "name": "Team A"},
+	    {"key": "b", "name": "Team B"},
+	    {"key": "c", "name": "Team C"},
+	    {"key": "d", "name": "Team D"}
+	  ],
+	  "weeks": [
+	    {"scores": {"a": 100.0, "b": 90.0, "c": 80.0, "d": 70.0}, "matchups": [["a", "b"], ["c", "d"]]},
+	    {"scores": {"a": 60.0, "b": 90.0, "c": 80.0, "d": 70.0}, "matchups": [["a", "c"], ["b", "d"]]}
+	  ]
+	}`))
+	if err != nil {
+		t.Fatalf("Unable to read offline league: %s", err)
+	}
+	client, err := offline.NewClient(league)
+	if err != nil {
+		t.Fatalf("Unable to create offline client: %s", err)
+	}
+	return &offlineFantasyClient{Client: client}
+}
+
+func (o *offlineFantasyClient) GetLeagueMetadata(leagueKey string) (*goff.League, error) {
+	return o.League(), nil
+}
+
+func (o *offlineFantasyClient) GetLeagueSettings(leagueKey string) (*LeagueSettings, error) {
+	return &LeagueSettings{}, nil
+}
+
+func (o *offlineFantasyClient) GetTeamStatLines(leagueKey string, week int) ([]rankings.TeamStatLine, error) {
+	return nil, nil
+}
+
+func (o *offlineFantasyClient) GetAllTeamStats(leagueKey string, week int) ([]goff.Team, error) {
+	return o.Client.GetAllTeamStats(leagueKey, week, false)
+}
+
+func (o *offlineFantasyClient) GetTeamRoster(teamKey string, week int) ([]goff.Player, error) {
+	return nil, nil
+}
+
+func (o *offlineFantasyClient) GetPlayersStats(leagueKey string, week int, players []goff.Player) ([]goff.Player, error) {
+	return nil, nil
+}
+
+func (o *offlineFantasyClient) RequestCount() int {
+	return 0
+}
+
 // MockedContentProvider creates a goff.ContentProvider that returns the
 // given content and error whenever provider.Get is called.
 type MockedContentProvider struct {
@@ -1171,6 +2364,34 @@ func (m *MockedContentProvider) RequestCount() int {
 	return m.count
 }
 
+// MockHTTPClient responds to every request with the given status and body,
+// or the given error. Requests for a URL in Bodies respond with its body.
+type MockHTTPClient struct {
+	Status  int
+	Body    string
+	Bodies  map[string]string
+	Err     error
+	LastURL string
+	Count   int
+}
+
+func (m *MockHTTPClient) Get(url string) (*http.Response, error) {
+	m.LastURL = url
+	m.Count++
+	if m.Err != nil {
+		return nil, m.Err
+	}
+	body, ok := m.Bodies[url]
+	if !ok {
+		body = m.Body
+	}
+	return &http.Response{
+		StatusCode: m.Status,
+		Status:     http.StatusText(m.Status),
+		Body:       ioutil.NopCloser(strings.NewReader(body)),
+	}, nil
+}
+
 type mockScoreScheme struct{}
 
 func (m mockScoreScheme) ID() string {
@@ -0,0 +1,231 @@
+package site
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/Forestmb/goff"
+	"github.com/Forestmb/power-league/rankings"
+	"github.com/golang/glog"
+)
+
+// LeagueSettings are the settings of a league that goff doesn't parse from
+// the Yahoo fantasy sports API
+type LeagueSettings struct {
+	// Number of teams that make the playoffs, or 0 if it isn't known
+	PlayoffTeams int
+
+	// Stats used to compare teams in category leagues
+	StatCategories []rankings.StatCategory
+
+	// Divisions of the league along with the teams in each, if it has any
+	Divisions []rankings.Division
+}
+
+// yahooAPIClient adds the league settings goff doesn't parse to a goff
+// client, requesting them from the Yahoo fantasy sports API directly using an
+// HTTP client authenticated as the same user. The settings of each league are
+// only requested once per client.
+type yahooAPIClient struct {
+	*goff.Client
+
+	// Used to request content goff doesn't parse. Without one, only the
+	// settings goff parses are known.
+	httpClient goff.HTTPClient
+
+	mutex        sync.Mutex
+	requestCount int
+	settings     map[string]*LeagueSettings
+}
+
+// yahooSettingsContent is the part of the response to a league settings
+// request that isn't in goff.Settings
+type yahooSettingsContent struct {
+	League struct {
+		Settings struct {
+			NumPlayoffTeams int         `xml:"num_playoff_teams"`
+			StatCategories  []yahooStat `xml:"stat_categories>stats>stat"`
+			Divisions       []struct {
+				DivisionID string `xml:"division_id"`
+				Name       string `xml:"name"`
+			} `xml:"divisions>division"`
+		} `xml:"settings"`
+		Teams []struct {
+			TeamKey    string `xml:"team_key"`
+			DivisionID string `xml:"division_id"`
+		} `xml:"standings>teams>team"`
+	} `xml:"league"`
+}
+
+// yahooStat is a stat category in a league's settings, or the value of a
+// stat for a team
+type yahooStat struct {
+	StatID      string `xml:"stat_id"`
+	Name        string `xml:"name"`
+	DisplayName string `xml:"display_name"`
+	SortOrder   string `xml:"sort_order"`
+	DisplayOnly bool   `xml:"is_only_display_stat"`
+	Value       string `xml:"value"`
+}
+
+// yahooTeamStatsContent is the response to a request for the stats of every
+// team in a league, which goff only parses the points of
+type yahooTeamStatsContent struct {
+	League struct {
+		Teams []struct {
+			goff.Team
+			Stats []yahooStat `xml:"team_stats>stats>stat"`
+		} `xml:"teams>team"`
+	} `xml:"league"`
+}
+
+func newYahooAPIClient(client *goff.Client, httpClient goff.HTTPClient) *yahooAPIClient {
+	return &yahooAPIClient{
+		Client:     client,
+		httpClient: httpClient,
+		settings:   make(map[string]*LeagueSettings),
+	}
+}
+
+// GetLeagueSettings returns the settings of a league that goff doesn't parse
+func (y *yahooAPIClient) GetLeagueSettings(leagueKey string) (*LeagueSettings, error) {
+	y.mutex.Lock()
+	settings, ok := y.settings[leagueKey]
+	y.mutex.Unlock()
+	if ok {
+		return settings, nil
+	}
+	if y.httpClient == nil {
+		return &LeagueSettings{}, nil
+	}
+
+	// Use the same request as goff.Client.GetLeagueStandings
+	var content yahooSettingsContent
+	err := y.get(
+		fmt.Sprintf("%s/league/%s;out=standings,settings",
+			goff.YahooBaseURL,
+			leagueKey),
+		&content)
+	if err != nil {
+		return nil, err
+	}
+
+	settings = &LeagueSettings{
+		PlayoffTeams: content.League.Settings.NumPlayoffTeams,
+	}
+
+	// Stats that are only displayed, like hits per at bat, aren't compared
+	for _, stat := range content.League.Settings.StatCategories {
+		if stat.DisplayOnly {
+			continue
+		}
+		name := stat.DisplayName
+		if name == "" {
+			name = stat.Name
+		}
+		settings.StatCategories = append(settings.StatCategories, rankings.StatCategory{
+			ID:            stat.StatID,
+			Name:          name,
+			LowerIsBetter: stat.SortOrder == "0",
+		})
+	}
+
+	// Teams are listed in the standings along with the ID of their division
+	for _, division := range content.League.Settings.Divisions {
+		rankingsDivision := rankings.Division{
+			ID:   division.DivisionID,
+			Name: division.Name,
+		}
+		for _, team := range content.League.Teams {
+			if team.DivisionID == division.DivisionID {
+				rankingsDivision.TeamKeys = append(rankingsDivision.TeamKeys, team.TeamKey)
+			}
+		}
+		settings.Divisions = append(settings.Divisions, rankingsDivision)
+	}
+
+	y.mutex.Lock()
+	y.settings[leagueKey] = settings
+	y.mutex.Unlock()
+	return settings, nil
+}
+
+// GetTeamStatLines returns the value of each stat for every team in a league
+// for the given week. Stats without a numeric value, like those of a team
+// that hasn't played, are left out.
+func (y *yahooAPIClient) GetTeamStatLines(leagueKey string, week int) ([]rankings.TeamStatLine, error) {
+	// Use the same request as goff.Client.GetAllTeamStats
+	var content yahooTeamStatsContent
+	err := y.get(
+		fmt.Sprintf("%s/league/%s/teams/stats;type=week;week=%d",
+			goff.YahooBaseURL,
+			leagueKey,
+			week),
+		&content)
+	if err != nil {
+		return nil, err
+	}
+
+	statLines := make([]rankings.TeamStatLine, len(content.League.Teams))
+	for index, team := range content.League.Teams {
+		statLines[index] = rankings.TeamStatLine{
+			Team:  team.Team,
+			Stats: make(map[string]float64),
+		}
+		for _, stat := range team.Stats {
+			value, err := strconv.ParseFloat(stat.Value, 64)
+			if err == nil {
+				statLines[index].Stats[stat.StatID] = value
+			}
+		}
+	}
+	return statLines, nil
+}
+
+// RequestCount returns the amount of requests made to the Yahoo API by goff
+// and by this client.
+func (y *yahooAPIClient) RequestCount() int {
+	y.mutex.Lock()
+	defer y.mutex.Unlock()
+	return y.Client.RequestCount() + y.requestCount
+}
+
+// get unmarshals the XML response to a request to the Yahoo fantasy sports
+// API
+func (y *yahooAPIClient) get(url string, content interface{}) error {
+	if y.httpClient == nil {
+		return errors.New("no HTTP client to request yahoo content")
+	}
+
+	y.mutex.Lock()
+	y.requestCount++
+	y.mutex.Unlock()
+
+	glog.V(3).Infof("requesting yahoo content -- url=%s", url)
+	response, err := y.httpClient.Get(url)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	switch {
+	case response.StatusCode == http.StatusUnauthorized,
+		response.StatusCode == http.StatusForbidden:
+		return goff.ErrAccessDenied
+	case response.StatusCode != http.StatusOK:
+		return fmt.Errorf("unexpected response from %s: %s",
+			url,
+			response.Status)
+	}
+
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return err
+	}
+	return xml.Unmarshal(body, content)
+}
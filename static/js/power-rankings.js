@@ -37,6 +37,25 @@ $(document).ready(function(){
         document.cookie='PowerPreference=' + schemeId;
     });
 
+    $('.composite-weights-form').submit(function() {
+        var weights = $(this).find('.composite-weights').val();
+        document.cookie='CompositeWeights=' + encodeURIComponent(weights);
+    });
+
+    $('.projector-form .projector').change(function() {
+        document.cookie='Projector=' + $(this).val();
+        $(this).closest('form').submit();
+    });
+
+    // Projections are only compared when asked for, so show them right away
+    $('.projections-modal').modal('show');
+
+    $('.tie-breakers-form').submit(function() {
+        var leagueKey = $(this).attr('data-league-key');
+        var tieBreakers = $(this).find('.tie-breakers').val();
+        document.cookie='TieBreakers-' + leagueKey + '=' + encodeURIComponent(tieBreakers);
+    });
+
     // Add the ability to sort the overall standings table
     $('.overall-table table').tablesorter({
         sortList: [[2,1]],
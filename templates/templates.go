@@ -14,6 +14,7 @@ import (
 	"strings"
 
 	"github.com/Forestmb/goff"
+	"github.com/Forestmb/power-league/providers"
 	"github.com/Forestmb/power-league/rankings"
 	"github.com/golang/glog"
 )
@@ -23,11 +24,13 @@ const (
 	// template files.
 	DefaultBaseDir = "html/"
 
-	baseTemplate     = "base.html"
-	aboutTemplate    = "about.html"
-	errorTemplate    = "error.html"
-	leaguesTemplate  = "leagues.html"
-	rankingsTemplate = "rankings.html"
+	baseTemplate         = "base.html"
+	aboutTemplate        = "about.html"
+	errorTemplate        = "error.html"
+	leaguesTemplate      = "leagues.html"
+	rankingsTemplate     = "rankings.html"
+	scheduleSwapTemplate = "schedule-swap.html"
+	uploadTemplate       = "upload.html"
 )
 
 // Templates provides programmtic access to power rankings templates
@@ -36,6 +39,8 @@ type Templates interface {
 	WriteErrorTemplate(w io.Writer, content *ErrorPageContent) error
 	WriteLeaguesTemplate(w io.Writer, content *LeaguesPageContent) error
 	WriteRankingsTemplate(w io.Writer, content *RankingsPageContent) error
+	WriteScheduleSwapTemplate(w io.Writer, content *ScheduleSwapPageContent) error
+	WriteUploadTemplate(w io.Writer, content *UploadPageContent) error
 }
 
 // defaultTemplates provides programmtic access to power rankings templates
@@ -73,6 +78,50 @@ type RankingsPageContent struct {
 	LeaguePowerData []*rankings.LeaguePowerData
 	LoggedIn        bool
 	SiteConfig      *SiteConfig
+
+	// Weights used by the 'Composite' scheme
+	CompositeWeights rankings.CompositeWeights
+
+	// Tie-breakers used to order teams tied in the overall rankings
+	TieBreakers rankings.TieBreakers
+
+	// Weeks of the season that are ranked, one of rankings.SeasonModes
+	SeasonMode string
+
+	// Whether the week being played is ranked using the points scored so far
+	Live bool
+
+	// Projector used for the weeks after the current week, or nil for the
+	// projections of the fantasy sports provider
+	Projector            rankings.Projector
+	Projectors           []rankings.Projector
+	ProjectorComparisons []*rankings.ProjectorComparison
+
+	// Whether the league was read from a file instead of Yahoo, so the
+	// rankings can't be recalculated with different options
+	Offline bool
+}
+
+// ScheduleSwapPageContent is used to show the records every team in a league
+// would have with the schedule of every other team.
+type ScheduleSwapPageContent struct {
+	Weeks         int
+	League        *goff.League
+	LeagueStarted bool
+	ScheduleSwap  *rankings.ScheduleSwap
+	LoggedIn      bool
+	SiteConfig    *SiteConfig
+}
+
+// UploadPageContent is used to upload a league from a file or import it from
+// another provider, along with a message describing why the last league
+// couldn't be read.
+type UploadPageContent struct {
+	Message    string
+	Providers  []providers.Provider
+	Year       string
+	LoggedIn   bool
+	SiteConfig *SiteConfig
 }
 
 // YearlyLeagues describes the leagues for a user for a given year.
@@ -136,7 +185,13 @@ func (t *defaultTemplates) WriteRankingsTemplate(w io.Writer, content *RankingsP
 		"getRankForScheme":       templateGetRankForScheme,
 		"getAbsoluteValue":       templateGetAbsoluteValue,
 		"getCSVContent":          templateGetCSVContent,
+		"getSeasonModeFilename":  templateGetSeasonModeFilename,
 		"getExportFilename":      templateGetExportFilename,
+		"hasManagerEfficiency":   templateHasManagerEfficiency,
+		"getPercentage":          templateGetPercentage,
+		"getWinPercentageOffset": templateGetWinPercentageOffset,
+		"getHeatmapLevel":        templateGetHeatmapLevel,
+		"getHeadToHeadCSV":       templateGetHeadToHeadCSVContent,
 	}
 	template, err := template.New(rankingsTemplate).Funcs(funcMap).ParseFiles(
 		t.baseDir+baseTemplate,
@@ -147,6 +202,34 @@ func (t *defaultTemplates) WriteRankingsTemplate(w io.Writer, content *RankingsP
 	return writeTemplateSafe(w, template, content)
 }
 
+// WriteScheduleSwapTemplate writes the schedule swap template to the given
+// writer
+func (t *defaultTemplates) WriteScheduleSwapTemplate(w io.Writer, content *ScheduleSwapPageContent) error {
+	funcMap := template.FuncMap{
+		"getRecordOffset":           templateGetRecordOffset,
+		"getExportFilename":         templateGetExportFilename,
+		"getScheduleSwapCSVContent": templateGetScheduleSwapCSVContent,
+	}
+	template, err := template.New(scheduleSwapTemplate).Funcs(funcMap).ParseFiles(
+		t.baseDir+baseTemplate,
+		t.baseDir+scheduleSwapTemplate)
+	if err != nil {
+		return err
+	}
+	return writeTemplateSafe(w, template, content)
+}
+
+// WriteUploadTemplate writes the upload page template to the given writer
+func (t *defaultTemplates) WriteUploadTemplate(w io.Writer, content *UploadPageContent) error {
+	template, err := template.New(uploadTemplate).ParseFiles(
+		t.baseDir+baseTemplate,
+		t.baseDir+uploadTemplate)
+	if err != nil {
+		return err
+	}
+	return writeTemplateSafe(w, template, content)
+}
+
 // WriteAboutTemplate writes the about page template to the given writer
 func (t *defaultTemplates) WriteAboutTemplate(w io.Writer, content *AboutPageContent) error {
 	template, err := template.New(aboutTemplate).ParseFiles(
@@ -332,7 +415,26 @@ func templateGetExportFilename(l *goff.League) string {
 			-1))
 }
 
-func templateGetCSVContent(leagueData *rankings.LeaguePowerData) string {
+// templateGetSeasonModeName returns the name of the weeks of the season that
+// are ranked, matching the buttons used to choose them
+func templateGetSeasonModeName(seasonMode string) string {
+	if seasonMode == rankings.SeasonModes.REGULAR {
+		return "Regular Season"
+	}
+	return "With Playoffs"
+}
+
+// templateGetSeasonModeFilename returns the name of the weeks of the season
+// that are ranked for use in a filename
+func templateGetSeasonModeFilename(seasonMode string) string {
+	return strings.Replace(
+		strings.ToLower(templateGetSeasonModeName(seasonMode)),
+		" ",
+		"-",
+		-1)
+}
+
+func templateGetCSVContent(leagueData *rankings.LeaguePowerData, seasonMode string) string {
 	scheme := leagueData.RankingScheme
 	var buffer bytes.Buffer
 	separator := ","
@@ -355,11 +457,26 @@ func templateGetCSVContent(leagueData *rankings.LeaguePowerData) string {
 	}
 	buffer.WriteString("League Rank,")
 	buffer.WriteString("League Rank Offset,")
-	buffer.WriteString("League Record")
+	buffer.WriteString("League Record,")
+	buffer.WriteString("Expected Wins,")
+	buffer.WriteString("Luck,")
+	buffer.WriteString("Strength of Schedule,")
+	buffer.WriteString("Remaining Strength of Schedule,")
+	buffer.WriteString("Manager Efficiency,")
+	buffer.WriteString("Mean Score,")
+	buffer.WriteString("Score Standard Deviation,")
+	buffer.WriteString("Coefficient of Variation,")
+	buffer.WriteString("Floor,")
+	buffer.WriteString("Ceiling,")
+	buffer.WriteString("Boom Weeks,")
+	buffer.WriteString("Bust Weeks,")
+	buffer.WriteString("Weeks Ranked")
 	for index, weeklyRanking := range leagueData.ByWeek {
 		var weekStr string
 		if weeklyRanking.Projected {
 			weekStr = fmt.Sprintf(",[Projected] Week %d ", index+1)
+		} else if weeklyRanking.Live {
+			weekStr = fmt.Sprintf(",[Live] Week %d ", index+1)
 		} else {
 			weekStr = fmt.Sprintf(",Week %d ", index+1)
 		}
@@ -406,6 +523,44 @@ func templateGetCSVContent(leagueData *rankings.LeaguePowerData) string {
 				teamData.Team.TeamStandings.Rank))
 		buffer.WriteString(separator)
 		writeRecordToBuffer(&buffer, &teamData.Team.TeamStandings.Record)
+		buffer.WriteString(separator)
+		buffer.WriteString(
+			strconv.FormatFloat(teamData.ExpectedWins, 'f', 2, 64))
+		buffer.WriteString(separator)
+		buffer.WriteString(
+			strconv.FormatFloat(teamData.Luck, 'f', 2, 64))
+		buffer.WriteString(separator)
+		buffer.WriteString(
+			strconv.FormatFloat(teamData.StrengthOfSchedule, 'f', 3, 64))
+		buffer.WriteString(separator)
+		buffer.WriteString(
+			strconv.FormatFloat(teamData.RemainingStrengthOfSchedule, 'f', 3, 64))
+		buffer.WriteString(separator)
+		if teamData.ManagerEfficiency > 0 {
+			buffer.WriteString(
+				strconv.FormatFloat(teamData.ManagerEfficiency, 'f', 3, 64))
+		}
+		buffer.WriteString(separator)
+		buffer.WriteString(
+			strconv.FormatFloat(teamData.MeanScore, 'f', 2, 64))
+		buffer.WriteString(separator)
+		buffer.WriteString(
+			strconv.FormatFloat(teamData.ScoreStandardDeviation, 'f', 2, 64))
+		buffer.WriteString(separator)
+		buffer.WriteString(
+			strconv.FormatFloat(teamData.CoefficientOfVariation, 'f', 3, 64))
+		buffer.WriteString(separator)
+		buffer.WriteString(
+			strconv.FormatFloat(teamData.Floor, 'f', 2, 64))
+		buffer.WriteString(separator)
+		buffer.WriteString(
+			strconv.FormatFloat(teamData.Ceiling, 'f', 2, 64))
+		buffer.WriteString(separator)
+		buffer.WriteString(strconv.Itoa(teamData.BoomWeeks))
+		buffer.WriteString(separator)
+		buffer.WriteString(strconv.Itoa(teamData.BustWeeks))
+		buffer.WriteString(separator)
+		buffer.WriteString(templateGetSeasonModeName(seasonMode))
 		for index, weeklyScore := range teamData.AllScores {
 			buffer.WriteString(separator)
 			buffer.WriteString(strconv.FormatFloat(weeklyScore.FantasyScore, 'f', 2, 64))
@@ -429,6 +584,106 @@ func templateGetCSVContent(leagueData *rankings.LeaguePowerData) string {
 	return base64.StdEncoding.EncodeToString(buffer.Bytes())
 }
 
+// templateHasManagerEfficiency returns whether any team in the league has a
+// manager efficiency, which is only calculated when rosters are available
+func templateHasManagerEfficiency(leagueData *rankings.LeaguePowerData) bool {
+	for _, teamData := range leagueData.OverallRankings {
+		if teamData.ManagerEfficiency > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func templateGetPercentage(value float64) string {
+	return strconv.FormatFloat(value*100.0, 'f', 1, 64) + "%"
+}
+
+func templateGetWinPercentageOffset(record goff.Record, expected float64) float64 {
+	games := record.Wins + record.Losses + record.Ties
+	if games == 0 {
+		return 0.0
+	}
+	actual := (float64(record.Wins) + 0.5*float64(record.Ties)) / float64(games)
+	return actual - expected
+}
+
+// templateGetRecordOffset returns how many more wins, counting ties as half a
+// win, the first record has compared to the second
+func templateGetRecordOffset(record, other *goff.Record) float64 {
+	return (float64(record.Wins) + 0.5*float64(record.Ties)) -
+		(float64(other.Wins) + 0.5*float64(other.Ties))
+}
+
+func templateGetScheduleSwapCSVContent(swap *rankings.ScheduleSwap) string {
+	var buffer bytes.Buffer
+	separator := ","
+	buffer.WriteString("Team")
+	for _, team := range swap.Teams {
+		buffer.WriteString(separator)
+		buffer.WriteString(team.Name)
+		buffer.WriteString(" Schedule")
+	}
+	buffer.WriteString("\n")
+	for i, team := range swap.Teams {
+		buffer.WriteString(team.Name)
+		for _, record := range swap.Records[i] {
+			buffer.WriteString(separator)
+			writeRecordToBuffer(&buffer, record)
+		}
+		buffer.WriteString("\n")
+	}
+
+	return base64.StdEncoding.EncodeToString(buffer.Bytes())
+}
+
+// templateGetHeatmapLevel returns how good a record is from 0 to 4, using its
+// win percentage, or -1 if no games have been played
+func templateGetHeatmapLevel(record *goff.Record) int {
+	games := record.Wins + record.Losses + record.Ties
+	if games == 0 {
+		return -1
+	}
+	percentage := (float64(record.Wins) + 0.5*float64(record.Ties)) / float64(games)
+	level := int(percentage * 5.0)
+	if level > 4 {
+		level = 4
+	}
+	return level
+}
+
+func templateGetHeadToHeadCSVContent(headToHead *rankings.HeadToHead) string {
+	var buffer bytes.Buffer
+	separator := ","
+	buffer.WriteString("Team")
+	for _, team := range headToHead.Teams {
+		buffer.WriteString(separator)
+		buffer.WriteString("vs. ")
+		buffer.WriteString(team.Name)
+		buffer.WriteString(separator)
+		buffer.WriteString("vs. ")
+		buffer.WriteString(team.Name)
+		buffer.WriteString(" All-Play")
+	}
+	buffer.WriteString("\n")
+	for i, team := range headToHead.Teams {
+		buffer.WriteString(team.Name)
+		for j := range headToHead.Teams {
+			buffer.WriteString(separator)
+			if i != j {
+				writeRecordToBuffer(&buffer, headToHead.Records[i][j])
+			}
+			buffer.WriteString(separator)
+			if i != j {
+				writeRecordToBuffer(&buffer, headToHead.AllPlayRecords[i][j])
+			}
+		}
+		buffer.WriteString("\n")
+	}
+
+	return base64.StdEncoding.EncodeToString(buffer.Bytes())
+}
+
 func writeRecordToBuffer(buffer *bytes.Buffer, r *goff.Record) {
 	buffer.WriteString(strconv.Itoa(r.Wins))
 	buffer.WriteString("-")
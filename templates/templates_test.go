@@ -12,6 +12,7 @@ import (
 	"testing"
 
 	"github.com/Forestmb/goff"
+	"github.com/Forestmb/power-league/providers"
 	"github.com/Forestmb/power-league/rankings"
 )
 
@@ -60,6 +61,157 @@ func TestWriteRankingsTemplate(t *testing.T) {
 	}
 }
 
+func TestWriteRankingsTemplateDivisions(t *testing.T) {
+	powerData := mockLeaguePowerData()
+	teamData := powerData.OverallRankings[0]
+	teamData.DivisionID = "1"
+	teamData.DivisionRank = 1
+	teamData.ProjectedDivisionRank = 1
+	teamData.DivisionAllPlayRecord = &goff.Record{Wins: 2, Losses: 1}
+	powerData.Divisions = []*rankings.DivisionPowerData{
+		&rankings.DivisionPowerData{
+			Division:          rankings.Division{ID: "1", Name: "East"},
+			OverallRankings:   rankings.PowerRankings{teamData},
+			ProjectedRankings: rankings.PowerRankings{teamData},
+			ProjectedWinner:   teamData,
+		},
+		&rankings.DivisionPowerData{
+			Division: rankings.Division{ID: "2", Name: "West"},
+		},
+	}
+	content := &RankingsPageContent{
+		Weeks:           12,
+		SchemeToShow:    mockRecordScheme{},
+		Schemes:         []rankings.Scheme{mockRecordScheme{}},
+		League:          &(mockLeagues()[0]),
+		LeaguePowerData: []*rankings.LeaguePowerData{powerData},
+		SiteConfig:      mockSiteConfig(),
+	}
+
+	templates := NewTemplates()
+	err := templates.WriteRankingsTemplate(mockWriter(), content)
+	if err != nil {
+		t.Fatalf("Writing rankings template failed with err='%s'", err.Error())
+	}
+}
+
+func TestWriteRankingsTemplatePlayoffOdds(t *testing.T) {
+	powerData := mockLeaguePowerData()
+	teamOdds := &rankings.TeamPlayoffOdds{
+		Team:        powerData.OverallRankings[0].Team,
+		SeedOdds:    []float64{0.5, 0.5},
+		PlayoffOdds: 0.75,
+		ByeOdds:     0.25,
+		AverageWins: 8.5,
+		AverageSeed: 1.5,
+	}
+	powerData.ByTeam = make(map[string]*rankings.TeamPowerData)
+	for _, teamData := range powerData.OverallRankings {
+		powerData.ByTeam[teamData.Team.TeamKey] = teamData
+	}
+	powerData.PlayoffOdds = &rankings.PlayoffOdds{
+		Simulations:  100,
+		PlayoffTeams: 6,
+		ByeTeams:     2,
+		Teams:        []*rankings.TeamPlayoffOdds{teamOdds},
+	}
+	content := &RankingsPageContent{
+		Weeks:           3,
+		LeagueStarted:   true,
+		SchemeToShow:    mockRecordScheme{},
+		Schemes:         []rankings.Scheme{mockRecordScheme{}},
+		League:          &(mockLeagues()[0]),
+		LeaguePowerData: []*rankings.LeaguePowerData{powerData},
+		SiteConfig:      mockSiteConfig(),
+	}
+
+	var buffer bytes.Buffer
+	templates := NewTemplates()
+	err := templates.WriteRankingsTemplate(&buffer, content)
+	if err != nil {
+		t.Fatalf("Writing rankings template failed with err='%s'", err.Error())
+	}
+	if !strings.Contains(buffer.String(), "playoff-odds-table") ||
+		!strings.Contains(buffer.String(), "75.0%") {
+		t.Fatalf("Rankings template is missing the playoff odds")
+	}
+}
+
+func TestWriteRankingsTemplateSeasonModeLinks(t *testing.T) {
+	powerData := mockLeaguePowerData()
+	powerData.ByTeam = make(map[string]*rankings.TeamPowerData)
+	for _, teamData := range powerData.OverallRankings {
+		powerData.ByTeam[teamData.Team.TeamKey] = teamData
+	}
+	weights, _ := rankings.ParseCompositeWeights("all-play:60,record:40")
+	tieBreakers, _ := rankings.ParseTieBreakers("head-to-head,coin-flip")
+	content := &RankingsPageContent{
+		Weeks:            3,
+		LeagueStarted:    true,
+		SchemeToShow:     mockRecordScheme{},
+		Schemes:          []rankings.Scheme{mockRecordScheme{}},
+		League:           &(mockLeagues()[0]),
+		LeaguePowerData:  []*rankings.LeaguePowerData{powerData},
+		SiteConfig:       mockSiteConfig(),
+		CompositeWeights: weights,
+		TieBreakers:      tieBreakers,
+		SeasonMode:       rankings.SeasonModes.PLAYOFFS,
+		Projector:        rankings.GetProjectors()[0],
+		Projectors:       rankings.GetProjectors(),
+	}
+
+	var buffer bytes.Buffer
+	templates := NewTemplates()
+	err := templates.WriteRankingsTemplate(&buffer, content)
+	if err != nil {
+		t.Fatalf("Writing rankings template failed with err='%s'", err.Error())
+	}
+
+	// The options of the rankings are kept when choosing the weeks to rank
+	options := "&projector=" + rankings.GetProjectors()[0].ID() +
+		"&weights=all-play%3a60%2crecord%3a40" +
+		"&tiebreakers=head-to-head%2ccoin-flip"
+	for _, link := range []string{
+		"season=regular&live=false" + options,
+		"season=playoffs&live=false" + options,
+	} {
+		if !strings.Contains(buffer.String(), link) {
+			t.Fatalf("Rankings template is missing season mode link with %s:\n%s",
+				link,
+				buffer.String())
+		}
+	}
+	if !strings.Contains(buffer.String(), "-with-playoffs.csv") {
+		t.Fatalf("Rankings export filename is missing the season mode")
+	}
+}
+
+func TestWriteRankingsTemplateOffline(t *testing.T) {
+	powerData := mockLeaguePowerData()
+	for _, teamData := range powerData.OverallRankings {
+		teamData.Team.TeamLogos = nil
+	}
+	content := &RankingsPageContent{
+		Weeks:           12,
+		SchemeToShow:    mockRecordScheme{},
+		Schemes:         []rankings.Scheme{mockRecordScheme{}},
+		League:          &(mockLeagues()[0]),
+		LeaguePowerData: []*rankings.LeaguePowerData{powerData},
+		SiteConfig:      mockSiteConfig(),
+		Offline:         true,
+	}
+
+	var buffer bytes.Buffer
+	templates := NewTemplates()
+	err := templates.WriteRankingsTemplate(&buffer, content)
+	if err != nil {
+		t.Fatalf("Writing offline rankings template failed with err='%s'", err.Error())
+	}
+	if strings.Contains(buffer.String(), "schedule-swap-link") {
+		t.Fatalf("Offline rankings link to the schedule swap page")
+	}
+}
+
 func TestWriteRankingsTemplateNilLeaguePowerData(t *testing.T) {
 	content := &RankingsPageContent{
 		Weeks:           12,
@@ -94,6 +246,69 @@ func TestWriteRankingsTemplateError(t *testing.T) {
 	}
 }
 
+func TestWriteScheduleSwapTemplate(t *testing.T) {
+	content := &ScheduleSwapPageContent{
+		Weeks:         2,
+		League:        &(mockLeagues()[0]),
+		LeagueStarted: true,
+		ScheduleSwap:  mockScheduleSwap(),
+		LoggedIn:      true,
+		SiteConfig:    mockSiteConfig(),
+	}
+
+	templates := NewTemplates()
+	err := templates.WriteScheduleSwapTemplate(mockWriter(), content)
+	if err != nil {
+		t.Fatalf("Writing schedule swap template failed with err='%s'", err.Error())
+	}
+}
+
+func TestWriteScheduleSwapTemplateError(t *testing.T) {
+	content := &ScheduleSwapPageContent{
+		Weeks:         2,
+		League:        &(mockLeagues()[0]),
+		LeagueStarted: true,
+		ScheduleSwap:  mockScheduleSwap(),
+		LoggedIn:      true,
+		SiteConfig:    mockSiteConfig(),
+	}
+
+	templates := NewTemplatesFromDir("dir-does-not-exist/")
+	err := templates.WriteScheduleSwapTemplate(mockWriter(), content)
+	if err == nil {
+		t.Fatalf("Writing schedule swap template did not fail with non-existent dir")
+	}
+}
+
+func TestWriteUploadTemplate(t *testing.T) {
+	content := &UploadPageContent{
+		Message:    "Unable to read league.csv",
+		Providers:  providers.GetProviders(),
+		Year:       "2021",
+		LoggedIn:   false,
+		SiteConfig: mockSiteConfig(),
+	}
+
+	templates := NewTemplates()
+	err := templates.WriteUploadTemplate(mockWriter(), content)
+	if err != nil {
+		t.Fatalf("Writing upload template failed with err='%s'", err.Error())
+	}
+}
+
+func TestWriteUploadTemplateError(t *testing.T) {
+	content := &UploadPageContent{
+		LoggedIn:   false,
+		SiteConfig: mockSiteConfig(),
+	}
+
+	templates := NewTemplatesFromDir("dir-does-not-exist/")
+	err := templates.WriteUploadTemplate(mockWriter(), content)
+	if err == nil {
+		t.Fatalf("Writing upload template did not fail with invalid directory")
+	}
+}
+
 func TestWriteAboutTemplate(t *testing.T) {
 	content := &AboutPageContent{
 		LoggedIn:   true,
@@ -634,9 +849,133 @@ func TestTemplateGetExportFilename(t *testing.T) {
 	}
 }
 
+func TestTemplateGetSeasonModeFilename(t *testing.T) {
+	for seasonMode, expected := range map[string]string{
+		rankings.SeasonModes.REGULAR:  "regular-season",
+		rankings.SeasonModes.PLAYOFFS: "with-playoffs",
+	} {
+		actual := templateGetSeasonModeFilename(seasonMode)
+		if actual != expected {
+			t.Fatalf("Unexpected filename for season mode %s:\n\t"+
+				"Expected: %s\n\tActual: %s",
+				seasonMode,
+				expected,
+				actual)
+		}
+	}
+}
+
+func TestTemplateHasManagerEfficiency(t *testing.T) {
+	leagueData := mockLeaguePowerData()
+	if !templateHasManagerEfficiency(leagueData) {
+		t.Fatal("Manager efficiency not found for league with efficiency")
+	}
+
+	for _, teamData := range leagueData.OverallRankings {
+		teamData.ManagerEfficiency = 0.0
+	}
+	if templateHasManagerEfficiency(leagueData) {
+		t.Fatal("Manager efficiency found for league without efficiency")
+	}
+}
+
+func TestTemplateGetPercentage(t *testing.T) {
+	actual := templateGetPercentage(0.8754)
+	if actual != "87.5%" {
+		t.Fatalf("Unexpected percentage\n\tExpected: 87.5%%\n\tActual: %s",
+			actual)
+	}
+}
+
+func TestTemplateGetWinPercentageOffset(t *testing.T) {
+	actual := templateGetWinPercentageOffset(
+		goff.Record{Wins: 5, Losses: 2, Ties: 1},
+		0.5)
+	if actual != 0.1875 {
+		t.Fatalf("Unexpected win percentage offset\n\tExpected: %f\n\t"+
+			"Actual: %f",
+			0.1875,
+			actual)
+	}
+
+	actual = templateGetWinPercentageOffset(goff.Record{}, 0.5)
+	if actual != 0.0 {
+		t.Fatalf("Win percentage offset given for team without any games: %f",
+			actual)
+	}
+}
+
+func TestTemplateGetRecordOffset(t *testing.T) {
+	actual := templateGetRecordOffset(
+		&goff.Record{Wins: 3, Losses: 0, Ties: 1},
+		&goff.Record{Wins: 1, Losses: 3, Ties: 0})
+	if actual != 2.5 {
+		t.Fatalf("Unexpected record offset\n\tExpected: %f\n\tActual: %f",
+			2.5,
+			actual)
+	}
+}
+
+func TestTemplateGetScheduleSwapCSVContent(t *testing.T) {
+	csvBase64 := templateGetScheduleSwapCSVContent(mockScheduleSwap())
+	csv, err := base64.StdEncoding.DecodeString(csvBase64)
+	if err != nil {
+		t.Fatalf("Error decoding content from Base64: %s", err)
+	}
+
+	expected := "Team,Team A Schedule,Team B Schedule\n" +
+		"Team A,2-0-0,1-0-1\n" +
+		"Team B,0-2-0,0-1-1\n"
+	if string(csv) != expected {
+		t.Fatalf("Unexpected schedule swap CSV content:\n\tExpected: %s"+
+			"\n\tActual: %s",
+			expected,
+			string(csv))
+	}
+}
+
+func TestTemplateGetHeatmapLevel(t *testing.T) {
+	for _, test := range []struct {
+		Record   *goff.Record
+		Expected int
+	}{
+		{&goff.Record{}, -1},
+		{&goff.Record{Losses: 3}, 0},
+		{&goff.Record{Wins: 1, Ties: 1, Losses: 1}, 2},
+		{&goff.Record{Wins: 3}, 4},
+	} {
+		actual := templateGetHeatmapLevel(test.Record)
+		if actual != test.Expected {
+			t.Fatalf("Unexpected heatmap level for record %+v\n\t"+
+				"Expected: %d\n\tActual: %d",
+				test.Record,
+				test.Expected,
+				actual)
+		}
+	}
+}
+
+func TestTemplateGetHeadToHeadCSVContent(t *testing.T) {
+	csvBase64 := templateGetHeadToHeadCSVContent(mockHeadToHead())
+	csv, err := base64.StdEncoding.DecodeString(csvBase64)
+	if err != nil {
+		t.Fatalf("Error decoding content from Base64: %s", err)
+	}
+
+	expected := "Team,vs. Team A,vs. Team A All-Play,vs. Team B,vs. Team B All-Play\n" +
+		"Team A,,,1-0-0,2-1-0\n" +
+		"Team B,0-1-0,1-2-0,,\n"
+	if string(csv) != expected {
+		t.Fatalf("Unexpected head-to-head CSV content:\n\tExpected: %s"+
+			"\n\tActual: %s",
+			expected,
+			string(csv))
+	}
+}
+
 func TestTemplateGetCSVContentRecordScheme(t *testing.T) {
 	leagueData := mockLeaguePowerData()
-	csvBase64 := templateGetCSVContent(leagueData)
+	csvBase64 := templateGetCSVContent(leagueData, rankings.SeasonModes.REGULAR)
 	csv, err := base64.StdEncoding.DecodeString(csvBase64)
 	if err != nil {
 		t.Fatalf("Error decoding content from Base64: %s", err)
@@ -654,7 +993,20 @@ func TestTemplateGetCSVContentRecordScheme(t *testing.T) {
 			"Projected Mock Scheme Record," +
 			"League Rank," +
 			"League Rank Offset," +
-			"League Record"
+			"League Record," +
+			"Expected Wins," +
+			"Luck," +
+			"Strength of Schedule," +
+			"Remaining Strength of Schedule," +
+			"Manager Efficiency," +
+			"Mean Score," +
+			"Score Standard Deviation," +
+			"Coefficient of Variation," +
+			"Floor," +
+			"Ceiling," +
+			"Boom Weeks," +
+			"Bust Weeks," +
+			"Weeks Ranked"
 
 	for _, weeklyRanking := range leagueData.ByWeek {
 		var weekStr string
@@ -679,6 +1031,10 @@ func TestTemplateGetCSVContentRecordScheme(t *testing.T) {
 	for _, teamData := range leagueData.OverallRankings {
 		csvScanner.Scan()
 		teamContent := csvScanner.Text()
+		efficiency := ""
+		if teamData.ManagerEfficiency > 0 {
+			efficiency = fmt.Sprintf("%.3f", teamData.ManagerEfficiency)
+		}
 		expectedContent :=
 			fmt.Sprintf(
 				"%d,"+
@@ -689,7 +1045,20 @@ func TestTemplateGetCSVContentRecordScheme(t *testing.T) {
 					"%d-%d-%d,"+
 					"%d,"+
 					"%s,"+
-					"%d-%d-%d",
+					"%d-%d-%d,"+
+					"%.2f,"+
+					"%.2f,"+
+					"%.3f,"+
+					"%.3f,"+
+					"%s,"+
+					"%.2f,"+
+					"%.2f,"+
+					"%.3f,"+
+					"%.2f,"+
+					"%.2f,"+
+					"%d,"+
+					"%d,"+
+					"%s",
 				teamData.Rank,
 				teamData.ProjectedRank,
 				teamData.Team.Name,
@@ -704,7 +1073,20 @@ func TestTemplateGetCSVContentRecordScheme(t *testing.T) {
 				templateGetRankOffset(teamData.Rank, teamData.Team.TeamStandings.Rank),
 				teamData.Team.TeamStandings.Record.Wins,
 				teamData.Team.TeamStandings.Record.Losses,
-				teamData.Team.TeamStandings.Record.Ties)
+				teamData.Team.TeamStandings.Record.Ties,
+				teamData.ExpectedWins,
+				teamData.Luck,
+				teamData.StrengthOfSchedule,
+				teamData.RemainingStrengthOfSchedule,
+				efficiency,
+				teamData.MeanScore,
+				teamData.ScoreStandardDeviation,
+				teamData.CoefficientOfVariation,
+				teamData.Floor,
+				teamData.Ceiling,
+				teamData.BoomWeeks,
+				teamData.BustWeeks,
+				"Regular Season")
 		for i, teamScoreData := range teamData.AllScores {
 			ranking := teamData.AllRankings[i]
 			expectedContent +=
@@ -732,7 +1114,7 @@ func TestTemplateGetCSVContentRecordScheme(t *testing.T) {
 func TestTemplateGetCSVContentScoreScheme(t *testing.T) {
 	leagueData := mockLeaguePowerData()
 	leagueData.RankingScheme = mockScoreScheme{}
-	csvBase64 := templateGetCSVContent(leagueData)
+	csvBase64 := templateGetCSVContent(leagueData, rankings.SeasonModes.PLAYOFFS)
 	csv, err := base64.StdEncoding.DecodeString(csvBase64)
 	if err != nil {
 		t.Fatalf("Error decoding content from Base64: %s", err)
@@ -750,7 +1132,20 @@ func TestTemplateGetCSVContentScoreScheme(t *testing.T) {
 			"Projected Mock Scheme Points," +
 			"League Rank," +
 			"League Rank Offset," +
-			"League Record"
+			"League Record," +
+			"Expected Wins," +
+			"Luck," +
+			"Strength of Schedule," +
+			"Remaining Strength of Schedule," +
+			"Manager Efficiency," +
+			"Mean Score," +
+			"Score Standard Deviation," +
+			"Coefficient of Variation," +
+			"Floor," +
+			"Ceiling," +
+			"Boom Weeks," +
+			"Bust Weeks," +
+			"Weeks Ranked"
 
 	for _, weeklyRanking := range leagueData.ByWeek {
 		var weekStr string
@@ -775,6 +1170,10 @@ func TestTemplateGetCSVContentScoreScheme(t *testing.T) {
 	for _, teamData := range leagueData.OverallRankings {
 		csvScanner.Scan()
 		teamContent := csvScanner.Text()
+		efficiency := ""
+		if teamData.ManagerEfficiency > 0 {
+			efficiency = fmt.Sprintf("%.3f", teamData.ManagerEfficiency)
+		}
 		expectedContent :=
 			fmt.Sprintf(
 				"%d,"+
@@ -785,7 +1184,20 @@ func TestTemplateGetCSVContentScoreScheme(t *testing.T) {
 					"%.2f,"+
 					"%d,"+
 					"%s,"+
-					"%d-%d-%d",
+					"%d-%d-%d,"+
+					"%.2f,"+
+					"%.2f,"+
+					"%.3f,"+
+					"%.3f,"+
+					"%s,"+
+					"%.2f,"+
+					"%.2f,"+
+					"%.3f,"+
+					"%.2f,"+
+					"%.2f,"+
+					"%d,"+
+					"%d,"+
+					"%s",
 				teamData.Rank,
 				teamData.ProjectedRank,
 				teamData.Team.Name,
@@ -796,7 +1208,20 @@ func TestTemplateGetCSVContentScoreScheme(t *testing.T) {
 				templateGetRankOffset(teamData.Rank, teamData.Team.TeamStandings.Rank),
 				teamData.Team.TeamStandings.Record.Wins,
 				teamData.Team.TeamStandings.Record.Losses,
-				teamData.Team.TeamStandings.Record.Ties)
+				teamData.Team.TeamStandings.Record.Ties,
+				teamData.ExpectedWins,
+				teamData.Luck,
+				teamData.StrengthOfSchedule,
+				teamData.RemainingStrengthOfSchedule,
+				efficiency,
+				teamData.MeanScore,
+				teamData.ScoreStandardDeviation,
+				teamData.CoefficientOfVariation,
+				teamData.Floor,
+				teamData.Ceiling,
+				teamData.BoomWeeks,
+				teamData.BustWeeks,
+				"With Playoffs")
 		for i, teamScoreData := range teamData.AllScores {
 			ranking := teamData.AllRankings[i]
 			expectedContent +=
@@ -958,6 +1383,7 @@ func mockLeaguePowerData() *rankings.LeaguePowerData {
 	ownerTeam.IsOwnedByCurrentLogin = true
 	return &rankings.LeaguePowerData{
 		RankingScheme: mockRecordScheme{},
+		HeadToHead:    mockHeadToHead(),
 		OverallRankings: rankings.PowerRankings{
 			&rankings.TeamPowerData{
 				AllScores: []*rankings.TeamScoreData{
@@ -995,6 +1421,12 @@ func mockLeaguePowerData() *rankings.LeaguePowerData {
 				},
 				Rank:          1,
 				ProjectedRank: 3,
+				ExpectedWins:  4.75,
+				Luck:          -0.75,
+
+				StrengthOfSchedule:          0.625,
+				RemainingStrengthOfSchedule: 0.4,
+				ManagerEfficiency:           0.875,
 				AllRankings: []*rankings.TeamRankingData{
 					&rankings.TeamRankingData{
 						Week:  1,
@@ -1531,3 +1963,53 @@ func mockTeams() []goff.Team {
 		},
 	}
 }
+
+func mockScheduleSwap() *rankings.ScheduleSwap {
+	return &rankings.ScheduleSwap{
+		Teams: []*goff.Team{
+			&goff.Team{TeamKey: "a", Name: "Team A"},
+			&goff.Team{TeamKey: "b", Name: "Team B"},
+		},
+		Records: [][]*goff.Record{
+			[]*goff.Record{
+				&goff.Record{Wins: 2},
+				&goff.Record{Wins: 1, Ties: 1},
+			},
+			[]*goff.Record{
+				&goff.Record{Losses: 2},
+				&goff.Record{Losses: 1, Ties: 1},
+			},
+		},
+		Weeks: 2,
+	}
+}
+
+func mockHeadToHead() *rankings.HeadToHead {
+	return &rankings.HeadToHead{
+		Teams: []*goff.Team{
+			&goff.Team{TeamKey: "a", Name: "Team A"},
+			&goff.Team{TeamKey: "b", Name: "Team B"},
+		},
+		Records: [][]*goff.Record{
+			[]*goff.Record{
+				&goff.Record{},
+				&goff.Record{Wins: 1},
+			},
+			[]*goff.Record{
+				&goff.Record{Losses: 1},
+				&goff.Record{},
+			},
+		},
+		AllPlayRecords: [][]*goff.Record{
+			[]*goff.Record{
+				&goff.Record{},
+				&goff.Record{Wins: 2, Losses: 1},
+			},
+			[]*goff.Record{
+				&goff.Record{Wins: 1, Losses: 2},
+				&goff.Record{},
+			},
+		},
+		Weeks: 3,
+	}
+}